@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/codegangsta/cli"
 	"github.com/supergiant/supergiant/pkg/core"
-	"github.com/supergiant/supergiant/pkg/provider/aws"
 	"github.com/supergiant/supergiant/pkg/server"
+
+	// Providers register themselves with core
+	_ "github.com/supergiant/supergiant/pkg/provider/aws"
+	_ "github.com/supergiant/supergiant/pkg/provider/digitalocean"
+	_ "github.com/supergiant/supergiant/pkg/provider/external"
+	_ "github.com/supergiant/supergiant/pkg/provider/fake"
+	_ "github.com/supergiant/supergiant/pkg/provider/gce"
+	_ "github.com/supergiant/supergiant/pkg/provider/openstack"
 )
 
 func main() {
@@ -23,11 +34,6 @@ func main() {
 		// TODO should check for missing setting here to show cli help
 		c.Initialize()
 
-		// See relevant NOTE in core.go
-		c.AWSProvider = func(creds map[string]string) core.Provider {
-			return &aws.Provider{Core: c, Credentials: creds}
-		}
-
 		// We do this here, and not in core, so that we can ensure the file closes on exit.
 		if c.LogPath != "" {
 			file, err := os.OpenFile(c.LogPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
@@ -48,7 +54,33 @@ func main() {
 			panic(err)
 		}
 
-		srv.Start()
+		// Shut down gracefully on SIGINT / SIGTERM
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+			sig := <-signals
+			c.Log.Infof("Received %s, shutting down", sig)
+
+			// A second signal skips waiting
+			go func() {
+				<-signals
+				os.Exit(1)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), c.ShutdownDeadline())
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil {
+				c.Log.Error("Error shutting down: ", err)
+			}
+		}()
+
+		if err := srv.Start(); err != http.ErrServerClosed {
+			panic(err)
+		}
+		<-done
 	}
 
 	app.Flags = []cli.Flag{
@@ -118,6 +150,11 @@ func main() {
 			Destination: &c.LogLevel,
 			// Value:  <--- NOTE just cuz you always forget you can set defaults
 		},
+		cli.IntFlag{
+			Name:        "shutdown-timeout",
+			Usage:       "Seconds to wait on shutdown for requests and Actions to finish (default 60)",
+			Destination: &c.ShutdownTimeout,
+		},
 		cli.StringFlag{
 			Name:        "config-file",
 			Usage:       "JSON config filepath (command line arguments will override the values set here)",
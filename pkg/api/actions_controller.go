@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// NOTE Actions are not stored like other models (the leader's in-memory Actions
+// are the source of truth), so they are looked up by the UUID of their resource
+// and filtered here instead of with handleList.
+
+func ListActions(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	qstr := r.URL.Query()
+	resourceType := qstr.Get("resource_type")
+	state := qstr.Get("state")
+
+	records, err := core.Actions.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.Action, 0)
+	for _, item := range records {
+		if resourceType != "" && item.ResourceType != resourceType {
+			continue
+		}
+		if state != "" && item.State != state {
+			continue
+		}
+		items = append(items, item)
+	}
+	return &Response{http.StatusOK, items}, nil
+}
+
+func GetAction(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item, err := core.Actions.Record(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, err
+	}
+	return &Response{http.StatusOK, item}, nil
+}
+
+func RetryAction(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item, err := core.Actions.Retry(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, err
+	}
+	return &Response{http.StatusAccepted, item}, nil
+}
+
+func CancelAction(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item, err := core.Actions.Cancel(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, err
+	}
+	return &Response{http.StatusAccepted, item}, nil
+}
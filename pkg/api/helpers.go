@@ -43,7 +43,7 @@ func errorHTTPStatus(err error) int {
 	if _, ok := err.(*bodyDecodingError); ok {
 		return 400
 	}
-	if err == core.ErrorBadLogin {
+	if err == core.ErrorBadLogin || err == core.ErrorActionNotFailed || err == core.ErrorKubeAlreadyProvisioned || err == core.ErrorKubeExternal || err == core.ErrorSnapshotsUnsupported || err == core.ErrorSnapshotRestoredFrom || err == core.ErrorVolumeNotRetained {
 		return 400
 	}
 	if err == errorUnauthorized || err == errorBadAuthHeader {
@@ -52,9 +52,12 @@ func errorHTTPStatus(err error) int {
 	if _, ok := err.(*errorForbidden); ok {
 		return 403
 	}
-	if err == gorm.ErrRecordNotFound {
+	if err == gorm.ErrRecordNotFound || err == core.ErrorActionNotFound {
 		return 404
 	}
+	if _, ok := err.(*core.RepeatedActionError); ok {
+		return 409
+	}
 	return 500
 }
 
@@ -215,11 +218,17 @@ func handleList(core *core.Core, r *http.Request, m model.Model) (*Response, err
 	qstr := r.URL.Query()
 
 	var andQueries []string
+	var andArgs []interface{}
 	for _, field := range model.IndexedFields(m) {
 		if val := qstr.Get(field.JSONName); val != "" {
-			if field.Kind == reflect.Int64 {
+			switch field.Kind {
+			case reflect.Int64:
 				andQueries = append(andQueries, fmt.Sprintf("%s = %s", field.JSONName, val))
-			} else { // string
+			case reflect.Bool:
+				// NOTE given as an argument, as databases store bools differently.
+				andQueries = append(andQueries, fmt.Sprintf("%s = ?", field.JSONName))
+				andArgs = append(andArgs, val == "true")
+			default: // string
 				andQueries = append(andQueries, fmt.Sprintf("%s = '%s'", field.JSONName, val))
 			}
 		}
@@ -228,7 +237,7 @@ func handleList(core *core.Core, r *http.Request, m model.Model) (*Response, err
 
 	scope := core.DB
 	if andQuery != "" {
-		scope = scope.Where(andQuery)
+		scope = scope.Where(andQuery, andArgs...)
 	}
 
 	if err := scope.Find(itemsPtr.Interface()); err != nil {
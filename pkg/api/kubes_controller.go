@@ -60,3 +60,15 @@ func DeleteKube(core *core.Core, user *model.User, r *http.Request) (*Response,
 	}
 	return itemResponse(core, item, http.StatusAccepted)
 }
+
+func ResumeKube(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Kube)
+	id, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.Kubes.Resume(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusAccepted)
+}
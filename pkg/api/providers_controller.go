@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// NOTE Providers are registered in core, not stored, so they are only listed.
+
+func ListProviders(c *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	items := make([]*model.Provider, 0)
+	for _, def := range core.ProviderDefinitions() {
+		items = append(items, def.Describe())
+	}
+	return &Response{http.StatusOK, items}, nil
+}
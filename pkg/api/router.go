@@ -25,6 +25,8 @@ func NewRouter(core *core.Core) *mux.Router {
 	s.HandleFunc("/users/{id}", restrictedHandler(core, DeleteUser)).Methods("DELETE")
 	s.HandleFunc("/users/{id}/regenerate_api_token", restrictedHandler(core, RegenerateUserAPIToken)).Methods("POST")
 
+	s.HandleFunc("/providers", restrictedHandler(core, ListProviders)).Methods("GET")
+
 	s.HandleFunc("/cloud_accounts", restrictedHandler(core, CreateCloudAccount)).Methods("POST")
 	s.HandleFunc("/cloud_accounts", restrictedHandler(core, ListCloudAccounts)).Methods("GET")
 	s.HandleFunc("/cloud_accounts/{id}", restrictedHandler(core, GetCloudAccount)).Methods("GET")
@@ -36,6 +38,7 @@ func NewRouter(core *core.Core) *mux.Router {
 	s.HandleFunc("/kubes/{id}", restrictedHandler(core, GetKube)).Methods("GET")
 	s.HandleFunc("/kubes/{id}", restrictedHandler(core, UpdateKube)).Methods("PATCH", "PUT")
 	s.HandleFunc("/kubes/{id}", restrictedHandler(core, DeleteKube)).Methods("DELETE")
+	s.HandleFunc("/kubes/{id}/resume", restrictedHandler(core, ResumeKube)).Methods("POST")
 
 	s.HandleFunc("/apps", restrictedHandler(core, CreateApp)).Methods("POST")
 	s.HandleFunc("/apps", restrictedHandler(core, ListApps)).Methods("GET")
@@ -65,6 +68,15 @@ func NewRouter(core *core.Core) *mux.Router {
 
 	s.HandleFunc("/volumes", restrictedHandler(core, ListVolumes)).Methods("GET")
 	s.HandleFunc("/volumes/{id}", restrictedHandler(core, GetVolume)).Methods("GET")
+	s.HandleFunc("/volumes/{id}", restrictedHandler(core, DeleteVolume)).Methods("DELETE")
+	s.HandleFunc("/volumes/{id}/snapshots", restrictedHandler(core, CreateVolumeSnapshot)).Methods("POST")
+	s.HandleFunc("/volumes/{id}/snapshots", restrictedHandler(core, ListVolumeSnapshots)).Methods("GET")
+	s.HandleFunc("/volumes/{id}/snapshots/{snapshot_id}", restrictedHandler(core, GetVolumeSnapshot)).Methods("GET")
+	s.HandleFunc("/volumes/{id}/snapshots/{snapshot_id}", restrictedHandler(core, DeleteVolumeSnapshot)).Methods("DELETE")
+
+	s.HandleFunc("/snapshots", restrictedHandler(core, ListSnapshots)).Methods("GET")
+	s.HandleFunc("/snapshots/{id}", restrictedHandler(core, GetSnapshot)).Methods("GET")
+	s.HandleFunc("/snapshots/{id}", restrictedHandler(core, DeleteSnapshot)).Methods("DELETE")
 
 	s.HandleFunc("/private_image_keys", restrictedHandler(core, CreatePrivateImageKey)).Methods("POST")
 	s.HandleFunc("/private_image_keys", restrictedHandler(core, ListPrivateImageKeys)).Methods("GET")
@@ -84,6 +96,17 @@ func NewRouter(core *core.Core) *mux.Router {
 	s.HandleFunc("/nodes/{id}", restrictedHandler(core, UpdateNode)).Methods("PATCH", "PUT")
 	s.HandleFunc("/nodes/{id}", restrictedHandler(core, DeleteNode)).Methods("DELETE")
 
+	s.HandleFunc("/services", restrictedHandler(core, ListServices)).Methods("GET")
+	s.HandleFunc("/services/{id}", restrictedHandler(core, GetService)).Methods("GET")
+	s.HandleFunc("/services/{id}/pause", restrictedHandler(core, PauseService)).Methods("POST")
+	s.HandleFunc("/services/{id}/resume", restrictedHandler(core, ResumeService)).Methods("POST")
+	s.HandleFunc("/services/{id}/run_now", restrictedHandler(core, RunService)).Methods("POST")
+
+	s.HandleFunc("/actions", restrictedHandler(core, ListActions)).Methods("GET")
+	s.HandleFunc("/actions/{id}", restrictedHandler(core, GetAction)).Methods("GET")
+	s.HandleFunc("/actions/{id}/retry", restrictedHandler(core, RetryAction)).Methods("POST")
+	s.HandleFunc("/actions/{id}/cancel", restrictedHandler(core, CancelAction)).Methods("POST")
+
 	s.HandleFunc("/log", logHandler(core)).Methods("GET")
 
 	return r
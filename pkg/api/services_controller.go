@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+func ListServices(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	return handleList(core, r, new(model.Service))
+}
+
+func GetService(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Service)
+	id, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.Services.Get(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusOK)
+}
+
+func PauseService(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	return updateService(core, user, r, core.Services.Pause)
+}
+
+func ResumeService(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	return updateService(core, user, r, core.Services.Resume)
+}
+
+func RunService(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	return updateService(core, user, r, core.Services.RunNow)
+}
+
+//------------------------------------------------------------------------------
+
+func updateService(core *core.Core, user *model.User, r *http.Request, fn func(*int64, *model.Service) error) (*Response, error) {
+	if err := ensureAdmin(user); err != nil {
+		return nil, err
+	}
+	item := new(model.Service)
+	id, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusAccepted)
+}
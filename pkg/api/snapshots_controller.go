@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+func ListSnapshots(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	return handleList(core, r, new(model.Snapshot))
+}
+
+func GetSnapshot(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Snapshot)
+	id, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.Snapshots.Get(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusOK)
+}
+
+func DeleteSnapshot(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Snapshot)
+	id, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.Snapshots.DeleteUnused(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusAccepted)
+}
+
+// The following are the Snapshots of the Volume given by {id}.
+
+func CreateVolumeSnapshot(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	volumeID, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	item := new(model.Snapshot)
+	if err := decodeBodyInto(r, item); err != nil {
+		return nil, err
+	}
+	item.VolumeID = volumeID
+	if err := core.Snapshots.Create(item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusCreated)
+}
+
+func ListVolumeSnapshots(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	volumeID, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	// NOTE handleList filters by the volume_id query value.
+	q := r.URL.Query()
+	q.Set("volume_id", strconv.FormatInt(*volumeID, 10))
+	r.URL.RawQuery = q.Encode()
+	return handleList(core, r, new(model.Snapshot))
+}
+
+func GetVolumeSnapshot(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Snapshot)
+	volumeID, id, err := parseVolumeSnapshotIDs(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.DB.Where("volume_id = ?", volumeID).First(item, *id); err != nil {
+		return nil, err
+	}
+	core.SetResourceActionStatus(item)
+	return itemResponse(core, item, http.StatusOK)
+}
+
+func DeleteVolumeSnapshot(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Snapshot)
+	volumeID, id, err := parseVolumeSnapshotIDs(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.DB.Where("volume_id = ?", volumeID).First(item, *id); err != nil {
+		return nil, err
+	}
+	if err := core.Snapshots.DeleteUnused(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusAccepted)
+}
+
+func parseVolumeSnapshotIDs(r *http.Request) (volumeID *int64, id *int64, err error) {
+	if volumeID, err = parseID(r); err != nil {
+		return nil, nil, err
+	}
+	snapshotID, err := strconv.ParseInt(mux.Vars(r)["snapshot_id"], 10, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	return volumeID, &snapshotID, nil
+}
@@ -22,3 +22,16 @@ func GetVolume(core *core.Core, user *model.User, r *http.Request) (*Response, e
 	}
 	return itemResponse(core, item, http.StatusOK)
 }
+
+// DeleteVolume deletes a retained Volume, whose Instance was deleted.
+func DeleteVolume(core *core.Core, user *model.User, r *http.Request) (*Response, error) {
+	item := new(model.Volume)
+	id, err := parseID(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.Volumes.DeleteRetained(id, item); err != nil {
+		return nil, err
+	}
+	return itemResponse(core, item, http.StatusAccepted)
+}
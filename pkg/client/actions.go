@@ -0,0 +1,16 @@
+package client
+
+import "github.com/supergiant/supergiant/pkg/model"
+
+// NOTE Actions are identified by the UUID of the resource they act on.
+type Actions struct {
+	Collection
+}
+
+func (c *Actions) Retry(m *model.Action) error {
+	return c.client.request("POST", c.memberPath(m.ResourceUUID)+"/retry", nil, m, nil)
+}
+
+func (c *Actions) Cancel(m *model.Action) error {
+	return c.client.request("POST", c.memberPath(m.ResourceUUID)+"/cancel", nil, m, nil)
+}
@@ -22,6 +22,7 @@ type Client struct {
 
 	Sessions         *Sessions
 	Users            *Users
+	Providers        *Providers
 	CloudAccounts    *CloudAccounts
 	Kubes            *Kubes
 	Apps             *Apps
@@ -29,9 +30,12 @@ type Client struct {
 	Releases         *Releases
 	Instances        *Instances
 	Volumes          *Volumes
+	Snapshots        *Snapshots
 	PrivateImageKeys *PrivateImageKeys
 	Entrypoints      *Entrypoints
 	Nodes            *Nodes
+	Actions          *Actions
+	Services         *Services
 }
 
 func New(url string, authType string, authToken string, certFile string) *Client {
@@ -63,6 +67,7 @@ func New(url string, authType string, authToken string, certFile string) *Client
 
 	client.Sessions = &Sessions{Collection{client, "sessions"}}
 	client.Users = &Users{Collection{client, "users"}}
+	client.Providers = &Providers{Collection{client, "providers"}}
 	client.CloudAccounts = &CloudAccounts{Collection{client, "cloud_accounts"}}
 	client.Kubes = &Kubes{Collection{client, "kubes"}}
 	client.Apps = &Apps{Collection{client, "apps"}}
@@ -70,9 +75,12 @@ func New(url string, authType string, authToken string, certFile string) *Client
 	client.Releases = &Releases{Collection{client, "releases"}}
 	client.Instances = &Instances{Collection{client, "instances"}}
 	client.Volumes = &Volumes{Collection{client, "volumes"}}
+	client.Snapshots = &Snapshots{Collection{client, "snapshots"}}
 	client.PrivateImageKeys = &PrivateImageKeys{Collection{client, "private_image_keys"}}
 	client.Entrypoints = &Entrypoints{Collection{client, "entrypoints"}}
 	client.Nodes = &Nodes{Collection{client, "nodes"}}
+	client.Actions = &Actions{Collection{client, "actions"}}
+	client.Services = &Services{Collection{client, "services"}}
 
 	return client
 }
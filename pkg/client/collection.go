@@ -17,6 +17,12 @@ func (c *Collection) List(items interface{}) error {
 	return c.client.request("GET", c.basePath, nil, items, nil)
 }
 
+// ListWithFilters takes query values (such as indexed field values) that are
+// used by the API to filter the list.
+func (c *Collection) ListWithFilters(items interface{}, filters map[string]string) error {
+	return c.client.request("GET", c.basePath, nil, items, filters)
+}
+
 func (c *Collection) Get(id interface{}, item model.Model) error {
 	return c.client.request("GET", c.memberPath(id), nil, item, nil)
 }
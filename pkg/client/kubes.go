@@ -1,5 +1,11 @@
 package client
 
+import "github.com/supergiant/supergiant/pkg/model"
+
 type Kubes struct {
 	Collection
 }
+
+func (c *Kubes) Resume(id interface{}, m *model.Kube) error {
+	return c.client.request("POST", c.memberPath(id)+"/resume", nil, m, nil)
+}
@@ -0,0 +1,6 @@
+package client
+
+// Providers only supports List, into a *[]*model.Provider.
+type Providers struct {
+	Collection
+}
@@ -0,0 +1,19 @@
+package client
+
+import "github.com/supergiant/supergiant/pkg/model"
+
+type Services struct {
+	Collection
+}
+
+func (c *Services) Pause(id interface{}, m *model.Service) error {
+	return c.client.request("POST", c.memberPath(id)+"/pause", nil, m, nil)
+}
+
+func (c *Services) Resume(id interface{}, m *model.Service) error {
+	return c.client.request("POST", c.memberPath(id)+"/resume", nil, m, nil)
+}
+
+func (c *Services) RunNow(id interface{}, m *model.Service) error {
+	return c.client.request("POST", c.memberPath(id)+"/run_now", nil, m, nil)
+}
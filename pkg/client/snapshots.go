@@ -0,0 +1,22 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/util"
+)
+
+type Snapshots struct {
+	Collection
+}
+
+func (c *Snapshots) WaitForReady(m *model.Snapshot) error {
+	return util.WaitFor(fmt.Sprintf("Snapshot %d to be ready", *m.ID), 4*time.Hour, 5*time.Second, func() (bool, error) {
+		if err := c.Get(m.ID, m); err != nil {
+			return false, err
+		}
+		return m.Ready, nil
+	})
+}
@@ -3,3 +3,14 @@ package client
 type Volumes struct {
 	Collection
 }
+
+// Snapshots returns the Snapshots of the Volume; Snapshots created with it are
+// of that Volume.
+func (c *Volumes) Snapshots(id *int64) *Snapshots {
+	return &Snapshots{Collection{c.client, c.memberPath(id) + "/snapshots"}}
+}
+
+// ListRetained lists the Volumes retained after their Instance was deleted.
+func (c *Volumes) ListRetained(items interface{}) error {
+	return c.ListWithFilters(items, map[string]string{"retained": "true"})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/supergiant/supergiant/pkg/model"
@@ -22,6 +23,16 @@ type Action struct {
 
 	fn             func(*Action) error
 	cancelExisting bool
+
+	// requester is the User performing the Action, if the Action needs one.
+	requester *model.User
+
+	async  bool
+	record *model.Action
+
+	// abandoned is set when the server stops being the leader (or shuts down),
+	// and stops the Action without touching its record.
+	abandoned bool
 }
 
 type RepeatedActionError struct {
@@ -35,8 +46,51 @@ func (err *RepeatedActionError) Error() string {
 //------------------------------------------------------------------------------
 
 func (a *Action) description() string {
-	modelType := strings.Split(reflect.TypeOf(a.model).String(), ".")[1]
-	return fmt.Sprintf("%s %s %s", a.Status.Description, modelType, a.resourceID)
+	return fmt.Sprintf("%s %s %s", a.Status.Description, a.modelType(), a.resourceID)
+}
+
+func (a *Action) modelType() string {
+	return strings.Split(reflect.TypeOf(a.model).String(), ".")[1]
+}
+
+func (a *Action) modelID() *int64 {
+	if a.id != nil {
+		return a.id
+	}
+	id, _ := a.model.GetID().(*int64)
+	return id
+}
+
+// failed is true when an Action has used up its retries without succeeding.
+func (a *Action) failed() bool {
+	return actionState(a.Status) == model.ActionStateFailed
+}
+
+func actionState(status *model.ActionStatus) string {
+	switch {
+	case status.Cancelled:
+		return model.ActionStateCancelled
+	case status.Error != "" && status.Retries >= status.MaxRetries:
+		return model.ActionStateFailed
+	default:
+		return model.ActionStateRunning
+	}
+}
+
+// Record returns the persisted representation of the Action, with its current
+// status and state.
+func (a *Action) Record() *model.Action {
+	m := new(model.Action)
+	if a.record != nil {
+		*m = *a.record
+	}
+	m.ResourceUUID = a.resourceID
+	m.ResourceType = a.modelType()
+	m.ResourceID = a.modelID()
+	m.Async = a.async
+	m.ActionStatus = *a.Status
+	m.State = actionState(a.Status)
+	return m
 }
 
 func (a *Action) prepare() error {
@@ -58,20 +112,81 @@ func (a *Action) stopUnlessCancelled() {
 
 func (a *Action) CancellableWaitFor(desc string, d time.Duration, i time.Duration, fn func() (bool, error)) error {
 	return util.WaitFor(desc, d, i, func() (bool, error) {
-		if a.Status.Cancelled {
+		if a.Status.Cancelled || a.abandoned {
 			return false, fmt.Errorf("Action cancelled while waiting for %s", desc)
 		}
 		return fn()
 	})
 }
 
+// SetSteps lists the steps the Action is about to run, all pending.
+func (a *Action) SetSteps(names ...string) {
+	steps := make([]*model.ActionStep, len(names))
+	for i, name := range names {
+		steps[i] = &model.ActionStep{
+			Name:  name,
+			State: model.ActionStepStatePending,
+		}
+	}
+	a.Status.Steps = steps
+}
+
+// RunStep runs fn as the named step, recording when it started and finished,
+// and its error if it fails. Steps not given to SetSteps beforehand are added
+// to the end of the list.
+func (a *Action) RunStep(name string, fn func() error) error {
+	var step *model.ActionStep
+	for _, s := range a.Status.Steps {
+		if s.Name == name && s.State != model.ActionStepStateCompleted && s.State != model.ActionStepStateSkipped {
+			step = s
+			break
+		}
+	}
+	if step == nil {
+		step = &model.ActionStep{Name: name}
+		a.Status.Steps = append(a.Status.Steps, step)
+	}
+
+	startedAt := time.Now()
+	step.State = model.ActionStepStateRunning
+	step.StartedAt = &startedAt
+	step.FinishedAt = nil
+	step.Error = ""
+
+	err := fn()
+
+	finishedAt := time.Now()
+	step.FinishedAt = &finishedAt
+	if err != nil {
+		step.State = model.ActionStepStateFailed
+		step.Error = err.Error()
+	} else {
+		step.State = model.ActionStepStateCompleted
+	}
+	return err
+}
+
+// SkipStep marks the named step as skipped, for steps already completed by a
+// previous attempt.
+func (a *Action) SkipStep(name string) {
+	for _, s := range a.Status.Steps {
+		if s.Name == name && s.State == model.ActionStepStatePending {
+			s.State = model.ActionStepStateSkipped
+			return
+		}
+	}
+	a.Status.Steps = append(a.Status.Steps, &model.ActionStep{
+		Name:  name,
+		State: model.ActionStepStateSkipped,
+	})
+}
+
 func (a *Action) Now() error {
 	if err := a.prepare(); err != nil {
 		return err
 	}
 
-	if ei := a.core.Actions.Get(a.resourceID); ei != nil {
-		existing := ei.(*Action)
+	if existing := a.core.Actions.Get(a.resourceID); existing != nil {
 		if a.cancelExisting {
 			existing.Status.Cancelled = true
 			a.core.Actions.Delete("Cancel : "+a.description(), a.resourceID)
@@ -83,7 +198,9 @@ func (a *Action) Now() error {
 	// TODO we may want some means of communicating with the existing action, to
 	// know that it has stopped its goroutines before continuing.
 
-	a.core.Actions.Put("Begin  : "+a.description(), a.resourceID, a)
+	if err := a.core.Actions.Put("Begin  : "+a.description(), a); err != nil {
+		return err
+	}
 
 	// Remove Action from map regardless of success or failure
 	defer a.stopUnlessCancelled()
@@ -96,8 +213,12 @@ func (a *Action) Async() error {
 		return err
 	}
 
-	if ei := a.core.Actions.Get(a.resourceID); ei != nil {
-		existing := ei.(*Action)
+	// Only the leader performs async Actions.
+	if !a.core.Leader.IsLeader() {
+		return a.core.Actions.Queue(a)
+	}
+
+	if existing := a.core.Actions.Get(a.resourceID); existing != nil {
 		if a.cancelExisting {
 			existing.Status.Cancelled = true
 			a.core.Actions.Delete("Cancel : "+a.description(), a.resourceID)
@@ -106,29 +227,62 @@ func (a *Action) Async() error {
 		}
 	}
 
-	a.core.Actions.Put("Begin  : "+a.description(), a.resourceID, a)
+	a.async = true
+	if err := a.core.Actions.Put("Begin  : "+a.description(), a); err != nil {
+		return err
+	}
 
 	go func() {
-		retries := 0
+		// NOTE Retries is not always 0 here; Actions resumed after a restart
+		// continue counting from where they left off.
 		for {
+			if a.abandoned {
+				return // Leave the record for the next leader
+			}
 			if a.Status.Cancelled {
 				break // Remove from Actions
 			}
 
+			atomic.AddInt32(&a.core.Actions.attempts, 1)
 			err := a.fn(a)
+			atomic.AddInt32(&a.core.Actions.attempts, -1)
+
+			if a.abandoned {
+				return
+			}
 			if err == nil {
 				break // Remove from Actions
 			}
 
-			retries++
-			a.Status.Retries = retries
+			a.Status.Retries++
 			a.Status.Error = err.Error()
 
 			a.core.Log.Error(err)
 
-			if retries >= a.Status.MaxRetries {
+			policy := retryPolicy(a.Status)
+
+			if isFatalError(policy, err) && a.Status.Retries < a.Status.MaxRetries {
+				a.core.Log.Errorf("Not retrying %s, error is fatal", a.description())
+				a.Status.Retries = a.Status.MaxRetries
+			}
+
+			var delay time.Duration
+			if a.Status.Retries < a.Status.MaxRetries {
+				delay = retryDelay(policy, a.Status.Retries)
+				nextRetryAt := time.Now().Add(delay)
+				a.Status.NextRetryAt = &nextRetryAt
+			}
+
+			if err := a.core.Actions.save(a); err != nil {
+				a.core.Log.Errorf("Error saving Action record for %s: %s", a.resourceID, err)
+			}
+
+			if a.Status.Retries >= a.Status.MaxRetries {
 				return // Don't remove from Actions
 			}
+
+			a.wait(delay)
+			a.Status.NextRetryAt = nil
 		}
 
 		a.stopUnlessCancelled()
@@ -137,13 +291,45 @@ func (a *Action) Async() error {
 	return nil
 }
 
+// wait sleeps for the given duration, returning early if the Action is
+// cancelled.
+func (a *Action) wait(d time.Duration) {
+	deadline := time.Now().Add(d)
+	for !a.Status.Cancelled && !a.abandoned {
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return
+		}
+		if remaining > time.Second {
+			remaining = time.Second
+		}
+		time.Sleep(remaining)
+	}
+}
+
+// restore puts a failed Action back in place without performing it, so its
+// status is still reported for the resource.
+func (a *Action) restore() error {
+	if err := a.prepare(); err != nil {
+		return err
+	}
+	return a.core.Actions.Put("Restore: "+a.description(), a)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 //\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\
 ////////////////////////////////////////////////////////////////////////////////
 //\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\\
 
 func (c *Core) SetResourceActionStatus(m model.Model) {
-	if ai := c.Actions.Get(m.GetUUID()); ai != nil {
-		m.SetActionStatus(ai.(*Action).Status)
+	if c.Leader.IsLeader() {
+		if a := c.Actions.Get(m.GetUUID()); a != nil {
+			m.SetActionStatus(a.Status)
+		}
+		return
+	}
+	// Followers show the status last saved by the leader.
+	if record, err := c.Actions.Record(m.GetUUID()); err == nil {
+		m.SetActionStatus(&record.ActionStatus)
 	}
 }
@@ -0,0 +1,487 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+var (
+	ErrorActionNotFound  = errors.New("Action not found")
+	ErrorActionNotFailed = errors.New("Only failed Actions can be retried")
+)
+
+// Actions keeps the Actions currently being performed in memory (keyed by
+// resource UUID), and mirrors each of them to the actions table so that they
+// can be resumed when the server restarts.
+//
+// Only the leader (see Leader) performs async Actions. Other servers queue
+// them in the actions table, and read Actions from there as well.
+type Actions struct {
+	core    *Core
+	actions *SafeMap // map[resource-uuid]*Action
+
+	// attempts is the number of async Action attempts in progress.
+	attempts int32
+}
+
+func NewActions(core *Core) *Actions {
+	return &Actions{
+		core:    core,
+		actions: NewSafeMap(core),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ActionQueue is a RecurringService through which the leader picks up the
+// Actions queued, retried, or cancelled by other servers.
+type ActionQueue struct {
+	core *Core
+}
+
+func (s *ActionQueue) Perform() error {
+	return s.core.Actions.performQueued()
+}
+
+//------------------------------------------------------------------------------
+
+func (c *Actions) List() (items []*Action) {
+	items = make([]*Action, 0)
+	for _, ai := range c.actions.List() {
+		items = append(items, ai.(*Action))
+	}
+	return
+}
+
+func (c *Actions) Get(resourceID string) *Action {
+	if ai := c.actions.Get(resourceID); ai != nil {
+		return ai.(*Action)
+	}
+	return nil
+}
+
+// Records returns the persisted form of every Action. The leader has those it
+// is performing in memory, and the rest (cancelled, or queued by another
+// server) are loaded from the database.
+func (c *Actions) Records() ([]*model.Action, error) {
+	records := make([]*model.Action, 0)
+	if c.core.Leader.IsLeader() {
+		for _, a := range c.List() {
+			records = append(records, a.Record())
+		}
+	}
+
+	var stored []*model.Action
+	if err := c.core.DB.Find(&stored); err != nil {
+		return nil, err
+	}
+	for _, record := range stored {
+		if c.core.Leader.IsLeader() && c.Get(record.ResourceUUID) != nil {
+			continue
+		}
+		record.State = actionState(&record.ActionStatus)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Record returns the persisted form of the Action for a resource.
+func (c *Actions) Record(resourceID string) (*model.Action, error) {
+	if c.core.Leader.IsLeader() {
+		if a := c.Get(resourceID); a != nil {
+			return a.Record(), nil
+		}
+	}
+	return c.loadRecord(resourceID)
+}
+
+func (c *Actions) Put(desc string, a *Action) error {
+	c.actions.Put(desc, a.resourceID, a)
+
+	if a.record != nil {
+		return c.save(a)
+	}
+
+	// Clear out any record left over from a previous Action on the resource
+	if _, err := c.clearRecords(a.resourceID); err != nil {
+		return err
+	}
+	a.record = c.newRecord(a)
+	return c.core.DB.Create(a.record)
+}
+
+// Queue stores an async Action for the leader to perform. It is used in place
+// of performing the Action when this server is not the leader.
+func (c *Actions) Queue(a *Action) error {
+	a.async = true
+	record := c.newRecord(a)
+	record.Queued = true
+
+	// The leader rebuilds the Action from its record, so refuse any it can't
+	// rebuild, rather than queue it only to be discarded.
+	rebuilt, err := c.rebuild(record)
+	if err != nil {
+		return err
+	}
+	if rebuilt == nil {
+		return fmt.Errorf("Action %s cannot be queued for the leader", a.description())
+	}
+
+	running, err := c.clearRecords(a.resourceID)
+	if err != nil {
+		return err
+	}
+	if len(running) > 0 && !a.cancelExisting {
+		return &RepeatedActionError{a.resourceID}
+	}
+
+	// The queued Action replaces those running, which the leader cancels when
+	// it picks it up.
+	for _, existing := range running {
+		if err := c.core.DB.Delete(existing); err != nil {
+			return err
+		}
+	}
+	a.record = record
+	c.core.Log.Debugf("QUEUE :: %s", a.description())
+	return c.core.DB.Create(a.record)
+}
+
+func (c *Actions) Delete(desc string, resourceID string) {
+	a := c.Get(resourceID)
+	c.actions.Delete(desc, resourceID)
+
+	// Only delete this Action's own record, in case another server has since
+	// queued a new Action for the resource.
+	scope := c.core.DB.DB.Where("resource_uuid = ?", resourceID)
+	if a != nil && a.record != nil && a.record.ID != nil {
+		scope = scope.Where("id = ?", *a.record.ID)
+	}
+	if err := scope.Delete(new(model.Action)).Error; err != nil {
+		c.core.Log.Errorf("Error deleting Action record for %s: %s", resourceID, err)
+	}
+}
+
+// Retry performs a failed Action again, with its retries reset.
+func (c *Actions) Retry(resourceID string) (*model.Action, error) {
+	if !c.core.Leader.IsLeader() {
+		return c.updateQueued(resourceID, func(record *model.Action) error {
+			if record.State != model.ActionStateFailed {
+				return ErrorActionNotFailed
+			}
+			record.Retries = 0
+			record.Error = ""
+			record.NextRetryAt = nil
+			record.Queued = true
+			return nil
+		})
+	}
+
+	a := c.Get(resourceID)
+	if a == nil {
+		return nil, ErrorActionNotFound
+	}
+	if !a.failed() {
+		return nil, ErrorActionNotFailed
+	}
+
+	// Reload the resource, since it was loaded when the Action first started.
+	if a.id != nil && a.scope != nil {
+		if err := a.scope.First(a.model, *a.id); err != nil {
+			return nil, err
+		}
+	}
+
+	// Take the failed Action out of the map (but keep its record), so that
+	// Async() doesn't see it as an existing Action.
+	c.actions.Delete("Retry  : "+a.description(), resourceID)
+
+	a.Status.Retries = 0
+	a.Status.Error = ""
+	a.Status.NextRetryAt = nil
+	if err := a.Async(); err != nil {
+		return nil, err
+	}
+	return a.Record(), nil
+}
+
+// Cancel flags the Action as cancelled and stops performing it. A running
+// Action stops at its next cancellable wait, or before its next retry. The
+// record is kept, as cancelled, until the next Action on the resource.
+func (c *Actions) Cancel(resourceID string) (*model.Action, error) {
+	if !c.core.Leader.IsLeader() {
+		return c.updateQueued(resourceID, func(record *model.Action) error {
+			record.Cancelled = true
+			record.Queued = true
+			return nil
+		})
+	}
+
+	a := c.Get(resourceID)
+	if a == nil {
+		return nil, ErrorActionNotFound
+	}
+	a.Status.Cancelled = true
+	c.actions.Delete("Cancel : "+a.description(), resourceID)
+	if err := c.save(a); err != nil {
+		return nil, err
+	}
+	return a.Record(), nil
+}
+
+// Resume loads the Action records left over from the last time the server ran.
+// Background provisioning, deploy, and delete Actions are re-enqueued; an
+// interrupted attempt counts against the Action's retries, so an Action which
+// is out of retries is restored in its failed state instead of being run.
+// Everything else is discarded.
+//
+// Resume is called when the server is elected leader, which also picks up the
+// Actions of the previous leader.
+func (c *Actions) Resume() error {
+	var records []*model.Action
+	if err := c.core.DB.Find(&records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if c.Get(record.ResourceUUID) != nil {
+			continue // already being performed here
+		}
+		if record.Cancelled && !record.Queued {
+			continue // kept only to be listed
+		}
+		if err := c.resume(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Abandon stops performing every Action, leaving their records in place for
+// whichever server leads next. It is called when the server stops being the
+// leader.
+func (c *Actions) Abandon() {
+	for _, a := range c.List() {
+		a.abandoned = true
+		c.actions.Delete("Abandon: "+a.description(), a.resourceID)
+	}
+}
+
+// Drain abandons every Action, and waits for those in the middle of an attempt
+// to reach a safe point -- the end of the attempt, or a cancellable wait -- or
+// for ctx to be done. The abandoned Actions are resumed by the next leader.
+func (c *Actions) Drain(ctx context.Context) error {
+	c.Abandon()
+	for {
+		n := atomic.LoadInt32(&c.attempts)
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Stopped waiting for %d Action(s) to drain: %s", n, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Actions) newRecord(a *Action) *model.Action {
+	record := &model.Action{
+		ResourceUUID: a.resourceID,
+		ResourceType: a.modelType(),
+		ResourceID:   a.modelID(),
+		Async:        a.async,
+		ActionStatus: *a.Status,
+	}
+	if a.requester != nil {
+		record.RequesterID = a.requester.ID
+	}
+	return record
+}
+
+func (c *Actions) save(a *Action) error {
+	if a.abandoned {
+		return nil // the record belongs to the next leader now
+	}
+	a.record.ActionStatus = *a.Status
+	return c.core.DB.Save(a.record)
+}
+
+// clearRecords deletes the records of previous Actions on the resource which
+// are failed or cancelled, and returns those still running. A running record
+// may be that of an Action in flight on another server, which the leader needs
+// to resume it.
+func (c *Actions) clearRecords(resourceID string) ([]*model.Action, error) {
+	var records []*model.Action
+	if err := c.core.DB.Where("resource_uuid = ?", resourceID).Find(&records); err != nil {
+		return nil, err
+	}
+	var running []*model.Action
+	for _, record := range records {
+		if actionState(&record.ActionStatus) == model.ActionStateRunning {
+			running = append(running, record)
+			continue
+		}
+		if err := c.core.DB.Delete(record); err != nil {
+			return nil, err
+		}
+	}
+	return running, nil
+}
+
+func (c *Actions) loadRecord(resourceID string) (*model.Action, error) {
+	record := new(model.Action)
+	if err := c.core.DB.Where("resource_uuid = ?", resourceID).First(record); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrorActionNotFound
+		}
+		return nil, err
+	}
+	record.State = actionState(&record.ActionStatus)
+	return record, nil
+}
+
+// updateQueued changes the record of an Action from a server that isn't the
+// leader. The leader applies the change when it next performs queued Actions.
+func (c *Actions) updateQueued(resourceID string, fn func(*model.Action) error) (*model.Action, error) {
+	record, err := c.loadRecord(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(record); err != nil {
+		return nil, err
+	}
+	if err := c.core.DB.Save(record); err != nil {
+		return nil, err
+	}
+	record.State = actionState(&record.ActionStatus)
+	return record, nil
+}
+
+// performQueued is run by the leader to apply the Actions queued, retried, or
+// cancelled by other servers.
+func (c *Actions) performQueued() error {
+	if !c.core.Leader.IsLeader() {
+		return nil
+	}
+
+	var records []*model.Action
+	if err := c.core.DB.Where("queued = ?", true).Find(&records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		// Whatever is running for the resource has been cancelled or replaced.
+		if existing := c.Get(record.ResourceUUID); existing != nil {
+			existing.Status.Cancelled = true
+			c.actions.Delete("Cancel : "+existing.description(), record.ResourceUUID)
+		}
+
+		if record.Cancelled {
+			record.Queued = false
+			if err := c.core.DB.Save(record); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.resume(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Actions) resume(record *model.Action) error {
+	a, err := c.rebuild(record)
+	if err != nil {
+		c.core.Log.Errorf("Could not resume Action for %s %s: %s", record.ResourceType, record.ResourceUUID, err)
+	}
+	if a == nil {
+		return c.core.DB.Delete(record)
+	}
+
+	a.record = record
+	a.Status.Retries = record.Retries
+	a.Status.Error = record.Error
+	a.Status.Steps = record.Steps
+
+	if record.Queued {
+		// Never attempted, so there is nothing to count as interrupted.
+		record.Queued = false
+	} else if a.Status.Error == "" || a.Status.Retries < a.Status.MaxRetries {
+		a.Status.Retries++
+		a.Status.Error = "Interrupted by server restart"
+	}
+
+	if a.Status.Error == "" || a.Status.Retries < a.Status.MaxRetries {
+		err = a.Async()
+	} else {
+		err = a.restore()
+	}
+	if err != nil {
+		c.core.Log.Errorf("Could not resume Action for %s %s: %s", record.ResourceType, record.ResourceUUID, err)
+		return c.core.DB.Delete(record)
+	}
+	return nil
+}
+
+// rebuild returns the Action described by the record, or nil if the Action is
+// not one we resume.
+func (c *Actions) rebuild(r *model.Action) (*Action, error) {
+	if !r.Async || r.Cancelled || r.ResourceID == nil {
+		return nil, nil
+	}
+
+	switch r.ResourceType + " " + r.Description {
+	case "Kube provisioning":
+		return c.core.Kubes.Provision(r.ResourceID, new(model.Kube)), nil
+	case "Kube deleting":
+		return c.core.Kubes.Delete(r.ResourceID, new(model.Kube)), nil
+	case "Node provisioning":
+		return c.core.Nodes.Provision(r.ResourceID, new(model.Node)), nil
+	case "Node deleting":
+		return c.core.Nodes.Delete(r.ResourceID, new(model.Node)), nil
+	case "App provisioning":
+		return c.core.Apps.Provision(r.ResourceID, new(model.App)), nil
+	case "App deleting":
+		return c.core.Apps.Delete(r.ResourceID, new(model.App)), nil
+	case "Component deploying":
+		if r.RequesterID == nil {
+			return nil, errors.New("Component deploy has no requester")
+		}
+		requester := new(model.User)
+		if err := c.core.Users.Get(r.RequesterID, requester); err != nil {
+			return nil, err
+		}
+		return c.core.Components.Deploy(requester, r.ResourceID, new(model.Component)), nil
+	case "Component deleting":
+		return c.core.Components.Delete(r.ResourceID, new(model.Component)), nil
+	case "Instance starting":
+		return c.core.Instances.Start(r.ResourceID, new(model.Instance)), nil
+	case "Instance stopping":
+		return c.core.Instances.Stop(r.ResourceID, new(model.Instance)), nil
+	case "Instance deleting":
+		return c.core.Instances.Delete(r.ResourceID, new(model.Instance)), nil
+	case "Volume deleting":
+		return c.core.Volumes.Delete(r.ResourceID, new(model.Volume)), nil
+	case "Snapshot provisioning":
+		return c.core.Snapshots.Provision(r.ResourceID, new(model.Snapshot)), nil
+	case "Snapshot deleting":
+		return c.core.Snapshots.Delete(r.ResourceID, new(model.Snapshot)), nil
+	case "Entrypoint provisioning":
+		return c.core.Entrypoints.Provision(r.ResourceID, new(model.Entrypoint)), nil
+	case "Entrypoint deleting":
+		return c.core.Entrypoints.Delete(r.ResourceID, new(model.Entrypoint)), nil
+	}
+	return nil, nil
+}
@@ -1,7 +1,7 @@
 package core
 
 import (
-	"github.com/supergiant/guber"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 )
 
@@ -13,20 +13,23 @@ func (c *Apps) Create(m *model.App) error {
 	if err := c.Collection.Create(m); err != nil {
 		return err
 	}
+	return c.Provision(m.ID, m).Async()
+}
 
-	provision := &Action{
+func (c *Apps) Provision(id *int64, m *model.App) *Action {
+	return &Action{
 		Status: &model.ActionStatus{
 			Description: "provisioning",
 			MaxRetries:  5,
 		},
-		core:       c.core,
-		resourceID: m.UUID,
-		model:      m,
+		core:  c.core,
+		scope: c.core.DB.Preload("Kube"),
+		model: m,
+		id:    id,
 		fn: func(_ *Action) error {
 			return c.createNamespace(m)
 		},
 	}
-	return provision.Async()
 }
 
 func (c *Apps) Delete(id *int64, m *model.App) *Action {
@@ -5,7 +5,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/supergiant/guber"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 )
 
@@ -29,6 +29,10 @@ func (s *CapacityService) Perform() error {
 	// 2. "scaling" should be an action on Kube, so we can see the status (actually that may not make sense, just use Nodes ?)
 
 	for _, kube := range kubes {
+		// The Nodes of external Kubes aren't ours to add or remove.
+		if isExternal(kube) {
+			continue
+		}
 		if err := newKubeScaler(s.core, kube).Scale(); err != nil {
 			return err
 		}
@@ -80,6 +84,13 @@ func newKubeScaler(c *Core, kube *model.Kube) *KubeScaler {
 //------------------------------------------------------------------------------
 
 func (s *KubeScaler) Scale() error {
+	// Interrupted spot Nodes are replaced right away, rather than once their
+	// pods are found to be pending. Failing to replace some doesn't stop the
+	// Kube from being scaled.
+	if err := s.core.Nodes.ReplaceInterrupted(s.kube); err != nil {
+		s.core.Log.Errorf("Capacity service error when replacing interrupted spot Nodes: %s", err)
+	}
+
 	incomingPods, err := s.incomingPods()
 	if err != nil {
 		return fmt.Errorf("Capacity service error when fetching incoming pods: %s", err)
@@ -90,6 +101,7 @@ func (s *KubeScaler) Scale() error {
 		projectedNodes = append(projectedNodes, &projectedNode{
 			false,
 			s.largestNodeSize,
+			podAvailabilityZone(pod),
 			[]*guber.Pod{pod},
 		})
 	}
@@ -199,6 +211,10 @@ func (s *KubeScaler) Scale() error {
 		node := &model.Node{
 			KubeID: s.kube.ID,
 			Size:   pnode.Size.Name,
+
+			// NOTE Nodes for pods that aren't kept in a zone are put in whichever
+			// has the fewest Nodes when created.
+			AvailabilityZone: pnode.Zone,
 		}
 
 		// If there's an existing node which is spinning up with this type, then
@@ -209,7 +225,7 @@ func (s *KubeScaler) Scale() error {
 		alreadySpinningUp := false
 		for _, existingNode := range s.kube.Nodes {
 
-			if existingNode.Size == node.Size && !existingNode.Ready {
+			if existingNode.Size == node.Size && !existingNode.Ready && (node.AvailabilityZone == "" || existingNode.AvailabilityZone == node.AvailabilityZone) {
 				// This may be a node that is already being created, or NOTE it could
 				// be a broken node that we erroneously identify as spinning up.
 				alreadySpinningUp = true
@@ -308,9 +324,18 @@ func (s *KubeScaler) incomingPods() (incomingPods []*guber.Pod, err error) {
 type projectedNode struct {
 	Committed bool
 	Size      *NodeSize
+	Zone      string
 	Pods      []*guber.Pod
 }
 
+// podAvailabilityZone returns the zone a pod is kept in, if it is.
+func podAvailabilityZone(pod *guber.Pod) string {
+	if pod.Spec == nil {
+		return ""
+	}
+	return pod.Spec.NodeSelector[availabilityZoneLabel]
+}
+
 func (pnode *projectedNode) usedRAM() (u float64) {
 	for _, pod := range pnode.Pods {
 		for _, container := range pod.Spec.Containers {
@@ -370,7 +395,7 @@ func (pnode *projectedNode) usedCPU() (u float64) {
 func (pnode *projectedNode) usedVolumes() (u int) {
 	for _, pod := range pnode.Pods {
 		for _, vol := range pod.Spec.Volumes {
-			if vol.AwsElasticBlockStore != nil {
+			if isDiskVolume(vol) {
 				u++
 			}
 		}
@@ -382,5 +407,5 @@ func (pnode1 *projectedNode) canMergeWith(pnode2 *projectedNode) bool {
 	usedCPU := pnode1.usedCPU() + pnode2.usedCPU()
 	usedRAM := pnode1.usedRAM() + pnode2.usedRAM()
 	usedVolumes := pnode1.usedVolumes() + pnode2.usedVolumes()
-	return pnode1.Size.CPUCores >= usedCPU && pnode1.Size.RAMGIB >= usedRAM && usedVolumes <= maxDisksPerNode
+	return pnode1.Zone == pnode2.Zone && pnode1.Size.CPUCores >= usedCPU && pnode1.Size.RAMGIB >= usedRAM && usedVolumes <= maxDisksPerNode
 }
@@ -15,6 +15,17 @@ func (c *CloudAccounts) Create(m *model.CloudAccount) error {
 	if err := validateFields(m); err != nil {
 		return err
 	}
+	def, err := providerDefinition(m.Provider)
+	if err != nil {
+		return err
+	}
+	if err := def.validateCredentials(m.Credentials); err != nil {
+		return err
+	}
+	// Providers without credentials (ex. external) may be given none at all.
+	if m.Credentials == nil {
+		m.Credentials = make(map[string]string)
+	}
 
 	if err := c.provider(m).ValidateAccount(m); err != nil {
 		return err
@@ -37,10 +48,9 @@ func (c *CloudAccounts) Delete(id *int64, m *model.CloudAccount) error {
 ////////////////////////////////////////////////////////////////////////////////
 
 func (c *CloudAccounts) provider(m *model.CloudAccount) Provider {
-	switch m.Provider {
-	case "aws":
-		return c.core.AWSProvider(m.Credentials)
-	default:
+	def, ok := providerDefinitions[m.Provider]
+	if !ok {
 		panic("Could not load provider interface for " + m.Provider)
 	}
+	return def.New(c.core, m.Credentials)
 }
@@ -12,18 +12,28 @@ type Components struct {
 	Collection
 }
 
+var componentRetryPolicy = &model.RetryPolicy{
+	InitialDelay: 5,
+	Multiplier:   1.5,
+	MaxDelay:     120,
+	Jitter:       0.2,
+	FatalErrors:  fatalAWSErrors,
+}
+
 // NOTE deploy has User passed into pass API token to CustomDeployScript if used
 func (c *Components) Deploy(requester *model.User, id *int64, m *model.Component) *Action {
 	return &Action{
 		Status: &model.ActionStatus{
 			Description: "deploying",
+			RetryPolicy: componentRetryPolicy,
 			MaxRetries:  5,
 		},
-		core:  c.core,
-		scope: c.core.DB.Preload("App.Kube.CloudAccount").Preload("PrivateImageKeys.Key").Preload("CurrentRelease").Preload("TargetRelease").Preload("Instances"),
-		model: m,
-		id:    id,
-		fn: func(_ *Action) error {
+		core:      c.core,
+		scope:     c.core.DB.Preload("App.Kube.CloudAccount").Preload("PrivateImageKeys.Key").Preload("CurrentRelease").Preload("TargetRelease").Preload("Instances"),
+		model:     m,
+		id:        id,
+		requester: requester,
+		fn: func(a *Action) error {
 
 			// TODO something is causing Kube to not exist by time it gets to
 			// serviceSet provision, there's a note below.
@@ -34,93 +44,130 @@ func (c *Components) Deploy(requester *model.User, id *int64, m *model.Component
 				return errors.New("Component does not have target Release")
 			}
 
-			m.TargetRelease.InUse = true
-			if err := c.core.DB.Save(m.TargetRelease); err != nil {
-				return err
-			}
+			a.SetSteps(
+				"creating Instances",
+				"creating Volumes",
+				"provisioning Secrets",
+				"provisioning Services",
+				"deploying Instances",
+				"retiring current Release",
+				"saving Addresses",
+			)
 
-			// Create Instances
-			for n := 0; n < m.InstanceCount(); n++ {
-				if m.InstanceByNum(n) != nil {
-					continue
+			err := a.RunStep("creating Instances", func() error {
+				m.TargetRelease.InUse = true
+				if err := c.core.DB.Save(m.TargetRelease); err != nil {
+					return err
 				}
 
-				instance := &model.Instance{
-					Component:   m,
-					ComponentID: m.ID,
-					Release:     m.TargetRelease,
-					ReleaseID:   m.TargetReleaseID,
-					Num:         n,
-					Name:        fmt.Sprintf("%s-%d", m.Name, n),
-				}
-				if err := c.core.Instances.Create(instance); err != nil {
-					return err
+				for n := 0; n < m.InstanceCount(); n++ {
+					if m.InstanceByNum(n) != nil {
+						continue
+					}
+
+					instance := &model.Instance{
+						Component:   m,
+						ComponentID: m.ID,
+						Release:     m.TargetRelease,
+						ReleaseID:   m.TargetReleaseID,
+						Num:         n,
+						Name:        fmt.Sprintf("%s-%d", m.Name, n),
+					}
+					if err := c.core.Instances.Create(instance); err != nil {
+						return err
+					}
+					m.Instances = append(m.Instances, instance)
 				}
-				m.Instances = append(m.Instances, instance)
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 
 			// Create Volumes in parallel
-			if m.TargetRelease.Config.Volumes != nil {
-				err := c.core.Instances.inParallel(m.Instances, func(mi interface{}) error {
+			err = a.RunStep("creating Volumes", func() error {
+				if m.TargetRelease.Config.Volumes == nil {
+					return nil
+				}
+				return c.core.Instances.inParallel(m.Instances, func(mi interface{}) error {
 					instance := mi.(*model.Instance)
 					return c.core.Instances.CreateVolumes(instance.ID, instance)
 				})
-				if err != nil {
-					return err
-				}
+			})
+			if err != nil {
+				return err
 			}
 
 			// TODO see above.... something unsets Preloaded Kube from App
 			m.App.Kube = &kube
 
-			// Provision Secrets
-			for _, imageKey := range m.PrivateImageKeys {
-				if err := provisionSecret(c.core, m.App, imageKey.Key); err != nil {
-					return err
+			err = a.RunStep("provisioning Secrets", func() error {
+				for _, imageKey := range m.PrivateImageKeys {
+					if err := provisionSecret(c.core, m.App, imageKey.Key); err != nil {
+						return err
+					}
 				}
-			}
-
-			// Provision Services
-			serviceSet, err := c.serviceSet(m)
+				return nil
+			})
 			if err != nil {
 				return err
 			}
-			if err := serviceSet.provision(); err != nil {
-				return err
-			}
 
-			// Add new ports to existing service, if there is one, and there are any.
-			if m.CurrentRelease != nil {
-				if err := serviceSet.addNewPorts(); err != nil {
+			var serviceSet *ServiceSet
+			err = a.RunStep("provisioning Services", func() (err error) {
+				serviceSet, err = c.serviceSet(m)
+				if err != nil {
+					return err
+				}
+				if err := serviceSet.provision(); err != nil {
 					return err
 				}
+
+				// Add new ports to existing service, if there is one, and there are any.
+				if m.CurrentRelease != nil {
+					return serviceSet.addNewPorts()
+				}
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 
 			// Run "inner" deployment
-			if m.CustomDeployScript != nil {
-				if err := RunCustomDeployment(c.core, m); err != nil {
-					return err
+			err = a.RunStep("deploying Instances", func() error {
+				if m.CustomDeployScript != nil {
+					if err := RunCustomDeployment(c.core, m); err != nil {
+						return err
+					}
+				} else {
+					// This goes to the deploy/ folder which uses the client package.
+					if err := deploy.Deploy(c.core.NewAPIClient("token", requester.APIToken), m.ID); err != nil {
+						return err
+					}
 				}
-			} else {
-				// This goes to the deploy/ folder which uses the client package.
-				if err := deploy.Deploy(c.core.NewAPIClient("token", requester.APIToken), m.ID); err != nil {
+
+				// Reload Instances
+				if err := c.core.DB.Where("component_id = ?", m.ID).Find(&m.Instances); err != nil {
 					return err
 				}
-			}
 
-			// Reload Instances
-			if err := c.core.DB.Where("component_id = ?", m.ID).Find(&m.Instances); err != nil {
+				// Make sure all Instances (that haven't been deleted) have been restarted
+				for _, instance := range m.Instances {
+					if instance.Num <= m.TargetRelease.InstanceCount && *instance.ReleaseID != *m.TargetReleaseID {
+						return fmt.Errorf("Not all Instances for Component %d have been started with the target Release", m.ID)
+					}
+				}
+				return nil
+			})
+			if err != nil {
 				return err
 			}
 
-			// Make sure all Instances (that haven't been deleted) have been restarted
-			for _, instance := range m.Instances {
-				if instance.Num <= m.TargetRelease.InstanceCount && *instance.ReleaseID != *m.TargetReleaseID {
-					return fmt.Errorf("Not all Instances for Component %d have been started with the target Release", m.ID)
+			err = a.RunStep("retiring current Release", func() error {
+				if m.CurrentRelease == nil {
+					return nil
 				}
-			}
 
-			if m.CurrentRelease != nil {
 				// Remove old ports from service if there are any
 				if err := serviceSet.removeOldPorts(); err != nil {
 					return err
@@ -128,31 +175,34 @@ func (c *Components) Deploy(requester *model.User, id *int64, m *model.Component
 
 				// Mark old Release as retired
 				m.CurrentRelease.InUse = false
-				if err := c.core.DB.Save(m.CurrentRelease); err != nil {
-					return err
-				}
-			}
-
-			// Save addresses to Component
-			externalAddrs, err := serviceSet.externalAddresses()
-			if err != nil {
-				return err
-			}
-			internalAddrs, err := serviceSet.internalAddresses()
+				return c.core.DB.Save(m.CurrentRelease)
+			})
 			if err != nil {
 				return err
 			}
-			m.Addresses = &model.Addresses{
-				External: externalAddrs,
-				Internal: internalAddrs,
-			}
 
-			// If we're all good, we set target to current, and remove target.
-			m.CurrentRelease = m.TargetRelease
-			m.CurrentReleaseID = m.TargetReleaseID
-			m.TargetRelease = nil
-			m.TargetReleaseID = nil
-			return c.core.DB.Save(m)
+			// Save addresses to Component
+			return a.RunStep("saving Addresses", func() error {
+				externalAddrs, err := serviceSet.externalAddresses()
+				if err != nil {
+					return err
+				}
+				internalAddrs, err := serviceSet.internalAddresses()
+				if err != nil {
+					return err
+				}
+				m.Addresses = &model.Addresses{
+					External: externalAddrs,
+					Internal: internalAddrs,
+				}
+
+				// If we're all good, we set target to current, and remove target.
+				m.CurrentRelease = m.TargetRelease
+				m.CurrentReleaseID = m.TargetReleaseID
+				m.TargetRelease = nil
+				m.TargetReleaseID = nil
+				return c.core.DB.Save(m)
+			})
 		},
 	}
 }
@@ -161,6 +211,7 @@ func (c *Components) Delete(id *int64, m *model.Component) *Action {
 	return &Action{
 		Status: &model.ActionStatus{
 			Description: "deleting",
+			RetryPolicy: componentRetryPolicy,
 			MaxRetries:  20,
 		},
 		core:           c.core,
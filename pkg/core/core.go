@@ -1,15 +1,18 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/imdario/mergo"
 	"github.com/supergiant/supergiant/pkg/client"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 
 	"github.com/jinzhu/gorm"
@@ -40,6 +43,19 @@ type Settings struct {
 	LogPath     string `json:"log_file"`
 	LogLevel    string `json:"log_level"`
 
+	// ShutdownTimeout is the number of seconds to wait, on shutdown, for
+	// requests and Actions to finish.
+	ShutdownTimeout int `json:"shutdown_timeout"`
+
+	// ServiceIntervals sets the number of seconds between runs of the
+	// RecurringServices, by name (ex. "capacity_service").
+	ServiceIntervals map[string]int `json:"service_intervals"`
+
+	// HostPathPrefixes are the directories of Nodes (ex. "/var/lib/data") that
+	// host_path Volumes of Releases may be in. Without any, host_path Volumes
+	// are refused, as they could mount any file of a Node into a pod.
+	HostPathPrefixes []string `json:"host_path_prefixes"`
+
 	// NOTE these MUST be provided in ascending order by cost in order to
 	// correctly provision the smallest size on Kube creation
 	//
@@ -50,9 +66,9 @@ type Settings struct {
 type Core struct {
 	Settings
 
-	// NOTE we do this to prevent having to load all the cloud provider various
-	// lib code everytime we load core
-	AWSProvider func(map[string]string) Provider
+	// K8SProvider, when set, replaces the Kubernetes client K8S returns for a
+	// Kube. Tests use it to point Core at a fake Kubernetes API.
+	K8SProvider func(*model.Kube) guber.Client
 
 	Log *logrus.Logger
 
@@ -67,14 +83,26 @@ type Core struct {
 	Releases         *Releases
 	Instances        *Instances
 	Volumes          *Volumes
+	Snapshots        *Snapshots
 	PrivateImageKeys *PrivateImageKeys
 	Entrypoints      *Entrypoints
 	Nodes            *Nodes
+	Services         *Services
 
-	// TODO should this be a pseudo-collection like Sessions?
-	Actions *SafeMap
+	Actions *Actions
+
+	// Leader decides whether this server performs background work, when there
+	// are several sharing the database.
+	Leader *Leader
+
+	// stopBackground stops the RecurringServices and Leader, which are tracked
+	// by background.
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
 }
 
+const defaultShutdownTimeout = 60 * time.Second
+
 // NOTE this used to be core.New(), but due to how we load in values from the
 // cli package, I needed to first actually initialize a Core struct and then
 // configure.
@@ -159,12 +187,23 @@ func (c *Core) InitializeForeground() error {
 		&model.Release{},
 		&model.Instance{},
 		&model.Volume{},
+		&model.Snapshot{},
 		&model.Entrypoint{},
 		&model.Node{},
+		&model.Action{},
+		&model.Lease{},
+		&model.Service{},
 	).Error
 	if err != nil {
 		return err
 	}
+	// Kubes created before ProviderConfig have their config in aws_config_json,
+	// which AutoMigrate leaves in place.
+	if c.DB.NewScope(&model.Kube{}).Dialect().HasColumn("kubes", "aws_config_json") {
+		if err := c.DB.Exec("UPDATE kubes SET provider_config_json = aws_config_json WHERE provider_config_json IS NULL").Error; err != nil {
+			return err
+		}
+	}
 	c.Users = &Users{Collection{c}}
 	c.Kubes = &Kubes{Collection{c}}
 	c.CloudAccounts = &CloudAccounts{Collection{c}}
@@ -173,45 +212,91 @@ func (c *Core) InitializeForeground() error {
 	c.Releases = &Releases{Collection{c}}
 	c.Instances = &Instances{Collection{c}}
 	c.Volumes = &Volumes{Collection{c}}
+	c.Snapshots = &Snapshots{Collection{c}}
 	c.PrivateImageKeys = &PrivateImageKeys{Collection{c}}
 	c.Entrypoints = &Entrypoints{Collection{c}}
 	c.Nodes = &Nodes{Collection{c}}
+	c.Services = &Services{Collection{c}}
 	c.Sessions = NewSessions(c)
 
 	// Actions for async work
-	c.Actions = NewSafeMap(c)
+	c.Actions = NewActions(c)
+
+	c.Leader = NewLeader(c)
 
 	return nil
 }
 
 // InitializeBackground starts Action processing and RecurringServices for *Core.
 func (c *Core) InitializeBackground() {
+	var ctx context.Context
+	ctx, c.stopBackground = context.WithCancel(context.Background())
+
+	// Leader election. On being elected, the server picks up any Actions that
+	// were in progress when the last leader stopped.
+	c.Leader.tick()
+	c.inBackground(func() { c.Leader.Run(ctx) })
+
 	// Recurring services
-	capacityService := &RecurringService{
-		core:     c,
-		service:  &CapacityService{c},
-		interval: 30 * time.Second,
+	capacityService := c.NewRecurringService("capacity_service", &CapacityService{c})
+	nodeObserver := c.NewRecurringService("node_observer", &NodeObserver{c})
+	instanceObserver := c.NewRecurringService("instance_observer", &InstanceObserver{c})
+	actionQueue := c.NewRecurringService("action_queue", &ActionQueue{c})
+	snapshotScheduler := c.NewRecurringService("snapshot_scheduler", &SnapshotScheduler{c})
+	// NOTE Sessions are kept in memory, so each server expires its own.
+	sessionExpirer := c.NewRecurringService("session_expirer", &SessionExpirer{c})
+	sessionExpirer.everyServer = true
+
+	for _, service := range []*RecurringService{capacityService, nodeObserver, instanceObserver, actionQueue, snapshotScheduler, sessionExpirer} {
+		service := service
+		c.inBackground(func() { service.Run(ctx) })
 	}
-	nodeObserver := &RecurringService{
-		core:     c,
-		service:  &NodeObserver{c},
-		interval: 30 * time.Second,
+}
+
+// Shutdown stops the RecurringServices, and leaves running Actions at a safe
+// point to be resumed by the next leader (which may be this server, after a
+// restart). It returns when done, or when ctx is done.
+func (c *Core) Shutdown(ctx context.Context) error {
+	if c.stopBackground != nil {
+		c.stopBackground()
 	}
-	instanceObserver := &RecurringService{
-		core:     c,
-		service:  &InstanceObserver{c},
-		interval: 30 * time.Second,
+
+	// Actions started from here on (by the ones still running) are queued.
+	c.Leader.stepDown()
+
+	err := c.Actions.Drain(ctx)
+
+	// Wait for RecurringServices to finish the tick they may be in.
+	stopped := make(chan struct{})
+	go func() {
+		c.background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
 	}
-	sessionExpirer := &RecurringService{
-		core:     c,
-		service:  &SessionExpirer{c},
-		interval: 15 * time.Second,
+
+	if releaseErr := c.Leader.release(); releaseErr != nil {
+		c.Log.Error("Error releasing leader Lease: ", releaseErr)
 	}
+	return err
+}
+
+// ShutdownDeadline is how long Shutdown should be given.
+func (c *Core) ShutdownDeadline() time.Duration {
+	if c.ShutdownTimeout > 0 {
+		return time.Duration(c.ShutdownTimeout) * time.Second
+	}
+	return defaultShutdownTimeout
+}
 
-	go capacityService.Run()
-	go nodeObserver.Run()
-	go instanceObserver.Run()
-	go sessionExpirer.Run()
+func (c *Core) inBackground(fn func()) {
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		fn()
+	}()
 }
 
 //------------------------------------------------------------------------------
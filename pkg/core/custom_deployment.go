@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/supergiant/guber"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 	"github.com/supergiant/supergiant/pkg/util"
 )
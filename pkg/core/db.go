@@ -45,7 +45,7 @@ func (db *DB) Find(out interface{}, where ...interface{}) error {
 		m := items.Index(i).Interface().(model.Model)
 		unmarshalSerializedFields(m)
 	}
-	return nil
+	return db.decodeProviderConfigs(reflect.ValueOf(out))
 }
 
 func (db *DB) First(out interface{}, where ...interface{}) error {
@@ -54,7 +54,7 @@ func (db *DB) First(out interface{}, where ...interface{}) error {
 	}
 	m := out.(model.Model)
 	unmarshalSerializedFields(m)
-	return nil
+	return db.decodeProviderConfigs(reflect.ValueOf(out))
 }
 
 func (db *DB) Delete(m model.Model) error {
@@ -80,6 +80,13 @@ func (db *DB) Where(query interface{}, args ...interface{}) *DB {
 	}
 }
 
+func (db *DB) Order(value interface{}) *DB {
+	return &DB{
+		db.core,
+		db.DB.Order(value),
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Private methods                                                            //
 ////////////////////////////////////////////////////////////////////////////////
@@ -99,6 +106,73 @@ func (db *DB) validateBelongsTos(m model.Model) error {
 	return nil
 }
 
+// decodeProviderConfigs decodes the ProviderConfig of each Kube in v, loaded
+// either as the Model or one of its associations, into the type its provider
+// registers.
+func (db *DB) decodeProviderConfigs(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if kube, ok := v.Interface().(*model.Kube); ok {
+			if err := db.decodeProviderConfig(kube); err != nil {
+				return err
+			}
+		}
+		return db.decodeProviderConfigs(v.Elem())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := db.decodeProviderConfigs(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue // unexported
+			}
+			if field := v.Field(i); field.Kind() == reflect.Ptr || field.Kind() == reflect.Slice {
+				if err := db.decodeProviderConfigs(field); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (db *DB) decodeProviderConfig(kube *model.Kube) error {
+	if len(kube.ProviderConfigJSON) == 0 {
+		return nil
+	}
+	cloudAccount := kube.CloudAccount
+	if cloudAccount == nil {
+		if kube.CloudAccountID == nil {
+			return nil
+		}
+		// NOTE this is a new scope, so that the conditions of the query which
+		// loaded the Kube don't apply.
+		cloudAccount = new(model.CloudAccount)
+		if err := db.core.DB.DB.Select("provider").First(cloudAccount, *kube.CloudAccountID).Error; err != nil {
+			return err
+		}
+	}
+	def, err := providerDefinition(cloudAccount.Provider)
+	if err != nil {
+		return err
+	}
+	if def.NewKubeConfig == nil {
+		return nil
+	}
+	config := def.NewKubeConfig()
+	if err := json.Unmarshal(kube.ProviderConfigJSON, config); err != nil {
+		return err
+	}
+	kube.ProviderConfig = config
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Helpers                                                                    //
 ////////////////////////////////////////////////////////////////////////////////
@@ -120,7 +194,25 @@ func setDefaultFields(m model.Model) {
 // validateFields takes a Model with a pointer and runs a validation on every
 // field with the validate:"..." tag.
 func validateFields(m model.Model) error {
-	return validator.Validate(m)
+	err := validator.Validate(m)
+
+	// The validator doesn't look into interfaces, such as the ProviderConfig of
+	// a Kube, so it is validated on its own.
+	if kube, ok := m.(*model.Kube); ok && kube.ProviderConfig != nil {
+		errs, _ := err.(validator.ErrorMap)
+		if configErrs, ok := validator.Validate(kube.ProviderConfig).(validator.ErrorMap); ok {
+			if errs == nil {
+				errs = make(validator.ErrorMap)
+			}
+			for field, fieldErrs := range configErrs {
+				errs["ProviderConfig."+field] = fieldErrs
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	}
+	return err
 }
 
 func marshalSerializedFields(m model.Model) {
@@ -161,6 +253,9 @@ func unmarshalSerializedFields(m model.Model) {
 				objField.Set(reflect.MakeSlice(objField.Type(), 0, 0))
 				unmarshalTo = objField.Addr()
 
+			} else if objField.Kind() == reflect.Interface {
+				continue // decoded by decodeProviderConfigs, into the type of the provider
+
 			} else { // *struct
 				objField.Set(reflect.New(objField.Type().Elem()))
 				unmarshalTo = objField
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-validator/validator"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+var ErrorKubeExternal = errors.New("Nodes of external Kubes are not managed by Supergiant")
+
+// NOTE clients are kept by config, so that each external Kube reuses its
+// connections instead of opening new ones on every call to K8S.
+var (
+	externalK8SHTTPClients      = make(map[[sha256.Size]byte]*http.Client)
+	externalK8SHTTPClientsMutex sync.Mutex
+)
+
+// isExternal returns true if the Kube is of an external provider. The Kube's
+// CloudAccount must be loaded.
+func isExternal(kube *model.Kube) bool {
+	if kube.CloudAccount == nil {
+		return false
+	}
+	def, err := providerDefinition(kube.CloudAccount.Provider)
+	return err == nil && def.External
+}
+
+// externalConfig returns the config of an external Kube, or nil if the Kube is
+// not external.
+func externalConfig(m *model.Kube) *model.ExternalKubeConfig {
+	config, _ := m.ProviderConfig.(*model.ExternalKubeConfig)
+	return config
+}
+
+// externalK8SHTTPClientKey is what the HTTP client of an external config is
+// kept by.
+func externalK8SHTTPClientKey(config *model.ExternalKubeConfig) [sha256.Size]byte {
+	return sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%t", config.Host, config.BearerToken, config.ClientCertificate, config.ClientKey, config.CertificateAuthority, config.InsecureSkipVerify)))
+}
+
+// externalK8SHTTPClient returns the HTTP client for the API server of an
+// external Kube, which presents its client certificate and bearer token.
+func externalK8SHTTPClient(config *model.ExternalKubeConfig) (*http.Client, error) {
+	key := externalK8SHTTPClientKey(config)
+
+	externalK8SHTTPClientsMutex.Lock()
+	defer externalK8SHTTPClientsMutex.Unlock()
+
+	if client, ok := externalK8SHTTPClients[key]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := externalK8STLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper = &http.Transport{
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     tlsConfig,
+	}
+	if config.BearerToken != "" {
+		transport = &bearerTokenTransport{config.BearerToken, transport}
+	}
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+	externalK8SHTTPClients[key] = client
+	return client, nil
+}
+
+// forgetExternalK8SHTTPClient drops the HTTP client of an external config, and
+// closes its idle connections, once its Kube is deleted.
+func forgetExternalK8SHTTPClient(config *model.ExternalKubeConfig) {
+	key := externalK8SHTTPClientKey(config)
+
+	externalK8SHTTPClientsMutex.Lock()
+	defer externalK8SHTTPClientsMutex.Unlock()
+
+	client, ok := externalK8SHTTPClients[key]
+	if !ok {
+		return
+	}
+	delete(externalK8SHTTPClients, key)
+	transport := client.Transport
+	if bearer, ok := transport.(*bearerTokenTransport); ok {
+		transport = bearer.base
+	}
+	if transport, ok := transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// externalK8STLSConfig verifies the API server's certificate with the config's
+// CertificateAuthority, or else the system's roots, unless InsecureSkipVerify
+// is set.
+func externalK8STLSConfig(config *model.ExternalKubeConfig) (*tls.Config, error) {
+	tlsConfig := new(tls.Config)
+
+	if config.InsecureSkipVerify {
+		if config.CertificateAuthority != "" {
+			return nil, validator.ErrorMap{
+				"ProviderConfig.InsecureSkipVerify": validator.ErrorArray{errors.New("can't be set with CertificateAuthority")},
+			}
+		}
+		tlsConfig.InsecureSkipVerify = true
+	} else if config.CertificateAuthority != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CertificateAuthority)) {
+			return nil, validator.ErrorMap{
+				"ProviderConfig.CertificateAuthority": validator.ErrorArray{errors.New("has no PEM-encoded certificates")},
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertificate != "" || config.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(config.ClientCertificate), []byte(config.ClientKey))
+		if err != nil {
+			return nil, validator.ErrorMap{
+				"ProviderConfig.ClientCertificate": validator.ErrorArray{fmt.Errorf("with ClientKey is not a valid key pair: %s", err)},
+			}
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// bearerTokenTransport authenticates requests with a bearer token, in place of
+// the basic auth guber sets.
+type bearerTokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// NOTE a RoundTripper must not modify the request it is given.
+	out := new(http.Request)
+	*out = *req
+	out.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		out.Header[k] = v
+	}
+	out.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(out)
+}
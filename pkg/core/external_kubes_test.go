@@ -0,0 +1,39 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExternalK8SHTTPClient(t *testing.T) {
+	Convey("Given the config of an external Kube", t, func() {
+		config := &model.ExternalKubeConfig{Host: "k8s.example.com:6443", BearerToken: "token"}
+
+		Convey("When its client is created", func() {
+			client, err := externalK8SHTTPClient(config)
+			So(err, ShouldBeNil)
+			again, _ := externalK8SHTTPClient(config)
+			insecure, _ := externalK8SHTTPClient(&model.ExternalKubeConfig{Host: config.Host, BearerToken: config.BearerToken, InsecureSkipVerify: true})
+			tlsConfig, _ := externalK8STLSConfig(config)
+
+			Convey("It should be reused for the config, verify the API server with the system's roots, and not be reused for another", func() {
+				So(again, ShouldEqual, client)
+				So(insecure, ShouldNotEqual, client)
+				So(tlsConfig.InsecureSkipVerify, ShouldBeFalse)
+				So(tlsConfig.RootCAs, ShouldBeNil)
+			})
+
+			Convey("When its Kube is deleted", func() {
+				forgetExternalK8SHTTPClient(config)
+				recreated, _ := externalK8SHTTPClient(config)
+
+				Convey("It should be dropped", func() {
+					So(recreated, ShouldNotEqual, client)
+				})
+			})
+		})
+	})
+}
@@ -2,9 +2,10 @@ package core
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/supergiant/guber"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 	"github.com/supergiant/supergiant/pkg/util"
 )
@@ -17,15 +18,23 @@ func (c *Instances) CreateVolumes(id *int64, m *model.Instance) error {
 	action := &Action{
 		Status: &model.ActionStatus{
 			Description: "creating volumes",
-			MaxRetries:  1, // see NOTE in Volume
+			MaxRetries:  5,
 		},
 		core:  c.core,
 		scope: c.core.DB.Preload("Component.App.Kube.CloudAccount").Preload("Component.CurrentRelease").Preload("Component.TargetRelease").Preload("Release").Preload("Volumes.Kube.CloudAccount"), // voluemes preloaded in case of retry and loading
 		model: m,
 		id:    id,
 		fn: func(_ *Action) error {
+			if err := c.reattachRetainedVolumes(m); err != nil {
+				return err
+			}
+			zone := c.availabilityZone(m)
+
 			return c.inParallel(m.Release.Config.Volumes, func(vc interface{}) error {
 				volConf := vc.(*model.VolumeBlueprint)
+				if volConf.KindOrDefault() != model.VolumeKindEBS {
+					return nil // nothing to create
+				}
 				var volume *model.Volume
 
 				for _, existingVol := range m.Volumes {
@@ -44,6 +53,14 @@ func (c *Instances) CreateVolumes(id *int64, m *model.Instance) error {
 						Name:       volConf.Name,
 						Type:       volConf.Type,
 						Size:       volConf.Size,
+						IOPS:       volConf.IOPS,
+						Throughput: volConf.Throughput,
+						SnapshotID: volConf.SnapshotID,
+
+						AvailabilityZone: zone,
+
+						ComponentID: m.ComponentID,
+						InstanceNum: m.Num,
 					}
 					if err := c.core.Volumes.Create(volume); err != nil {
 						return err
@@ -74,7 +91,7 @@ func (c *Instances) Start(id *int64, m *model.Instance) *Action {
 		scope: c.core.DB.Preload("Component.App.Kube.CloudAccount").Preload("Component.PrivateImageKeys.Key").Preload("Component.CurrentRelease").Preload("Component.TargetRelease").Preload("Release").Preload("Volumes.Kube.CloudAccount"),
 		model: m,
 		id:    id,
-		fn: func(_ *Action) error {
+		fn: func(action *Action) error {
 			if m.Started {
 				return fmt.Errorf("Instance %d already started", m.ID)
 			}
@@ -120,11 +137,21 @@ func (c *Instances) Start(id *int64, m *model.Instance) *Action {
 
 			// TODO sloppy... we need a way (can't do this at initialize due to how we set
 			// Release above) to get the volume conf from the Release for a volume
+			//
+			// NOTE a change of type or IOPS is made like a resize; on AWS the volume
+			// is replaced by one of the new type from a snapshot. The new config is
+			// saved first, since the resize Action loads the Volume.
 			err = c.inParallel(m.Volumes, func(vi interface{}) error {
 				volume := vi.(*model.Volume)
 				for _, volConf := range m.Release.Config.Volumes {
-					if volume.Name == volConf.Name && volume.Size != volConf.Size {
+					if volume.Name == volConf.Name && volumeChanged(volume, volConf) {
+						volume.Type = volConf.Type
 						volume.Size = volConf.Size
+						volume.IOPS = volConf.IOPS
+						volume.Throughput = volConf.Throughput
+						if err := c.core.DB.Save(volume); err != nil {
+							return err
+						}
 						if err := c.core.Volumes.Resize(volume.ID, volume).Now(); err != nil {
 							return err
 						}
@@ -136,7 +163,7 @@ func (c *Instances) Start(id *int64, m *model.Instance) *Action {
 				return err
 			}
 
-			if err := c.provisionReplicationController(m); err != nil {
+			if err := c.provisionReplicationController(m, action); err != nil {
 				return err
 			}
 
@@ -154,6 +181,39 @@ func (c *Instances) Start(id *int64, m *model.Instance) *Action {
 			if err != nil {
 				return err
 			}
+
+			// The filesystems of grown Volumes are grown once the containers growing
+			// them log so. Those containers are then dropped from the RC.
+			for i, volume := range m.Volumes {
+				if !volume.GrowFilesystem {
+					continue
+				}
+				name := growFilesystemContainerName(i)
+				err = action.CancellableWaitFor(fmt.Sprintf("filesystem of Volume %s to grow", volume.Name), 10*time.Minute, 3*time.Second, func() (bool, error) {
+					pod, err := c.pod(m)
+					if err != nil {
+						return false, err
+					}
+					if !hasContainer(pod.Spec.Containers, name) {
+						return false, fmt.Errorf("Pod of Instance %d has no container %s to grow the filesystem of Volume %s", *m.ID, name, volume.Name)
+					}
+					log, err := pod.Log(name)
+					if err != nil {
+						return false, err
+					}
+					return strings.Contains(log, growFilesystemLog), nil
+				})
+				if err != nil {
+					return err
+				}
+				if err := c.core.DB.Model(volume).Update("grow_filesystem", false).Error; err != nil {
+					return err
+				}
+			}
+			if err := c.dropGrowFilesystemContainers(m); err != nil {
+				return err
+			}
+
 			return c.core.DB.Model(m).Update("started", true).Error
 		},
 	}
@@ -169,8 +229,8 @@ func (c *Instances) Stop(id *int64, m *model.Instance) *Action {
 		scope: c.core.DB.Preload("Component.App.Kube.CloudAccount").Preload("Component.CurrentRelease").Preload("Component.TargetRelease").Preload("Release").Preload("Volumes.Kube.CloudAccount"),
 		model: m,
 		id:    id,
-		fn: func(_ *Action) error {
-			if err := c.deleteReplicationControllerAndPod(m); err != nil {
+		fn: func(action *Action) error {
+			if err := c.deleteReplicationControllerAndPod(m, action); err != nil {
 				return err
 			}
 
@@ -190,12 +250,12 @@ func (c *Instances) Delete(id *int64, m *model.Instance) *Action {
 		model:          m,
 		id:             id,
 		cancelExisting: true,
-		fn: func(_ *Action) error {
-			if err := c.deleteReplicationControllerAndPod(m); err != nil {
+		fn: func(action *Action) error {
+			if err := c.deleteReplicationControllerAndPod(m, action); err != nil {
 				return err
 			}
 			for _, volume := range m.Volumes {
-				if err := c.core.Volumes.Delete(volume.ID, volume).Now(); err != nil {
+				if err := c.reclaimVolume(m, volume); err != nil {
 					return err
 				}
 			}
@@ -251,9 +311,140 @@ func (c *Instances) serviceSet(m *model.Instance) (*ServiceSet, error) {
 	return NewServiceSet(c.core, m.Component, m.Release, m.Name, labelSelector, portFilter)
 }
 
-func (c *Instances) provisionReplicationController(m *model.Instance) error {
-	if _, err := c.core.K8S(m.Component.App.Kube).ReplicationControllers(m.Component.App.Name).Get(m.Name); err == nil {
-		return nil // already provisioned
+// availabilityZone returns the zone of an Instance's Volumes; that of those it
+// has, or else one of its Kube's zones by its number, so that the Instances of
+// a Component are spread across them.
+func (c *Instances) availabilityZone(m *model.Instance) string {
+	for _, volume := range m.Volumes {
+		if volume.AvailabilityZone != "" {
+			return volume.AvailabilityZone
+		}
+	}
+	zones := c.core.availabilityZones(m.Component.App.Kube)
+	if len(zones) == 0 {
+		return ""
+	}
+	return zones[m.Num%len(zones)]
+}
+
+// reattachRetainedVolumes gives an Instance the Volumes retained from a deleted
+// Instance of the same Component and number, which its Release still has.
+// Volumes larger than the Release gives them stay retained, as they can't
+// shrink.
+func (c *Instances) reattachRetainedVolumes(m *model.Instance) error {
+	var retained []*model.Volume
+	if err := c.core.DB.Preload("Kube.CloudAccount").Where("retained = ? AND component_id = ? AND instance_num = ?", true, m.ComponentID, m.Num).Order("id desc").Find(&retained); err != nil {
+		return err
+	}
+
+VolumesLoop:
+	for _, volume := range retained {
+		volConf := volumeBlueprint(m.Release, volume.Name)
+		if volConf == nil || volConf.KindOrDefault() != model.VolumeKindEBS || volume.Size > volConf.Size {
+			continue
+		}
+		for _, existingVol := range m.Volumes {
+			if existingVol.Name == volume.Name {
+				continue VolumesLoop
+			}
+		}
+
+		volume.InstanceID = m.ID
+		volume.Retained = false
+		if err := c.core.DB.Save(volume); err != nil {
+			return err
+		}
+		m.Volumes = append(m.Volumes, volume)
+	}
+	return nil
+}
+
+// reclaimVolume deletes a Volume of a deleted Instance, or retains it, or
+// snapshots it before deleting it, by the ReclaimPolicy of its Release.
+func (c *Instances) reclaimVolume(m *model.Instance, volume *model.Volume) error {
+	var policy string
+	if volConf := volumeBlueprint(m.Release, volume.Name); volConf != nil {
+		policy = volConf.ReclaimPolicy
+	}
+
+	switch policy {
+	case model.ReclaimPolicyRetain:
+		return c.core.DB.Model(volume).Updates(map[string]interface{}{
+			"retained":     true,
+			"component_id": m.ComponentID,
+			"instance_num": m.Num,
+		}).Error
+
+	case model.ReclaimPolicySnapshot:
+		// NOTE a retry after the Snapshot was taken takes another.
+		if volume.ProviderID != "" {
+			snapshot := &model.Snapshot{VolumeID: volume.ID}
+			if err := c.core.Snapshots.create(snapshot); err != nil {
+				return err
+			}
+			if err := c.core.Snapshots.Provision(snapshot.ID, snapshot).Now(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.core.Volumes.Delete(volume.ID, volume).Now()
+}
+
+// dropGrowFilesystemContainers removes the containers growing the filesystems
+// of Volumes from the RC of an Instance, once they have. The running pod keeps
+// them, idle, until it is replaced.
+func (c *Instances) dropGrowFilesystemContainers(m *model.Instance) error {
+	rcs := c.core.K8S(m.Component.App.Kube).ReplicationControllers(m.Component.App.Name)
+	rc, err := rcs.Get(m.Name)
+	if err != nil {
+		return err
+	}
+	var containers []*guber.Container
+	for _, container := range rc.Spec.Template.Spec.Containers {
+		if !strings.HasPrefix(container.Name, growFilesystemContainerPrefix) {
+			containers = append(containers, container)
+		}
+	}
+	if len(containers) == len(rc.Spec.Template.Spec.Containers) {
+		return nil
+	}
+	rc.Spec.Template.Spec.Containers = containers
+	_, err = rcs.Update(m.Name, rc)
+	return err
+}
+
+// missingGrowFilesystemContainers returns true if the RC of an Instance lacks
+// the container growing the filesystem of any of its Volumes that has grown.
+func missingGrowFilesystemContainers(rc *guber.ReplicationController, m *model.Instance) bool {
+	for i, volume := range m.Volumes {
+		if volume.GrowFilesystem && !hasContainer(rc.Spec.Template.Spec.Containers, growFilesystemContainerName(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasContainer(containers []*guber.Container, name string) bool {
+	for _, container := range containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Instances) provisionReplicationController(m *model.Instance, action *Action) error {
+	if rc, err := c.core.K8S(m.Component.App.Kube).ReplicationControllers(m.Component.App.Name).Get(m.Name); err == nil {
+		// An RC created before a Volume grew (such as by an attempt that was
+		// interrupted after the resize) has no container to grow its filesystem,
+		// so it is replaced.
+		if !missingGrowFilesystemContainers(rc, m) {
+			return nil // already provisioned
+		}
+		if err := c.deleteReplicationControllerAndPod(m, action); err != nil {
+			return err
+		}
 	} else if !isKubeNotFoundErr(err) {
 		return err
 	}
@@ -263,16 +454,34 @@ func (c *Instances) provisionReplicationController(m *model.Instance) error {
 		containers = append(containers, asKubeContainer(blueprint, m))
 	}
 
+	nodeSelector, err := c.attachVolumes(m, action)
+	if err != nil {
+		return err
+	}
+
+	provider := c.core.CloudAccounts.provider(m.Component.App.Kube.CloudAccount)
 	var kubeVolumes []*guber.Volume
-	for _, volume := range m.Volumes {
-		kubeVol := &guber.Volume{
-			Name: volume.Name,
-			AwsElasticBlockStore: &guber.AwsElasticBlockStore{
-				VolumeID: volume.ProviderID,
-				FSType:   "ext4",
-			},
+	for i, volume := range m.Volumes {
+		if volume.AvailabilityZone != "" && nodeSelector == nil {
+			nodeSelector = map[string]string{
+				availabilityZoneLabel: volume.AvailabilityZone,
+			}
 		}
+		kubeVol, err := provider.KubeVolume(volume)
+		if err != nil {
+			return err
+		}
+		kubeVol.Name = volume.Name
 		kubeVolumes = append(kubeVolumes, kubeVol)
+
+		if volume.GrowFilesystem {
+			containers = append(containers, growFilesystemContainer(i, volume))
+		}
+	}
+	for _, volConf := range m.Release.Config.Volumes {
+		if volConf.KindOrDefault() != model.VolumeKindEBS {
+			kubeVolumes = append(kubeVolumes, asKubeVolume(volConf, m.Component.App.Kube))
+		}
 	}
 
 	var pullSecrets []*guber.ImagePullSecret
@@ -306,19 +515,21 @@ func (c *Instances) provisionReplicationController(m *model.Instance) error {
 					Containers:                    containers,
 					ImagePullSecrets:              pullSecrets,
 					TerminationGracePeriodSeconds: m.Release.Config.TerminationGracePeriod,
+					NodeSelector:                  nodeSelector,
 				},
 			},
 		},
 	}
-	_, err := c.core.K8S(m.Component.App.Kube).ReplicationControllers(m.Component.App.Name).Create(rc)
+	_, err = c.core.K8S(m.Component.App.Kube).ReplicationControllers(m.Component.App.Name).Create(rc)
 	return err
 }
 
-func (c *Instances) deleteReplicationControllerAndPod(m *model.Instance) error {
+func (c *Instances) deleteReplicationControllerAndPod(m *model.Instance, action *Action) error {
 	pod, err := c.pod(m)
 	if err != nil {
 		if _, podNotFound := err.(*PodNotFoundError); podNotFound {
-			return nil
+			// The pod may be gone before its Volumes were detached.
+			return c.detachVolumes(m, action)
 		}
 		return err
 	}
@@ -330,6 +541,9 @@ func (c *Instances) deleteReplicationControllerAndPod(m *model.Instance) error {
 	if err := pod.Delete(); err != nil {
 		return err
 	}
+	if err := c.detachVolumes(m, action); err != nil {
+		return err
+	}
 	// Wait for volume detach
 	for _, volume := range m.Volumes {
 		if err := c.core.Volumes.WaitForAvailable(volume.ID, volume); err != nil {
@@ -338,3 +552,76 @@ func (c *Instances) deleteReplicationControllerAndPod(m *model.Instance) error {
 	}
 	return nil
 }
+
+// attachVolumes attaches the Volumes of an Instance to one of its Kube's ready
+// Nodes, for providers whose volumes Kubernetes can't attach, and returns the
+// node selector which keeps its pod on that Node. The Nodes are taken in turn
+// by the Instance's number, as zones are.
+func (c *Instances) attachVolumes(m *model.Instance, action *Action) (map[string]string, error) {
+	attacher, ok := c.core.CloudAccounts.provider(m.Component.App.Kube.CloudAccount).(AttachingProvider)
+	if !ok || len(m.Volumes) == 0 {
+		return nil, nil
+	}
+	var nodes []*model.Node
+	if err := c.core.DB.Where("kube_id = ? AND ready = ?", m.Component.App.KubeID, true).Order("id").Find(&nodes); err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("No ready Node to attach the Volumes of Instance %d to", *m.ID)
+	}
+	node := nodes[m.Num%len(nodes)]
+	for _, volume := range m.Volumes {
+		if err := attacher.AttachVolume(volume, node, action); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]string{"kubernetes.io/hostname": node.Name}, nil
+}
+
+// detachVolumes detaches the Volumes attached by attachVolumes, once the pod
+// of the Instance is gone.
+func (c *Instances) detachVolumes(m *model.Instance, action *Action) error {
+	attacher, ok := c.core.CloudAccounts.provider(m.Component.App.Kube.CloudAccount).(AttachingProvider)
+	if !ok || len(m.Volumes) == 0 {
+		return nil
+	}
+	err := util.WaitFor(fmt.Sprintf("Pod of Instance %d to stop", *m.ID), 5*time.Minute, 3*time.Second, func() (bool, error) {
+		_, err := c.pod(m)
+		if _, podNotFound := err.(*PodNotFoundError); podNotFound {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, volume := range m.Volumes {
+		if err := attacher.DetachVolume(volume, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// volumeChanged returns true if a Volume differs from its config in the
+// Release in a way that needs the volume to be resized or replaced.
+func volumeChanged(volume *model.Volume, volConf *model.VolumeBlueprint) bool {
+	return volume.Size != volConf.Size ||
+		volume.Type != volConf.Type ||
+		volume.IOPS != volConf.IOPS ||
+		volume.Throughput != volConf.Throughput
+}
+
+// volumeBlueprint returns the VolumeBlueprint of the name in the Release, if it
+// has one.
+func volumeBlueprint(release *model.Release, name string) *model.VolumeBlueprint {
+	if release == nil || release.Config == nil {
+		return nil
+	}
+	for _, volConf := range release.Config.Volumes {
+		if volConf.Name == name {
+			return volConf
+		}
+	}
+	return nil
+}
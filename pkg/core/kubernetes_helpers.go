@@ -1,10 +1,11 @@
 package core
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
-	"github.com/supergiant/guber"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 )
 
@@ -12,6 +13,22 @@ import (
 // resource definition into a Kubernetes resource defition.
 // (and some other assorted things that should maybe be moved out...)
 
+// availabilityZoneLabel is the label the kubelet gives a Node of the zone it is
+// in, which pods are kept in a zone by.
+const availabilityZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+
+// growFilesystemImage is the image with resize2fs and findmnt that grows the
+// filesystems of Volumes.
+const growFilesystemImage = "debian:jessie"
+
+// growFilesystemContainerPrefix is the start of the names of the containers
+// that grow the filesystems of Volumes.
+const growFilesystemContainerPrefix = "grow-filesystem-"
+
+// growFilesystemLog is logged by a growFilesystemContainer once it has grown
+// the filesystem.
+const growFilesystemLog = "filesystem grown"
+
 func isKubeNotFoundErr(err error) bool {
 	_, yes := err.(*guber.Error404)
 	return yes
@@ -93,6 +110,60 @@ func asKubeContainer(m *model.ContainerBlueprint, instance *model.Instance) *gub
 	return container
 }
 
+// asKubeVolume returns the Kubernetes volume of a Volume which isn't a disk of
+// the provider. efs Volumes are mounted over NFS, by the DNS name of the file
+// system in the Kube's region.
+func asKubeVolume(m *model.VolumeBlueprint, kube *model.Kube) *guber.Volume {
+	volume := &guber.Volume{Name: m.Name}
+	switch m.Kind {
+	case model.VolumeKindEmptyDir:
+		volume.EmptyDir = new(guber.EmptyDir)
+	case model.VolumeKindHostPath:
+		volume.HostPath = &guber.HostPath{Path: m.Path}
+	case model.VolumeKindNFS:
+		volume.NFS = &guber.NFS{Server: m.Server, Path: m.Path, ReadOnly: m.ReadOnly}
+	case model.VolumeKindEFS:
+		server := fmt.Sprintf("%s.efs.%s.amazonaws.com", m.Server, kube.ProviderConfig.(*model.AWSKubeConfig).Region)
+		volume.NFS = &guber.NFS{Server: server, Path: m.Path, ReadOnly: m.ReadOnly}
+	}
+	return volume
+}
+
+// isDiskVolume returns true of a Kubernetes volume of a disk which Kubernetes
+// attaches to the Node of its pod, taking one of the Node's disk slots.
+func isDiskVolume(volume *guber.Volume) bool {
+	return volume.AwsElasticBlockStore != nil || volume.GCEPersistentDisk != nil || volume.Cinder != nil
+}
+
+// growFilesystemContainer grows the ext4 filesystem of a Volume, while it is
+// mounted, to the size the volume has grown to, and logs growFilesystemLog. It
+// then idles, as a container of a pod which exits is restarted.
+func growFilesystemContainer(n int, volume *model.Volume) *guber.Container {
+	path := "/mnt/" + volume.Name
+	return &guber.Container{
+		Name:  growFilesystemContainerName(n),
+		Image: growFilesystemImage,
+		Command: []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf(`resize2fs "$(findmnt -n -o SOURCE --target %s)" && echo %q && exec sleep 2147483647`, path, growFilesystemLog),
+		},
+		Resources: &guber.Resources{
+			Requests: &guber.ResourceValues{Memory: "32Mi", CPU: "10m"},
+			Limits:   new(guber.ResourceValues),
+		},
+		VolumeMounts: []*guber.VolumeMount{
+			{Name: volume.Name, MountPath: path},
+		},
+		SecurityContext: &guber.SecurityContext{
+			Privileged: true,
+		},
+	}
+}
+
+func growFilesystemContainerName(n int) string {
+	return fmt.Sprintf("%s%d", growFilesystemContainerPrefix, n)
+}
+
 // EnvVar
 //==============================================================================
 func interpolatedValue(m *model.EnvVar, instance *model.Instance) string {
@@ -2,14 +2,22 @@ package core
 
 import (
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
 	"time"
 
-	"github.com/supergiant/guber"
+	"github.com/go-validator/validator"
+	"github.com/imdario/mergo"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 	"github.com/supergiant/supergiant/pkg/util"
 )
 
+var ErrorKubeAlreadyProvisioned = errors.New("Kube is already provisioned")
+
 // TODO
 var globalK8SHTTPClient = &http.Client{
 	Timeout: 30 * time.Second,
@@ -22,6 +30,19 @@ var globalK8SHTTPClient = &http.Client{
 }
 
 func (c *Core) K8S(m *model.Kube) guber.Client {
+	if c.K8SProvider != nil {
+		return c.K8SProvider(m)
+	}
+	if config := externalConfig(m); config != nil {
+		httpClient, err := externalK8SHTTPClient(config)
+		if err != nil {
+			// NOTE the config is checked when the Kube is created, so this is only
+			// logged; requests then fail to authenticate.
+			c.Log.Errorf("Invalid external config of Kube %s: %s", m.Name, err)
+			httpClient = globalK8SHTTPClient
+		}
+		return guber.NewClient(config.Host, m.Username, m.Password, httpClient)
+	}
 	return guber.NewClient(m.MasterPublicIP, m.Username, m.Password, globalK8SHTTPClient)
 }
 
@@ -31,40 +52,113 @@ type Kubes struct {
 	Collection
 }
 
+// kubeRetryPolicy backs off further than the default, since provisioning
+// failures are mostly spent waiting on AWS resources that aren't ready yet.
+var kubeRetryPolicy = &model.RetryPolicy{
+	InitialDelay: 15,
+	Multiplier:   2,
+	MaxDelay:     600,
+	Jitter:       0.2,
+	FatalErrors:  fatalAWSErrors,
+}
+
 func (c *Kubes) Create(m *model.Kube) error {
 	// Defaults
 	if m.Username == "" && m.Password == "" {
 		m.Username = util.RandomString(16)
 		m.Password = util.RandomString(8)
 	}
+	// External Kubes may have no node sizes, but they are stored all the same.
+	if m.NodeSizes == nil {
+		m.NodeSizes = []string{}
+	}
+
+	if err := c.validateProviderConfig(m); err != nil {
+		return err
+	}
 
 	if err := c.Collection.Create(m); err != nil {
 		return err
 	}
+	return c.Provision(m.ID, m).Async()
+}
+
+// Update changes a Kube. Its ProviderConfig is merged with the one it has, like
+// the rest of its fields, so only the fields of it that change need be given.
+// Createonly fields of it are kept as they are.
+func (c *Kubes) Update(id *int64, oldM *model.Kube, m *model.Kube) error {
+	if err := c.core.DB.First(oldM, *id); err != nil {
+		return err
+	}
+	cloudAccount := new(model.CloudAccount)
+	if err := c.core.DB.First(cloudAccount, *oldM.CloudAccountID); err != nil {
+		return err
+	}
+	def, err := providerDefinition(cloudAccount.Provider)
+	if err != nil {
+		return err
+	}
+	if err := def.decodeKubeConfig(m); err != nil {
+		return err
+	}
+	if m.ProviderConfig != nil && reflect.TypeOf(m.ProviderConfig) != reflect.TypeOf(oldM.ProviderConfig) {
+		return validator.ErrorMap{
+			"ProviderConfig": validator.ErrorArray{errors.New("is not the config of provider " + def.Name)},
+		}
+	}
+	if err := mergo.Merge(m, oldM); err != nil {
+		return err
+	}
+	model.KeepCreateonlyFields(m, oldM)
+	return c.core.DB.Save(m)
+}
 
-	provision := &Action{
+func (c *Kubes) Provision(id *int64, m *model.Kube) *Action {
+	return &Action{
 		Status: &model.ActionStatus{
 			Description: "provisioning",
+			RetryPolicy: kubeRetryPolicy,
 			MaxRetries:  20,
 		},
-		core:       c.core,
-		resourceID: m.UUID,
-		model:      m,
+		core:  c.core,
+		scope: c.core.DB.Preload("CloudAccount"),
+		model: m,
+		id:    id,
 		fn: func(a *Action) error {
 			return c.core.CloudAccounts.provider(m.CloudAccount).CreateKube(m, a)
 		},
 	}
-	return provision.Async()
+}
+
+// Resume restarts the failed provisioning of a Kube. Steps completed by an
+// earlier attempt are skipped.
+func (c *Kubes) Resume(id *int64, m *model.Kube) error {
+	if err := c.Get(id, m); err != nil {
+		return err
+	}
+	if m.Ready {
+		return ErrorKubeAlreadyProvisioned
+	}
+
+	if existing := c.core.Actions.Get(m.UUID); existing != nil {
+		if existing.Status.Description != "provisioning" {
+			return &RepeatedActionError{m.UUID}
+		}
+		_, err := c.core.Actions.Retry(m.UUID)
+		return err
+	}
+	return c.Provision(id, m).Async()
 }
 
 func (c *Kubes) Delete(id *int64, m *model.Kube) *Action {
 	return &Action{
 		Status: &model.ActionStatus{
 			Description: "deleting",
+			RetryPolicy: kubeRetryPolicy,
 			MaxRetries:  5,
 		},
 		core:           c.core,
-		scope:          c.core.DB.Preload("CloudAccount").Preload("Entrypoints").Preload("Volumes.Kube.CloudAccount").Preload("Apps.Components.Instances").Preload("Apps.Components.Releases").Preload("Nodes.Kube.CloudAccount"),
+		scope:          c.core.DB.Preload("CloudAccount").Preload("Entrypoints").Preload("Volumes.Kube.CloudAccount").Preload("Snapshots.Kube.CloudAccount").Preload("Apps.Components.Instances").Preload("Apps.Components.Releases").Preload("Nodes.Kube.CloudAccount"),
 		model:          m,
 		id:             id,
 		cancelExisting: true,
@@ -111,10 +205,83 @@ func (c *Kubes) Delete(id *int64, m *model.Kube) *Action {
 					return err
 				}
 			}
+			for _, snapshot := range m.Snapshots {
+				if err := c.core.Snapshots.Delete(snapshot.ID, snapshot).Now(); err != nil {
+					return err
+				}
+			}
 			if err := c.core.CloudAccounts.provider(m.CloudAccount).DeleteKube(m); err != nil {
 				return err
 			}
-			return c.Collection.Delete(id, m)
+			if err := c.Collection.Delete(id, m); err != nil {
+				return err
+			}
+			if config := externalConfig(m); config != nil {
+				forgetExternalK8SHTTPClient(config)
+			}
+			return nil
 		},
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+// validateProviderConfig checks the provider config of a new Kube against the
+// provider of its CloudAccount.
+func (c *Kubes) validateProviderConfig(m *model.Kube) error {
+	if m.CloudAccountID == nil {
+		return nil // reported by Create
+	}
+	cloudAccount := new(model.CloudAccount)
+	if err := c.core.DB.First(cloudAccount, *m.CloudAccountID); err != nil {
+		return err
+	}
+	def, err := providerDefinition(cloudAccount.Provider)
+	if err != nil {
+		return err
+	}
+	if err := def.decodeKubeConfig(m); err != nil {
+		return err
+	}
+	if err := def.validateKubeConfig(m); err != nil {
+		return err
+	}
+	if config := externalConfig(m); config != nil {
+		if _, err := externalK8STLSConfig(config); err != nil {
+			return err
+		}
+	}
+	if m.SpotPolicy != nil {
+		return c.validateSpotPolicy(cloudAccount, m)
+	}
+	return nil
+}
+
+// validateSpotPolicy checks that the provider of a Kube has spot servers, and
+// that its SpotPolicy is of the Kube's node sizes.
+func (c *Kubes) validateSpotPolicy(cloudAccount *model.CloudAccount, m *model.Kube) error {
+	var errs validator.ErrorArray
+	if _, ok := c.core.CloudAccounts.provider(cloudAccount).(SpotProvider); !ok {
+		errs = append(errs, fmt.Errorf("is not supported by provider %s", cloudAccount.Provider))
+	}
+	nodeSizes := make(map[string]bool)
+	for _, size := range m.NodeSizes {
+		nodeSizes[size] = true
+	}
+	for _, size := range m.SpotPolicy.NodeSizes {
+		if !nodeSizes[size] {
+			errs = append(errs, fmt.Errorf("node size %s is not one of the Kube's node sizes", size))
+		}
+	}
+	if m.SpotPolicy.MaxPrice != "" {
+		if price, err := strconv.ParseFloat(m.SpotPolicy.MaxPrice, 64); err != nil || price <= 0 {
+			errs = append(errs, fmt.Errorf("max price %q must be a positive number of USD", m.SpotPolicy.MaxPrice))
+		}
+	}
+	if len(errs) > 0 {
+		return validator.ErrorMap{"SpotPolicy": errs}
+	}
+	return nil
+}
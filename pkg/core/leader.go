@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/util"
+)
+
+const (
+	leaderLeaseName = "leader"
+
+	// A follower takes over once the leader has failed to renew the lease for
+	// leaderLeaseDuration.
+	leaderLeaseDuration = 30 * time.Second
+	leaderRenewInterval = 10 * time.Second
+
+	// A leader that can't renew the lease stops leading once it has no more
+	// than leaderFenceTime left on it, and gives its Actions until then to stop,
+	// so that they are done before another server can take over.
+	leaderFenceTime = 10 * time.Second
+)
+
+// Leader elects one of the Supergiant servers sharing a database as the
+// leader, using a Lease row that the leader renews as a heartbeat. Only the
+// leader runs RecurringServices and async Actions; the other servers (the
+// followers) just serve the API, queueing Actions for the leader.
+//
+// The expiry of the Lease is by the clock of the database, so that the servers'
+// own clocks needn't agree. The leader only times its own hold on the Lease,
+// from before each renewal.
+type Leader struct {
+	core *Core
+	id   string
+
+	mutex     sync.RWMutex
+	leading   bool
+	resigned  bool
+	expiresAt time.Time
+}
+
+func NewLeader(core *Core) *Leader {
+	hostname, _ := os.Hostname()
+	return &Leader{
+		core: core,
+		id:   hostname + "-" + util.RandomString(8),
+	}
+}
+
+// ID identifies this server as a holder of the Lease.
+func (l *Leader) ID() string {
+	return l.id
+}
+
+func (l *Leader) IsLeader() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.leading
+}
+
+// Run tries to acquire (or renew) the Lease every leaderRenewInterval, until
+// ctx is done.
+func (l *Leader) Run(ctx context.Context) {
+	ticker := time.NewTicker(leaderRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.tick()
+		}
+	}
+}
+
+// stepDown stops this server acting as leader, for good, though it keeps the
+// Lease until release() so no other server takes over in the meantime.
+func (l *Leader) stepDown() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.leading = false
+	l.resigned = true
+}
+
+// release expires the Lease, if held, so another server can take over without
+// waiting out leaderLeaseDuration.
+func (l *Leader) release() error {
+	now, err := l.databaseNow()
+	if err != nil {
+		return err
+	}
+	return l.core.DB.DB.Model(new(model.Lease)).
+		Where("name = ? AND holder_id = ?", leaderLeaseName, l.id).
+		Update("expires_at", now).Error
+}
+
+func (l *Leader) tick() {
+	l.mutex.RLock()
+	resigned := l.resigned
+	l.mutex.RUnlock()
+	if resigned {
+		return
+	}
+
+	// Timed before the renewal, so we never count on holding the Lease for
+	// longer than we do.
+	heldUntil := time.Now().Add(leaderLeaseDuration)
+
+	acquired, err := l.acquire()
+	if err != nil {
+		l.core.Log.Error("Error renewing leader Lease: ", err)
+
+		// Keep leading while there is time to try again before fencing.
+		l.mutex.RLock()
+		stillValid := l.leading && time.Now().Add(leaderFenceTime).Before(l.expiresAt)
+		l.mutex.RUnlock()
+		if stillValid {
+			return
+		}
+		acquired = false
+	}
+
+	l.mutex.Lock()
+	wasLeading := l.leading
+	lostAt := l.expiresAt
+	l.leading = acquired
+	if acquired {
+		l.expiresAt = heldUntil
+	}
+	l.mutex.Unlock()
+
+	switch {
+	case acquired && !wasLeading:
+		l.core.Log.Infof("Elected leader (%s)", l.id)
+		if err := l.core.Actions.Resume(); err != nil {
+			l.core.Log.Error("Error resuming Actions: ", err)
+		}
+
+	case !acquired && wasLeading:
+		l.core.Log.Warnf("Lost leader Lease (%s), abandoning Actions", l.id)
+		ctx, cancel := context.WithDeadline(context.Background(), lostAt)
+		defer cancel()
+		if err := l.core.Actions.Drain(ctx); err != nil {
+			l.core.Log.Error("Error abandoning Actions: ", err)
+		}
+	}
+}
+
+// acquire takes the Lease if it is unheld, expired, or already ours, and
+// returns true if we hold it for leaderLeaseDuration.
+func (l *Leader) acquire() (bool, error) {
+	now, err := l.databaseNow()
+	if err != nil {
+		return false, err
+	}
+	expiresAt := now.Add(leaderLeaseDuration)
+
+	result := l.core.DB.DB.Model(new(model.Lease)).
+		Where("name = ? AND (holder_id = ? OR expires_at <= ?)", leaderLeaseName, l.id, now).
+		Updates(map[string]interface{}{"holder_id": l.id, "expires_at": expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// The Lease may not exist yet.
+	var count int
+	if err := l.core.DB.DB.Model(new(model.Lease)).Where("name = ?", leaderLeaseName).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	lease := &model.Lease{
+		Name:      leaderLeaseName,
+		HolderID:  l.id,
+		ExpiresAt: expiresAt,
+	}
+	if err := l.core.DB.Create(lease); err != nil {
+		// Another server created it first (Name is unique).
+		return false, nil
+	}
+	return true, nil
+}
+
+// databaseNow returns the current time by the clock of the database.
+func (l *Leader) databaseNow() (time.Time, error) {
+	var now interface{}
+	if err := l.core.DB.DB.Raw("SELECT CURRENT_TIMESTAMP").Row().Scan(&now); err != nil {
+		return time.Time{}, err
+	}
+	switch now := now.(type) {
+	case time.Time: // Postgres
+		return now.UTC(), nil
+	case []byte: // SQLite, which has it as text, in UTC
+		return time.Parse("2006-01-02 15:04:05", string(now))
+	case string:
+		return time.Parse("2006-01-02 15:04:05", now)
+	}
+	return time.Time{}, fmt.Errorf("Unexpected database time %v", now)
+}
@@ -1,10 +1,13 @@
 package core
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
-	"github.com/supergiant/guber"
+	"github.com/go-validator/validator"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 )
 
@@ -12,47 +15,63 @@ type Nodes struct {
 	Collection
 }
 
+var nodeRetryPolicy = &model.RetryPolicy{
+	InitialDelay: 30,
+	Multiplier:   2,
+	MaxDelay:     300,
+	Jitter:       0.2,
+	FatalErrors:  append(fatalAWSErrors, "InstanceLimitExceeded", "Unsupported"),
+}
+
 func (c *Nodes) Create(m *model.Node) error {
+	if m.KubeID != nil {
+		kube := new(model.Kube)
+		if err := c.core.DB.Preload("CloudAccount").First(kube, *m.KubeID); err == nil {
+			if isExternal(kube) {
+				return ErrorKubeExternal
+			}
+			if err := c.setAvailabilityZone(kube, m); err != nil {
+				return err
+			}
+		}
+	}
 	if err := c.Collection.Create(m); err != nil {
 		return err
 	}
+	return c.Provision(m.ID, m).Async()
+}
 
-	provision := &Action{
+func (c *Nodes) Provision(id *int64, m *model.Node) *Action {
+	return &Action{
 		Status: &model.ActionStatus{
 			Description: "provisioning",
+			RetryPolicy: nodeRetryPolicy,
 
-			// TODO
-			// This resource has an issue with retryable provisioning -- which in this
-			// context means creating an remote asset from the local record.
-			//
-			// Apps, for example, which use their user-set Name field as the actual
-			// identifier for the provisioned Kubernetes Namespace. That makes the
-			// creation of the Namespace retryable, because it is IDEMPOTENT.
-			//
-			// The problem here, is that WE CANNOT SET AN IDENTIFIER UP FRONT. The ID
-			// is given to us upon successful creation of the remote asset.
-			//
-			// We currently do not have a great solution in place for this problem.
-			// In the meantime, MaxRetries is set low to prevent creating several
-			// duplicate, billable assets in the user's cloud account. If there is an
-			// error, the user will know about it quickly, instead of after 20 retries.
-			MaxRetries: 0,
+			// NOTE the ID of a server is given upon its creation, so retrying could
+			// create duplicate, billable servers, unless the provider finds the one
+			// an earlier attempt created (see IdempotentNodes). Errors of other
+			// providers are fatal instead.
+			MaxRetries: 5,
 		},
 		core:  c.core,
 		scope: c.core.DB.Preload("Kube.CloudAccount").Preload("Kube.Entrypoints.Kube.CloudAccount"),
 		model: m,
-		id:    m.ID,
+		id:    id,
 		fn: func(a *Action) error {
-			return c.core.CloudAccounts.provider(m.Kube.CloudAccount).CreateNode(m, a)
+			err := c.core.CloudAccounts.provider(m.Kube.CloudAccount).CreateNode(m, a)
+			if err != nil && !idempotentNodes(m.Kube) {
+				return &FatalError{Err: err}
+			}
+			return err
 		},
 	}
-	return provision.Async()
 }
 
 func (c *Nodes) Delete(id *int64, m *model.Node) *Action {
 	return &Action{
 		Status: &model.ActionStatus{
 			Description: "deleting",
+			RetryPolicy: nodeRetryPolicy,
 			MaxRetries:  5,
 		},
 		core:  c.core,
@@ -72,10 +91,94 @@ func (c *Nodes) Delete(id *int64, m *model.Node) *Action {
 	}
 }
 
+// ReplaceInterrupted deletes the spot Nodes of a Kube whose servers the cloud
+// has interrupted, as they are lost, and creates a Node of the same size in the
+// same zone in place of each. Nodes with an Action in flight, such as those
+// being deleted, are left to it. An error replacing one Node is logged, and
+// the rest are still replaced. The Kube's CloudAccount must be loaded.
+func (c *Nodes) ReplaceInterrupted(kube *model.Kube) error {
+	provider, ok := c.core.CloudAccounts.provider(kube.CloudAccount).(SpotProvider)
+	if !ok {
+		return nil
+	}
+
+	var nodes []*model.Node
+	if err := c.core.DB.Preload("Kube.CloudAccount").Where("kube_id = ? AND spot = ? AND provider_id <> ?", kube.ID, true, "").Find(&nodes); err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, node := range nodes {
+		if c.core.Actions.Get(node.UUID) != nil {
+			continue
+		}
+		if err := c.replaceIfInterrupted(provider, node); err != nil {
+			c.core.Log.Errorf("Error replacing spot Node %s: %s", node.Name, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Private methods                                                            //
 ////////////////////////////////////////////////////////////////////////////////
 
+// replaceIfInterrupted replaces a spot Node, as ReplaceInterrupted does, if its
+// server was interrupted.
+func (c *Nodes) replaceIfInterrupted(provider SpotProvider, m *model.Node) error {
+	interrupted, err := provider.SpotInterrupted(m)
+	if err != nil {
+		return err
+	}
+	if !interrupted {
+		return nil
+	}
+
+	c.core.Log.Infof("Replacing spot Node %s, which was interrupted", m.Name)
+
+	if err := c.Delete(m.ID, m).Now(); err != nil {
+		return err
+	}
+	replacement := &model.Node{
+		KubeID:           m.KubeID,
+		Size:             m.Size,
+		AvailabilityZone: m.AvailabilityZone,
+	}
+	return c.Create(replacement)
+}
+
+// setAvailabilityZone checks that a new Node's zone is one of its Kube's, or,
+// if it isn't given one, puts it in the zone with the fewest Nodes.
+func (c *Nodes) setAvailabilityZone(kube *model.Kube, m *model.Node) error {
+	zones := c.core.availabilityZones(kube)
+	if len(zones) == 0 {
+		return nil
+	}
+
+	if m.AvailabilityZone != "" {
+		for _, zone := range zones {
+			if zone == m.AvailabilityZone {
+				return nil
+			}
+		}
+		return validator.ErrorMap{
+			"AvailabilityZone": validator.ErrorArray{fmt.Errorf("must be one of the Kube's zones (%s)", strings.Join(zones, ", "))},
+		}
+	}
+
+	var nodes []*model.Node
+	if err := c.core.DB.Find(&nodes, "kube_id = ?", kube.ID); err != nil {
+		return err
+	}
+	var used []string
+	for _, node := range nodes {
+		used = append(used, node.AvailabilityZone)
+	}
+	m.AvailabilityZone = leastUsedZone(zones, used)
+	return nil
+}
+
 func (c *Nodes) hasPodsWithReservedResources(m *model.Node) (bool, error) {
 	q := &guber.QueryParams{
 		FieldSelector: "spec.nodeName=" + m.Name + ",status.phase=Running",
@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/supergiant/guber"
+	"github.com/jinzhu/gorm"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 )
 
@@ -28,6 +29,7 @@ type Port struct {
 	core *Core
 	*model.Port
 	service *guber.Service
+	kube    *model.Kube
 	// entrypoint is nil if it's an internal port
 	entrypoint *model.Entrypoint
 }
@@ -46,15 +48,19 @@ func (p *Port) internalAddress() *model.PortAddress {
 	}
 }
 
+// externalAddress returns nil if the port has no Entrypoint and none of the
+// Kube's Nodes has a public IP to reach its node port on, as when the Nodes
+// are in private subnets.
 func (p *Port) externalAddress() *model.PortAddress {
 	if p.entrypoint == nil {
-		host := ""
 		node := new(model.Node)
-		if err := p.core.DB.First(node); err != nil {
-			p.core.Log.Errorf("Error when fetching nodes for external address IP: %s", err)
-		} else {
-			host = node.ExternalIP
+		if err := p.core.DB.Where("kube_id = ? AND external_ip <> ?", p.kube.ID, "").First(node); err != nil {
+			if err != gorm.ErrRecordNotFound {
+				p.core.Log.Errorf("Error when fetching nodes for external address IP: %s", err)
+			}
+			return nil
 		}
+		host := node.ExternalIP
 		return &model.PortAddress{
 			Port: p.name(),
 			// Address: fmt.Sprintf("%s://%s:%d", protoWithDefault(p.Protocol), host, p.nodePort()),
@@ -1,6 +1,17 @@
 package core
 
-import "github.com/supergiant/supergiant/pkg/model"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-validator/validator"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+)
 
 type Provider interface {
 	ValidateAccount(*model.CloudAccount) error
@@ -16,8 +27,317 @@ type Provider interface {
 	ResizeVolume(*model.Volume, *Action) error
 	DeleteVolume(*model.Volume) error
 
+	// KubeVolume returns the source of a Volume for the pods of its Instance,
+	// which core names after it.
+	KubeVolume(*model.Volume) (*guber.Volume, error)
+
 	CreateEntrypoint(*model.Entrypoint, *Action) error
 	AddPortToEntrypoint(*model.Entrypoint, int64, int64) error
 	RemovePortFromEntrypoint(*model.Entrypoint, int64) error
 	DeleteEntrypoint(*model.Entrypoint) error
 }
+
+// ZonedProvider is a Provider whose Kubes can span availability zones. Nodes
+// are spread across the zones, and each Instance is kept in one with its
+// Volumes.
+type ZonedProvider interface {
+	Provider
+
+	// AvailabilityZones returns the names of a Kube's zones.
+	AvailabilityZones(*model.Kube) []string
+}
+
+// AttachingProvider is a Provider whose volumes Kubernetes can't attach
+// itself. Core attaches the Volumes of an Instance to a Node before starting
+// its pod there, and detaches them once the pod is gone.
+type AttachingProvider interface {
+	Provider
+
+	// AttachVolume attaches a Volume to a Node, detaching it from any other it
+	// was left attached to.
+	AttachVolume(*model.Volume, *model.Node, *Action) error
+	DetachVolume(*model.Volume, *Action) error
+}
+
+// SnapshotProvider is a Provider which can snapshot Volumes. Its CreateVolume
+// restores Volumes which have a Snapshot from it.
+type SnapshotProvider interface {
+	Provider
+
+	CreateSnapshot(*model.Snapshot, *Action) error
+	DeleteSnapshot(*model.Snapshot) error
+}
+
+// SpotProvider is a Provider which can create Nodes as spot servers, per their
+// Kube's SpotPolicy. Its CreateNode sets Spot of the Nodes it does.
+type SpotProvider interface {
+	Provider
+
+	// SpotInterrupted returns true if the cloud has interrupted the spot server
+	// of a Node, taking it away.
+	SpotInterrupted(*model.Node) (bool, error)
+}
+
+//------------------------------------------------------------------------------
+
+// ProviderDefinition describes a Provider to the registry; what its
+// CloudAccounts' credentials are, what config its Kubes take, and how to create
+// it.
+type ProviderDefinition struct {
+	Name string
+
+	Credentials []*model.ProviderCredential
+
+	// NewKubeConfig returns a new config of the provider's Kubes (ex.
+	// new(model.AWSKubeConfig)), which their ProviderConfig is decoded into.
+	// Providers without it take no config.
+	NewKubeConfig func() interface{}
+
+	// External providers connect to Kubernetes clusters that already exist,
+	// instead of provisioning them. Their Kubes need no node sizes, and their
+	// Nodes are not managed by Supergiant.
+	External bool
+
+	// IdempotentNodes is true of providers whose CreateNode finds the server
+	// an earlier attempt created, instead of creating another, so that it is
+	// safe to retry.
+	IdempotentNodes bool
+
+	// ValidateKubeConfig, when set, checks the provider's config of a new Kube
+	// further than its validate tags can. It may also set readonly fields of
+	// the config which depend on what was given.
+	ValidateKubeConfig func(*model.Kube) error
+
+	// New returns the Provider for the credentials of a CloudAccount.
+	New func(c *Core, credentials map[string]string) Provider
+}
+
+// NOTE providers register themselves from their own packages (which main
+// imports), so that core doesn't have to load the lib code of every cloud.
+var providerDefinitions = make(map[string]*ProviderDefinition)
+
+// RegisterProvider makes a Provider available to CloudAccounts by name. It is
+// meant to be called from the init func of the provider's package.
+func RegisterProvider(def *ProviderDefinition) {
+	if _, ok := providerDefinitions[def.Name]; ok {
+		panic("Provider " + def.Name + " is already registered")
+	}
+	if def.NewKubeConfig != nil {
+		if t := reflect.TypeOf(def.NewKubeConfig()); t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			panic("Provider " + def.Name + " Kube config is not a pointer to a struct")
+		}
+	}
+	providerDefinitions[def.Name] = def
+}
+
+// ProviderDefinitions returns the registered providers, ordered by name.
+func ProviderDefinitions() (defs []*ProviderDefinition) {
+	for _, def := range providerDefinitions {
+		defs = append(defs, def)
+	}
+	sort.Sort(providerDefinitionsByName(defs))
+	return defs
+}
+
+// Describe returns the definition as it is shown through the API.
+func (def *ProviderDefinition) Describe() *model.Provider {
+	provider := &model.Provider{
+		Name:        def.Name,
+		Credentials: def.Credentials,
+		External:    def.External,
+	}
+	if provider.Credentials == nil {
+		provider.Credentials = []*model.ProviderCredential{}
+	}
+	if def.NewKubeConfig != nil {
+		provider.KubeConfig = &model.ProviderKubeConfig{
+			Fields: kubeConfigFields(reflect.TypeOf(def.NewKubeConfig()).Elem()),
+		}
+	}
+	return provider
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func providerDefinition(name string) (*ProviderDefinition, error) {
+	def, ok := providerDefinitions[name]
+	if !ok {
+		return nil, validator.ErrorMap{
+			"Provider": validator.ErrorArray{fmt.Errorf("%q is not a registered provider", name)},
+		}
+	}
+	return def, nil
+}
+
+// validateCredentials checks credentials against the provider's schema; every
+// required key must have a value, and there may be no unknown keys.
+func (def *ProviderDefinition) validateCredentials(credentials map[string]string) error {
+	var errs validator.ErrorArray
+	known := make(map[string]bool)
+	for _, cred := range def.Credentials {
+		known[cred.Name] = true
+		if cred.Required && credentials[cred.Name] == "" {
+			errs = append(errs, fmt.Errorf("%s is required", cred.Name))
+		}
+	}
+	var unknown []string
+	for name := range credentials {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("%s is not a credential of provider %s", name, def.Name))
+	}
+
+	if len(errs) > 0 {
+		return validator.ErrorMap{"Credentials": errs}
+	}
+	return nil
+}
+
+// decodeKubeConfig makes the ProviderConfig of a Kube, as given through the
+// API (decoded as a map), the provider's type of config. Readonly fields of it
+// are dropped, as they are of the Kube.
+func (def *ProviderDefinition) decodeKubeConfig(m *model.Kube) error {
+	given, ok := m.ProviderConfig.(map[string]interface{})
+	if !ok || def.NewKubeConfig == nil {
+		return nil // checked by validateKubeConfig
+	}
+	data, err := json.Marshal(given)
+	if err != nil {
+		return err
+	}
+	config := def.NewKubeConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return validator.ErrorMap{
+			"ProviderConfig": validator.ErrorArray{err},
+		}
+	}
+	m.ProviderConfig = config
+	model.ZeroReadonlyFields(m)
+	return nil
+}
+
+// validateKubeConfig checks that a Kube has the config of the provider, if it
+// has one, and no config otherwise, and that it has node sizes unless the
+// provider is external.
+func (def *ProviderDefinition) validateKubeConfig(m *model.Kube) error {
+	errs := make(validator.ErrorMap)
+
+	if !def.External {
+		if m.MasterNodeSize == "" {
+			errs["MasterNodeSize"] = validator.ErrorArray{validator.ErrZeroValue}
+		}
+		if len(m.NodeSizes) == 0 {
+			errs["NodeSizes"] = validator.ErrorArray{validator.ErrMin}
+		}
+	}
+
+	switch {
+	case def.NewKubeConfig == nil && m.ProviderConfig != nil:
+		errs["ProviderConfig"] = validator.ErrorArray{
+			fmt.Errorf("must be empty for Kubes of provider %s", def.Name),
+		}
+	case def.NewKubeConfig != nil && m.ProviderConfig == nil:
+		errs["ProviderConfig"] = validator.ErrorArray{
+			errors.New("is required for Kubes of provider " + def.Name),
+		}
+	case def.NewKubeConfig != nil && reflect.TypeOf(m.ProviderConfig) != reflect.TypeOf(def.NewKubeConfig()):
+		errs["ProviderConfig"] = validator.ErrorArray{
+			errors.New("is not the config of provider " + def.Name),
+		}
+	}
+
+	if len(errs) == 0 && def.ValidateKubeConfig != nil {
+		return def.ValidateKubeConfig(m)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// availabilityZones returns the zones of a Kube, if its provider has them. The
+// Kube's CloudAccount must be loaded.
+func (c *Core) availabilityZones(kube *model.Kube) []string {
+	if zoned, ok := c.CloudAccounts.provider(kube.CloudAccount).(ZonedProvider); ok {
+		return zoned.AvailabilityZones(kube)
+	}
+	return nil
+}
+
+// idempotentNodes returns true if the provider of the Kube can retry creating
+// Nodes. The Kube's CloudAccount must be loaded.
+func idempotentNodes(kube *model.Kube) bool {
+	if kube.CloudAccount == nil {
+		return false
+	}
+	def, err := providerDefinition(kube.CloudAccount.Provider)
+	return err == nil && def.IdempotentNodes
+}
+
+// leastUsedZone returns the first of zones which is used the fewest times in
+// used.
+func leastUsedZone(zones []string, used []string) string {
+	counts := make(map[string]int)
+	for _, zone := range used {
+		counts[zone]++
+	}
+	least := zones[0]
+	for _, zone := range zones[1:] {
+		if counts[zone] < counts[least] {
+			least = zone
+		}
+	}
+	return least
+}
+
+func kubeConfigFields(t reflect.Type) (fields []*model.ProviderKubeConfigField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" || name == "-" {
+			continue
+		}
+		out := &model.ProviderKubeConfigField{
+			Name: name,
+			Type: field.Type.Kind().String(),
+		}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "nonzero" {
+				out.Required = true
+			}
+		}
+		for _, part := range strings.Split(field.Tag.Get("sg"), ",") {
+			switch {
+			case part == "readonly":
+				out.Readonly = true
+			case part == "createonly":
+				out.Createonly = true
+			case strings.HasPrefix(part, "default="):
+				out.Default = strings.TrimPrefix(part, "default=")
+			}
+		}
+		// Fields with defaults can be left out.
+		if out.Default != "" || out.Readonly {
+			out.Required = false
+		}
+		fields = append(fields, out)
+	}
+	return fields
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+type providerDefinitionsByName []*ProviderDefinition
+
+func (defs providerDefinitionsByName) Len() int           { return len(defs) }
+func (defs providerDefinitionsByName) Swap(i, j int)      { defs[i], defs[j] = defs[j], defs[i] }
+func (defs providerDefinitionsByName) Less(i, j int) bool { return defs[i].Name < defs[j].Name }
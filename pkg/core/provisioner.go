@@ -0,0 +1,86 @@
+package core
+
+import "github.com/supergiant/supergiant/pkg/model"
+
+// Provisioner runs the steps providers take to create or delete a Kube, in
+// order. Each step that succeeds is saved to the Kube's CompletedSteps, so that
+// retrying or resuming the provisioning skips it.
+type Provisioner struct {
+	Core *Core
+	Kube *model.Kube
+
+	// Action, when set, reports the progress of each step in its status.
+	Action *Action
+
+	steps []*provisionerStep
+}
+
+type provisionerStep struct {
+	desc string
+	fn   func() error
+}
+
+// AddStep adds a step to run after those already added. Steps are told apart
+// by their descriptions, which must be unique.
+func (p *Provisioner) AddStep(desc string, fn func() error) {
+	for _, step := range p.steps {
+		if step.desc == desc {
+			panic("Duplicate Kube provisioner step: " + desc)
+		}
+	}
+	p.steps = append(p.steps, &provisionerStep{desc, fn})
+}
+
+func (p *Provisioner) Run() error {
+	if p.Action != nil {
+		var names []string
+		for _, step := range p.steps {
+			names = append(names, step.desc)
+		}
+		p.Action.SetSteps(names...)
+	}
+
+	for _, step := range p.steps {
+		if p.completed(step) {
+			p.Core.Log.Infof("Skipping completed step of Kube provisioner: %s", step.desc)
+			if p.Action != nil {
+				p.Action.SkipStep(step.desc)
+			}
+			continue
+		}
+
+		p.Core.Log.Infof("Running step of Kube provisioner: %s", step.desc)
+		if err := p.runStep(step); err != nil {
+			return err
+		}
+		p.Kube.CompletedSteps = append(p.Kube.CompletedSteps, step.desc)
+		if err := p.Core.DB.Save(p.Kube); err != nil {
+			return err
+		}
+	}
+
+	// Every step is done, so there's nothing left to resume.
+	p.Kube.CompletedSteps = []string{}
+	return p.Core.DB.Save(p.Kube)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func (p *Provisioner) runStep(step *provisionerStep) error {
+	if p.Action == nil {
+		return step.fn()
+	}
+	return p.Action.RunStep(step.desc, step.fn)
+}
+
+// completed is true when the step succeeded on a previous run.
+func (p *Provisioner) completed(step *provisionerStep) bool {
+	for _, desc := range p.Kube.CompletedSteps {
+		if desc == step.desc {
+			return true
+		}
+	}
+	return false
+}
@@ -1,39 +1,122 @@
 package core
 
 import (
-	"reflect"
+	"context"
+	"fmt"
 	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
 )
 
+// recurringServicePoll is how often a RecurringService checks whether it is
+// due, paused, or has been asked to run now.
+const recurringServicePoll = 5 * time.Second
+
 type Service interface {
 	Perform() error
 }
 
 type RecurringService struct {
 	core     *Core
+	name     string
 	service  Service
 	interval time.Duration
+
+	// everyServer services run on every server, instead of only the leader.
+	// The servers share the record of the service, so each one times its own
+	// runs, by lastRunAt.
+	everyServer bool
+	lastRunAt   time.Time
+
+	record *model.Service
+}
+
+// NewRecurringService returns a RecurringService running service at the
+// interval configured for name.
+func (c *Core) NewRecurringService(name string, service Service) *RecurringService {
+	return &RecurringService{
+		core:     c,
+		name:     name,
+		service:  service,
+		interval: c.Services.interval(name),
+	}
+}
+
+// Run performs the service every interval until ctx is done.
+func (s *RecurringService) Run(ctx context.Context) {
+	if err := s.core.Services.register(s); err != nil {
+		s.core.Log.Error("Error registering RecurringService "+s.name+": ", err)
+		return
+	}
+
+	poll := recurringServicePoll
+	if s.interval < poll {
+		poll = s.interval
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.due() {
+				s.tick()
+			}
+		}
+	}
 }
 
-func (s *RecurringService) Run() {
-	for _ = range time.NewTicker(s.interval).C {
-		s.tick()
+// due reloads the record of the service (which may have been paused, resumed,
+// or asked to run through the API) and returns true if it should run now.
+func (s *RecurringService) due() bool {
+	if !s.everyServer && !s.core.Leader.IsLeader() {
+		return false
+	}
+	if err := s.core.DB.First(s.record, *s.record.ID); err != nil {
+		s.core.Log.Error("Error loading RecurringService "+s.name+": ", err)
+		return false
+	}
+	if s.record.RunRequested {
+		return true
 	}
+	if s.record.Paused {
+		return false
+	}
+	if s.everyServer {
+		return time.Since(s.lastRunAt) >= s.interval
+	}
+	return s.record.LastRunAt == nil || time.Since(*s.record.LastRunAt) >= s.interval
 }
 
 func (s *RecurringService) tick() {
-	defer s.recover()
-	if err := s.service.Perform(); err != nil {
-		s.core.Log.Error("Error in RecurringService "+s.name()+": ", err)
+	startedAt := time.Now()
+	s.lastRunAt = startedAt
+	err := s.perform()
+
+	columns := map[string]interface{}{
+		"run_requested": false,
+		"last_run_at":   startedAt,
+		"last_duration": time.Since(startedAt).Seconds(),
+		"last_error":    "",
+	}
+	if err != nil {
+		s.core.Log.Error("Error in RecurringService "+s.name+": ", err)
+		columns["last_error"] = err.Error()
+	}
+	if err := s.core.DB.DB.Model(s.record).Updates(columns).Error; err != nil {
+		s.core.Log.Error("Error saving RecurringService "+s.name+": ", err)
 	}
 }
 
-func (s *RecurringService) name() string {
-	return reflect.TypeOf(s.service).Elem().String()
+func (s *RecurringService) perform() (err error) {
+	defer s.recover(&err)
+	return s.service.Perform()
 }
 
-func (s *RecurringService) recover() {
+func (s *RecurringService) recover(err *error) {
 	if r := recover(); r != nil {
-		s.core.Log.Error("Recovered in RecurringService "+s.name()+": ", r)
+		s.core.Log.Error("Recovered in RecurringService "+s.name+": ", r)
+		*err = fmt.Errorf("Recovered from panic: %v", r)
 	}
 }
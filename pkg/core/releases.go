@@ -2,7 +2,12 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
 
+	"github.com/go-validator/validator"
 	"github.com/imdario/mergo"
 	"github.com/supergiant/supergiant/pkg/model"
 )
@@ -14,7 +19,7 @@ type Releases struct {
 func (c *Releases) Create(m *model.Release) error {
 	// load Component (you can't have it preloaded here)
 	m.Component = new(model.Component)
-	if err := c.core.DB.Preload("CurrentRelease").First(m.Component, *m.ComponentID); err != nil {
+	if err := c.core.DB.Preload("App.Kube.CloudAccount").Preload("CurrentRelease").First(m.Component, *m.ComponentID); err != nil {
 		return err
 	}
 
@@ -38,6 +43,12 @@ func (c *Releases) Create(m *model.Release) error {
 		return errors.New("Release InstanceGroup field can only be set to either the current or target Release's Timestamp value.")
 	}
 
+	if m.Config != nil {
+		if err := c.validateVolumes(m.Component, m.Config.Volumes); err != nil {
+			return err
+		}
+	}
+
 	if err := c.Collection.Create(m); err != nil {
 		return err
 	}
@@ -46,4 +57,247 @@ func (c *Releases) Create(m *model.Release) error {
 	return c.core.DB.Save(m.Component)
 }
 
+// Update changes a Release. The Volumes it is given are checked against the
+// current Release of its Component and the Kube's provider, as when it is
+// created.
+func (c *Releases) Update(id *int64, oldM *model.Release, m *model.Release) error {
+	if m.Config != nil {
+		release := new(model.Release)
+		if err := c.core.DB.First(release, *id); err != nil {
+			return err
+		}
+		component := new(model.Component)
+		if err := c.core.DB.Preload("App.Kube.CloudAccount").Preload("CurrentRelease").First(component, *release.ComponentID); err != nil {
+			return err
+		}
+		if err := c.validateVolumes(component, m.Config.Volumes); err != nil {
+			return err
+		}
+	}
+	return c.Collection.Update(id, oldM, m)
+}
+
 // TODO prevent updating / deleting active release in controller
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+// validateVolumes checks the Volumes of a Release of the Component, which must
+// have its CurrentRelease and the CloudAccount of its App's Kube loaded.
+func (c *Releases) validateVolumes(component *model.Component, volumes []*model.VolumeBlueprint) error {
+	var currentVolumes []*model.VolumeBlueprint
+	if current := component.CurrentRelease; current != nil && current.Config != nil {
+		currentVolumes = current.Config.Volumes
+	}
+	if err := validateVolumeBlueprints(volumes, currentVolumes, c.core.HostPathPrefixes); err != nil {
+		return err
+	}
+	return c.validateVolumeProvider(component.App.Kube, volumes)
+}
+
+// volumeTypeLimits are the sizes (GiB), IOPS and throughput (MiB/s) each type
+// of Volume may have. Types without IOPS or throughput take neither.
+type volumeTypeLimits struct {
+	minSize, maxSize             int
+	minIOPS, maxIOPS, iopsPerGiB int
+	requiresIOPS                 bool
+	minThroughput, maxThroughput int
+}
+
+var efsFileSystemIDRegexp = regexp.MustCompile("^fs-[0-9a-f]+$")
+
+var volumeTypes = map[string]volumeTypeLimits{
+	"gp2":         {minSize: 1, maxSize: 16384},
+	"gp3":         {minSize: 1, maxSize: 16384, minIOPS: 3000, maxIOPS: 16000, iopsPerGiB: 500, minThroughput: 125, maxThroughput: 1000},
+	"io1":         {minSize: 4, maxSize: 16384, minIOPS: 100, maxIOPS: 64000, iopsPerGiB: 50, requiresIOPS: true},
+	"io2":         {minSize: 4, maxSize: 16384, minIOPS: 100, maxIOPS: 64000, iopsPerGiB: 500, requiresIOPS: true},
+	"st1":         {minSize: 125, maxSize: 16384},
+	"sc1":         {minSize: 125, maxSize: 16384},
+	"standard":    {minSize: 1, maxSize: 1024},
+	"pd-standard": {minSize: 1, maxSize: 65536},
+	"pd-ssd":      {minSize: 1, maxSize: 65536},
+}
+
+// validateVolumeBlueprints checks the settings of each Volume against its
+// kind, and the size, IOPS and throughput of ebs Volumes against the limits of
+// their type. No Volume may change kind from the current Release, or be smaller
+// than in it, since volumes can't shrink.
+func validateVolumeBlueprints(volumes []*model.VolumeBlueprint, currentVolumes []*model.VolumeBlueprint, hostPathPrefixes []string) error {
+	errs := make(validator.ErrorMap)
+	add := func(i int, field string, format string, args ...interface{}) {
+		key := fmt.Sprintf("Config.Volumes[%d].%s", i, field)
+		errs[key] = append(errs[key], fmt.Errorf(format, args...))
+	}
+
+	for i, volume := range volumes {
+		kind := volume.KindOrDefault()
+
+		for _, current := range currentVolumes {
+			if current.Name != volume.Name {
+				continue
+			}
+			if current.KindOrDefault() != kind {
+				add(i, "Kind", "can't be changed from the %s of the current Release", current.KindOrDefault())
+			} else if volume.Size < current.Size {
+				add(i, "Size", "can't be less than the %d GiB of the current Release, as Volumes can't shrink", current.Size)
+			}
+		}
+
+		if kind != model.VolumeKindEBS {
+			validateSharedVolumeBlueprint(volume, hostPathPrefixes, func(field string, format string, args ...interface{}) {
+				add(i, field, format, args...)
+			})
+			continue
+		}
+		if volume.Size == 0 {
+			add(i, "Size", "is required for ebs Volumes")
+		}
+		if volume.Server != "" || volume.Path != "" || volume.ReadOnly {
+			add(i, "Kind", "must be nfs or efs for Volumes with a Server, Path or ReadOnly")
+		}
+
+		limits, ok := volumeTypes[volume.Type]
+		if !ok {
+			continue // the Type itself is validated with the model
+		}
+
+		if volume.Size < limits.minSize || volume.Size > limits.maxSize {
+			add(i, "Size", "must be from %d to %d GiB for %s Volumes", limits.minSize, limits.maxSize, volume.Type)
+		}
+
+		switch {
+		case limits.maxIOPS == 0 && volume.IOPS != 0:
+			add(i, "IOPS", "can't be given for %s Volumes", volume.Type)
+		case limits.requiresIOPS && volume.IOPS == 0:
+			add(i, "IOPS", "is required for %s Volumes", volume.Type)
+		case volume.IOPS != 0 && (volume.IOPS < limits.minIOPS || volume.IOPS > limits.maxIOPS):
+			add(i, "IOPS", "must be from %d to %d for %s Volumes", limits.minIOPS, limits.maxIOPS, volume.Type)
+		case volume.IOPS > limits.iopsPerGiB*volume.Size && volume.IOPS > limits.minIOPS:
+			add(i, "IOPS", "can be at most %d per GiB of Size for %s Volumes", limits.iopsPerGiB, volume.Type)
+		}
+
+		switch {
+		case limits.maxThroughput == 0 && volume.Throughput != 0:
+			add(i, "Throughput", "can't be given for %s Volumes", volume.Type)
+		case volume.Throughput != 0 && (volume.Throughput < limits.minThroughput || volume.Throughput > limits.maxThroughput):
+			add(i, "Throughput", "must be from %d to %d MiB/s for %s Volumes", limits.minThroughput, limits.maxThroughput, volume.Type)
+		case volume.Throughput*4 > volume.IOPS && volume.Throughput*4 > limits.minIOPS:
+			add(i, "Throughput", "can be at most a quarter of IOPS, in MiB/s, for %s Volumes", volume.Type)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateVolumeProvider checks the Volumes against the Kube's provider: ebs
+// Volumes can't be on external Kubes, efs Volumes must be on AWS, and the
+// provider must support Snapshots for Volumes that have a SnapshotPolicy, are
+// snapshotted when reclaimed, or are restored from a Snapshot. Each Snapshot
+// restored from must be ready, of the Kube, and no larger than its Volume.
+func (c *Releases) validateVolumeProvider(kube *model.Kube, volumes []*model.VolumeBlueprint) error {
+	_, snapshotsSupported := c.core.CloudAccounts.provider(kube.CloudAccount).(SnapshotProvider)
+	_, onAWS := kube.ProviderConfig.(*model.AWSKubeConfig)
+	external := isExternal(kube)
+
+	errs := make(validator.ErrorMap)
+	add := func(i int, field string, err error) {
+		key := fmt.Sprintf("Config.Volumes[%d].%s", i, field)
+		errs[key] = append(errs[key], err)
+	}
+
+	for i, volume := range volumes {
+		if volume.KindOrDefault() == model.VolumeKindEBS && external {
+			add(i, "Kind", errors.New("can't be ebs on external Kubes, whose disks Supergiant doesn't manage"))
+		}
+		if volume.Kind == model.VolumeKindEFS && !onAWS {
+			add(i, "Kind", errors.New("can only be efs on AWS"))
+		}
+		if volume.SnapshotPolicy != nil && !snapshotsSupported {
+			add(i, "SnapshotPolicy", ErrorSnapshotsUnsupported)
+		}
+		if volume.ReclaimPolicy == model.ReclaimPolicySnapshot && !snapshotsSupported {
+			add(i, "ReclaimPolicy", ErrorSnapshotsUnsupported)
+		}
+
+		if volume.SnapshotID == nil {
+			continue
+		}
+		if !snapshotsSupported {
+			add(i, "SnapshotID", ErrorSnapshotsUnsupported)
+			continue
+		}
+		snapshot := new(model.Snapshot)
+		if err := c.core.DB.First(snapshot, *volume.SnapshotID); err != nil {
+			add(i, "SnapshotID", fmt.Errorf("%d is not a Snapshot", *volume.SnapshotID))
+			continue
+		}
+		switch {
+		case *snapshot.KubeID != *kube.ID:
+			add(i, "SnapshotID", errors.New("is of a Snapshot of another Kube"))
+		case !snapshot.Ready:
+			add(i, "SnapshotID", errors.New("is of a Snapshot that isn't ready"))
+		case volume.Size < snapshot.Size:
+			add(i, "Size", fmt.Errorf("can't be less than the %d GiB of the Snapshot", snapshot.Size))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateSharedVolumeBlueprint checks a Volume which isn't a disk of the
+// provider; that it has the Server and Path of its kind, and none of the
+// settings of ebs Volumes. The Path of a host_path Volume must be in one of
+// hostPathPrefixes.
+func validateSharedVolumeBlueprint(volume *model.VolumeBlueprint, hostPathPrefixes []string, add func(field string, format string, args ...interface{})) {
+	hasPath := volume.Kind == model.VolumeKindHostPath || volume.Kind == model.VolumeKindNFS || volume.Kind == model.VolumeKindEFS
+	hasServer := volume.Kind == model.VolumeKindNFS || volume.Kind == model.VolumeKindEFS
+
+	switch {
+	case hasPath && !strings.HasPrefix(volume.Path, "/"):
+		add("Path", "is required, as an absolute path, for %s Volumes", volume.Kind)
+	case !hasPath && volume.Path != "":
+		add("Path", "can't be given for %s Volumes", volume.Kind)
+	case volume.Kind == model.VolumeKindHostPath && len(hostPathPrefixes) == 0:
+		add("Path", "can't be given, as host_path Volumes aren't allowed without host_path_prefixes")
+	case volume.Kind == model.VolumeKindHostPath && !inAnyDir(volume.Path, hostPathPrefixes):
+		add("Path", "must be in one of the host_path_prefixes (%s) for host_path Volumes", strings.Join(hostPathPrefixes, ", "))
+	}
+
+	switch {
+	case hasServer && volume.Server == "":
+		add("Server", "is required for %s Volumes", volume.Kind)
+	case !hasServer && (volume.Server != "" || volume.ReadOnly):
+		add("Server", "and ReadOnly can't be given for %s Volumes", volume.Kind)
+	case volume.Kind == model.VolumeKindEFS && !efsFileSystemIDRegexp.MatchString(volume.Server):
+		add("Server", "must be the ID of an EFS file system (fs-...) for efs Volumes")
+	}
+
+	if volume.Size != 0 || volume.IOPS != 0 || volume.Throughput != 0 {
+		add("Size", "IOPS and Throughput can't be given for %s Volumes", volume.Kind)
+	}
+	if volume.SnapshotID != nil || volume.SnapshotPolicy != nil {
+		add("SnapshotID", "and SnapshotPolicy can't be given for %s Volumes", volume.Kind)
+	}
+	if volume.ReclaimPolicy != "" && volume.ReclaimPolicy != model.ReclaimPolicyDelete {
+		add("ReclaimPolicy", "can't be %s for %s Volumes", volume.ReclaimPolicy, volume.Kind)
+	}
+}
+
+// inAnyDir returns true of a path that is one of dirs, or is inside one.
+func inAnyDir(file string, dirs []string) bool {
+	file = path.Clean(file)
+	for _, dir := range dirs {
+		dir = path.Clean(dir)
+		if file == dir || strings.HasPrefix(file, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
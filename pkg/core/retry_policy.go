@@ -0,0 +1,96 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-validator/validator"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// FatalError wraps an error which retrying an Action will not fix. Returning
+// one from an Action's fn stops any further retries.
+type FatalError struct {
+	Err error
+}
+
+func (err *FatalError) Error() string {
+	return err.Err.Error()
+}
+
+// fatalAWSErrors are the AWS error codes that mean a request is bad, or that
+// the credentials are, rather than the cloud being temporarily unavailable.
+var fatalAWSErrors = []string{
+	"AuthFailure",
+	"Blocked",
+	"InvalidClientTokenId",
+	"InvalidParameterCombination",
+	"InvalidParameterValue",
+	"MissingParameter",
+	"OptInRequired",
+	"UnauthorizedOperation",
+}
+
+// DefaultRetryPolicy is used by Actions without a RetryPolicy of their own.
+var DefaultRetryPolicy = &model.RetryPolicy{
+	InitialDelay: 5,
+	Multiplier:   2,
+	MaxDelay:     300,
+	Jitter:       0.2,
+	FatalErrors:  fatalAWSErrors,
+}
+
+//------------------------------------------------------------------------------
+
+func retryPolicy(status *model.ActionStatus) *model.RetryPolicy {
+	if status.RetryPolicy != nil {
+		return status.RetryPolicy
+	}
+	return DefaultRetryPolicy
+}
+
+// retryDelay returns how long to wait after the given number of failed
+// attempts: InitialDelay * Multiplier^(retries-1), capped at MaxDelay, with up
+// to Jitter of it randomized.
+func retryDelay(p *model.RetryPolicy, retries int) time.Duration {
+	if retries < 1 {
+		retries = 1
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(retries-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay * float64(time.Second))
+}
+
+// isFatalError is true when retrying won't fix the error; when the error is a
+// FatalError, a validation failure, a 4xx from the Supergiant API (other than
+// 404, which is usually a race), or matches one of the policy's FatalErrors.
+func isFatalError(p *model.RetryPolicy, err error) bool {
+	switch e := err.(type) {
+	case *FatalError:
+		return true
+	case validator.ErrorMap:
+		return true
+	case *model.Error:
+		return e.Status >= 400 && e.Status < 500 && e.Status != 404
+	}
+
+	msg := err.Error()
+	for _, fragment := range p.FatalErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
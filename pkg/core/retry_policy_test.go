@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-validator/validator"
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryDelay(t *testing.T) {
+	policy := &model.RetryPolicy{InitialDelay: 5, Multiplier: 2, MaxDelay: 60}
+
+	tests := []struct {
+		policy  *model.RetryPolicy
+		retries int
+		want    time.Duration
+	}{
+		{policy, 0, 5 * time.Second}, // counted as the first retry
+		{policy, 1, 5 * time.Second},
+		{policy, 2, 10 * time.Second},
+		{policy, 4, 40 * time.Second},
+		{policy, 5, 60 * time.Second}, // 80s, capped at MaxDelay
+		{policy, 30, 60 * time.Second},
+		{&model.RetryPolicy{InitialDelay: 5, Multiplier: 0.5}, 3, 5 * time.Second}, // multiplier below 1 is 1
+		{&model.RetryPolicy{InitialDelay: 5, Multiplier: 2}, 8, 640 * time.Second}, // no MaxDelay
+	}
+	Convey("Given a RetryPolicy", t, func() {
+		for _, test := range tests {
+			Convey(fmt.Sprintf("The delay of retry %d with %+v should be %s", test.retries, *test.policy, test.want), func() {
+				So(retryDelay(test.policy, test.retries), ShouldEqual, test.want)
+			})
+		}
+	})
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	tests := []struct {
+		jitter   float64
+		min, max time.Duration
+	}{
+		{0, 40 * time.Second, 40 * time.Second},
+		{0.25, 30 * time.Second, 40 * time.Second},
+		{1, 0, 40 * time.Second},
+	}
+	Convey("Given a RetryPolicy with Jitter", t, func() {
+		for _, test := range tests {
+			Convey(fmt.Sprintf("Delays with jitter %g should be between %s and %s", test.jitter, test.min, test.max), func() {
+				policy := &model.RetryPolicy{InitialDelay: 10, Multiplier: 2, MaxDelay: 300, Jitter: test.jitter}
+				for i := 0; i < 1000; i++ {
+					delay := retryDelay(policy, 3)
+					So(delay, ShouldBeGreaterThanOrEqualTo, test.min)
+					So(delay, ShouldBeLessThanOrEqualTo, test.max)
+				}
+			})
+		}
+	})
+}
+
+func TestIsFatalError(t *testing.T) {
+	policy := &model.RetryPolicy{FatalErrors: []string{"UnauthorizedOperation", "VolumeLimitExceeded"}}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("UnauthorizedOperation: You are not authorized to perform this operation."), true},
+		{errors.New("status code: 400, VolumeLimitExceeded: maximum volume count reached"), true},
+		{errors.New("unauthorizedoperation"), false}, // fragments are case-sensitive
+		{errors.New("RequestLimitExceeded: Request limit exceeded."), false},
+		{&FatalError{errors.New("Simulated failure")}, true},
+		{validator.ErrorMap{"Size": validator.ErrorArray{validator.ErrMin}}, true},
+		{&model.Error{Status: 422, Message: "Validation failed"}, true},
+		{&model.Error{Status: 404, Message: "Not found"}, false},
+		{&model.Error{Status: 500, Message: "Internal error"}, false},
+	}
+	Convey("Given a RetryPolicy with FatalErrors", t, func() {
+		for _, test := range tests {
+			Convey(fmt.Sprintf("%q should be fatal: %t", test.err, test.want), func() {
+				So(isFatalError(policy, test.err), ShouldEqual, test.want)
+			})
+		}
+	})
+}
@@ -1,7 +1,7 @@
 package core
 
 import (
-	"github.com/supergiant/guber"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 )
 
@@ -119,7 +119,7 @@ func (s *ServiceSet) internalPorts() (ports []*Port, err error) {
 		return // Service does not exist (which... can be an error depending on the context called)
 	}
 	for _, port := range s.internalPortDefs() {
-		ports = append(ports, &Port{s.core, port, svc, nil})
+		ports = append(ports, &Port{s.core, port, svc, s.component.App.Kube, nil})
 	}
 	return
 }
@@ -141,7 +141,7 @@ func (s *ServiceSet) externalPorts() (ports []*Port, err error) {
 			}
 			entrypoint = ep
 		}
-		ports = append(ports, &Port{s.core, port, svc, entrypoint})
+		ports = append(ports, &Port{s.core, port, svc, s.component.App.Kube, entrypoint})
 	}
 	return
 }
@@ -353,7 +353,9 @@ func (s *ServiceSet) externalAddresses() (addrs []*model.PortAddress, err error)
 		return nil, err
 	}
 	for _, port := range ports {
-		addrs = append(addrs, port.externalAddress())
+		if addr := port.externalAddress(); addr != nil {
+			addrs = append(addrs, addr)
+		}
 	}
 	return addrs, nil
 }
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// defaultServiceIntervals are the seconds between runs of each
+// RecurringService, unless set in Settings.ServiceIntervals.
+var defaultServiceIntervals = map[string]int{
+	"capacity_service":   30,
+	"node_observer":      30,
+	"instance_observer":  30,
+	"action_queue":       5,
+	"snapshot_scheduler": 60,
+	"session_expirer":    15,
+}
+
+type Services struct {
+	Collection
+}
+
+func (c *Services) Pause(id *int64, m *model.Service) error {
+	return c.updateColumns(id, m, map[string]interface{}{"paused": true})
+}
+
+func (c *Services) Resume(id *int64, m *model.Service) error {
+	return c.updateColumns(id, m, map[string]interface{}{"paused": false})
+}
+
+// RunNow has the service run (on the leader) within a few seconds, whether or
+// not it is paused.
+func (c *Services) RunNow(id *int64, m *model.Service) error {
+	return c.updateColumns(id, m, map[string]interface{}{"run_requested": true})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Services) interval(name string) time.Duration {
+	seconds := defaultServiceIntervals[name]
+	if configured, ok := c.core.ServiceIntervals[name]; ok && configured > 0 {
+		seconds = configured
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// register loads (or creates) the record of a RecurringService, updating its
+// interval to the current Settings.
+func (c *Services) register(s *RecurringService) error {
+	s.record = new(model.Service)
+	err := c.core.DB.Where("name = ?", s.name).First(s.record)
+	if err == nil {
+		return c.core.DB.DB.Model(s.record).Update("interval", int(s.interval/time.Second)).Error
+	}
+	s.record = &model.Service{
+		Name:     s.name,
+		Interval: int(s.interval / time.Second),
+	}
+	return c.core.DB.Create(s.record)
+}
+
+// NOTE the columns are updated on their own (instead of saving the record) so
+// that the leader recording a run doesn't overwrite a pause made through
+// another server at the same time.
+func (c *Services) updateColumns(id *int64, m *model.Service, columns map[string]interface{}) error {
+	if err := c.Get(id, m); err != nil {
+		return err
+	}
+	if err := c.core.DB.DB.Model(m).Updates(columns).Error; err != nil {
+		return err
+	}
+	return c.Get(id, m)
+}
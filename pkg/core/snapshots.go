@@ -0,0 +1,259 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+var ErrorSnapshotsUnsupported = errors.New("Snapshots are not supported by the provider of the Kube")
+
+var ErrorSnapshotRestoredFrom = errors.New("Snapshots that the current or target Release of a Component restores Volumes from can't be deleted")
+
+type Snapshots struct {
+	Collection
+}
+
+// Create snapshots the Volume of the Snapshot.
+func (c *Snapshots) Create(m *model.Snapshot) error {
+	if err := c.create(m); err != nil {
+		return err
+	}
+	return c.Provision(m.ID, m).Async()
+}
+
+func (c *Snapshots) Provision(id *int64, m *model.Snapshot) *Action {
+	return &Action{
+		Status: &model.ActionStatus{
+			Description: "provisioning",
+			RetryPolicy: volumeRetryPolicy,
+			MaxRetries:  5,
+		},
+		core:  c.core,
+		scope: c.core.DB.Preload("Volume").Preload("Kube.CloudAccount"),
+		model: m,
+		id:    id,
+		fn: func(a *Action) error {
+			provider, err := c.provider(m)
+			if err != nil {
+				return err
+			}
+			return provider.CreateSnapshot(m, a)
+		},
+	}
+}
+
+func (c *Snapshots) Delete(id *int64, m *model.Snapshot) *Action {
+	return &Action{
+		Status: &model.ActionStatus{
+			Description: "deleting",
+			RetryPolicy: volumeRetryPolicy,
+			MaxRetries:  5,
+		},
+		core:  c.core,
+		scope: c.core.DB.Preload("Kube.CloudAccount"),
+		model: m,
+		id:    id,
+		fn: func(_ *Action) error {
+			provider, err := c.provider(m)
+			if err != nil {
+				return err
+			}
+			restoredFrom, err := c.restoredFrom(m)
+			if err != nil {
+				return err
+			}
+			if restoredFrom {
+				return &FatalError{Err: ErrorSnapshotRestoredFrom}
+			}
+			if err := provider.DeleteSnapshot(m); err != nil {
+				return err
+			}
+			return c.Collection.Delete(id, m)
+		},
+	}
+}
+
+// DeleteUnused deletes a Snapshot which no current or target Release of a
+// Component restores Volumes from.
+func (c *Snapshots) DeleteUnused(id *int64, m *model.Snapshot) error {
+	if err := c.Get(id, m); err != nil {
+		return err
+	}
+	restoredFrom, err := c.restoredFrom(m)
+	if err != nil {
+		return err
+	}
+	if restoredFrom {
+		return ErrorSnapshotRestoredFrom
+	}
+	return c.Delete(id, m).Async()
+}
+
+// Schedule snapshots each Volume whose Instance's Release gives it a
+// SnapshotPolicy, once per the policy's interval, and deletes its scheduled
+// Snapshots past the policy's retention.
+func (c *Snapshots) Schedule() error {
+	var volumes []*model.Volume
+	if err := c.core.DB.Preload("Instance.Release").Where("provider_id <> ?", "").Find(&volumes); err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, volume := range volumes {
+		policy := snapshotPolicy(volume)
+		if policy == nil {
+			continue
+		}
+		if err := c.schedule(volume, policy); err != nil {
+			c.core.Log.Errorf("Error scheduling Snapshots of Volume %d: %s", *volume.ID, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+// create records a Snapshot of its Volume, without taking it.
+func (c *Snapshots) create(m *model.Snapshot) error {
+	volume := new(model.Volume)
+	if err := c.core.DB.Preload("Kube.CloudAccount").First(volume, *m.VolumeID); err != nil {
+		return err
+	}
+	if _, ok := c.core.CloudAccounts.provider(volume.Kube.CloudAccount).(SnapshotProvider); !ok {
+		return ErrorSnapshotsUnsupported
+	}
+	m.KubeID = volume.KubeID
+	m.VolumeName = volume.Name
+	m.Size = volume.Size
+	return c.Collection.Create(m)
+}
+
+// restoredFrom is true of a Snapshot which the current or target Release of a
+// Component restores Volumes from, as the new Instances of the Component need
+// it.
+func (c *Snapshots) restoredFrom(m *model.Snapshot) (bool, error) {
+	var releases []*model.Release
+	if err := c.core.DB.Where("id IN (SELECT current_release_id FROM components) OR id IN (SELECT target_release_id FROM components)").Find(&releases); err != nil {
+		return false, err
+	}
+	for _, release := range releases {
+		if release.Config == nil {
+			continue
+		}
+		for _, volume := range release.Config.Volumes {
+			if volume.SnapshotID != nil && *volume.SnapshotID == *m.ID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (c *Snapshots) provider(m *model.Snapshot) (SnapshotProvider, error) {
+	provider, ok := c.core.CloudAccounts.provider(m.Kube.CloudAccount).(SnapshotProvider)
+	if !ok {
+		return nil, &FatalError{Err: ErrorSnapshotsUnsupported}
+	}
+	return provider, nil
+}
+
+func (c *Snapshots) schedule(volume *model.Volume, policy *model.SnapshotPolicy) error {
+	var snapshots []*model.Snapshot
+	if err := c.core.DB.Where("volume_id = ? AND scheduled = ?", volume.ID, true).Order("id desc").Find(&snapshots); err != nil {
+		return err
+	}
+
+	// NOTE only ready Snapshots count toward retention, so that the oldest is
+	// not deleted before the one replacing it is complete, and the interval is
+	// from the latest of them. One still being provisioned holds off the next;
+	// those whose provisioning failed (or was cancelled) are deleted.
+	var ready, failed []*model.Snapshot
+	var provisioning bool
+	for _, snapshot := range snapshots {
+		if snapshot.Ready {
+			ready = append(ready, snapshot)
+			continue
+		}
+		inFlight, err := c.provisioning(snapshot)
+		if err != nil {
+			return err
+		}
+		if inFlight {
+			provisioning = true
+		} else {
+			failed = append(failed, snapshot)
+		}
+	}
+
+	if !provisioning && (len(ready) == 0 || time.Since(ready[0].CreatedAt) >= time.Duration(policy.Interval)*time.Hour) {
+		snapshot := &model.Snapshot{
+			VolumeID:  volume.ID,
+			Scheduled: true,
+		}
+		if err := c.Create(snapshot); err != nil {
+			return err
+		}
+	}
+
+	expired := failed
+	if len(ready) > policy.Retention {
+		expired = append(expired, ready[policy.Retention:]...)
+	}
+	for _, snapshot := range expired {
+		restoredFrom, err := c.restoredFrom(snapshot)
+		if err != nil {
+			return err
+		}
+		if restoredFrom {
+			continue
+		}
+		err = c.Delete(snapshot.ID, snapshot).Async()
+		if _, repeated := err.(*RepeatedActionError); err != nil && !repeated {
+			return err
+		}
+	}
+	return nil
+}
+
+// provisioning is true of a Snapshot whose provisioning Action is running,
+// on this server or another.
+func (c *Snapshots) provisioning(m *model.Snapshot) (bool, error) {
+	var records []*model.Action
+	if err := c.core.DB.Where("resource_uuid = ?", m.UUID).Find(&records); err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if record.Description == "provisioning" && actionState(&record.ActionStatus) == model.ActionStateRunning {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// snapshotPolicy returns the SnapshotPolicy of a Volume in the Release of its
+// Instance, if it has one. The Volume's Instance.Release must be loaded.
+func snapshotPolicy(volume *model.Volume) *model.SnapshotPolicy {
+	if volume.Instance == nil {
+		return nil
+	}
+	if volConf := volumeBlueprint(volume.Instance.Release, volume.Name); volConf != nil {
+		return volConf.SnapshotPolicy
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// SnapshotScheduler takes and expires the scheduled Snapshots of Volumes.
+type SnapshotScheduler struct {
+	core *Core
+}
+
+func (s *SnapshotScheduler) Perform() error {
+	return s.core.Snapshots.Schedule()
+}
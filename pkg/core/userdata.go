@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// Userdata is what the userdata templates of masters and minions shared by
+// providers (config/generic_master_userdata.txt and
+// config/generic_minion_userdata.txt) are rendered with. The Kube's fields are
+// those of the Userdata.
+type Userdata struct {
+	*model.Kube
+
+	// Zone is the zone (or region) of the Kube's servers.
+	Zone string
+
+	// HostnameCommand prints the name of a minion's server, which it registers
+	// its Node by.
+	HostnameCommand string
+
+	// Cloud is the Kubernetes cloud provider of the servers (ex. "gce"), by
+	// which Kubernetes attaches their disks to them. It is empty for clouds
+	// Kubernetes has no provider of.
+	Cloud string
+
+	// CloudConfig is the config file of the cloud provider, if it takes one.
+	CloudConfig string
+
+	// VolumeMountDir, when set, is where minions mount the volumes attached to
+	// them by an AttachingProvider, by the name after VolumeDevicePrefix in
+	// /dev/disk/by-id.
+	VolumeMountDir     string
+	VolumeDevicePrefix string
+}
+
+// RenderUserdata renders the userdata template file in dir with data.
+func RenderUserdata(dir string, file string, data interface{}) (string, error) {
+	userdataTemplate, err := ioutil.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return "", err
+	}
+	template, err := template.New(file).Parse(string(userdataTemplate))
+	if err != nil {
+		return "", err
+	}
+	var userdata bytes.Buffer
+	if err = template.Execute(&userdata, data); err != nil {
+		return "", err
+	}
+	return userdata.String(), nil
+}
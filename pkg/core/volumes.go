@@ -1,40 +1,36 @@
 package core
 
-import "github.com/supergiant/supergiant/pkg/model"
+import (
+	"errors"
+
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+var ErrorVolumeNotRetained = errors.New("Only retained Volumes can be deleted; others are deleted with their Instance")
 
 type Volumes struct {
 	Collection
 }
 
+var volumeRetryPolicy = &model.RetryPolicy{
+	InitialDelay: 10,
+	Multiplier:   2,
+	MaxDelay:     120,
+	Jitter:       0.2,
+	FatalErrors:  append(fatalAWSErrors, "VolumeLimitExceeded", "MaxIOPSLimitExceeded"),
+}
+
 func (c *Volumes) Provision(id *int64, m *model.Volume) *Action {
 	return &Action{
 		Status: &model.ActionStatus{
+			// NOTE Volumes are provisioned by the Actions of their Instances, which
+			// retry. That doesn't create duplicate volumes; providers name them
+			// after the Volume, or (on AWS) tag them as they are created and pass a
+			// ClientToken, and find the one an earlier attempt created.
 			Description: "provisioning",
-
-			// TODO
-			// This resource has an issue with retryable provisioning -- which in this
-			// context means creating an remote asset from the local record.
-			//
-			// Apps, for example, which use their user-set Name field as the actual
-			// identifier for the provisioned Kubernetes Namespace. That makes the
-			// creation of the Namespace retryable, because it is IDEMPOTENT.
-			//
-			// The problem here, is that WE CANNOT SET AN IDENTIFIER UP FRONT. The ID
-			// is given to us upon successful creation of the remote asset.
-			//
-			// You can tag volumes after creation, but that means it is a 2-step
-			// process, which means it fails to be atomic -- if tag creation fails,
-			// retrying would re-create a volume, since our identifer (which is used
-			// to load and check existence of the asset) was never set.
-			//
-			// We currently do not have a great solution in place for this problem.
-			// In the meantime, MaxRetries is set low to prevent creating several
-			// duplicate, billable assets in the user's cloud account. If there is an
-			// error, the user will know about it quickly, instead of after 20 retries.
-			MaxRetries: 1,
 		},
 		core:  c.core,
-		scope: c.core.DB.Preload("Instance").Preload("Kube.CloudAccount"),
+		scope: c.core.DB.Preload("Instance").Preload("Kube.CloudAccount").Preload("Snapshot"),
 		model: m,
 		id:    id,
 		fn: func(a *Action) error {
@@ -47,6 +43,7 @@ func (c *Volumes) Delete(id *int64, m *model.Volume) *Action {
 	return &Action{
 		Status: &model.ActionStatus{
 			Description: "deleting",
+			RetryPolicy: volumeRetryPolicy,
 			MaxRetries:  5,
 		},
 		core:  c.core,
@@ -62,6 +59,18 @@ func (c *Volumes) Delete(id *int64, m *model.Volume) *Action {
 	}
 }
 
+// DeleteRetained deletes a Volume which was retained when its Instance was
+// deleted. Other Volumes are deleted with their Instance.
+func (c *Volumes) DeleteRetained(id *int64, m *model.Volume) error {
+	if err := c.Get(id, m); err != nil {
+		return err
+	}
+	if !m.Retained {
+		return ErrorVolumeNotRetained
+	}
+	return c.Delete(id, m).Async()
+}
+
 // Resize the Volume
 func (c *Volumes) Resize(id *int64, m *model.Volume) *Action {
 	return &Action{
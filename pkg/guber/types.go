@@ -104,9 +104,38 @@ type AwsElasticBlockStore struct {
 	FSType   string `json:"fsType"`
 }
 
+type GCEPersistentDisk struct {
+	PDName string `json:"pdName"`
+	FSType string `json:"fsType"`
+}
+
+type Cinder struct {
+	VolumeID string `json:"volumeID"`
+	FSType   string `json:"fsType"`
+}
+
+type EmptyDir struct {
+	Medium string `json:"medium,omitempty"`
+}
+
+type HostPath struct {
+	Path string `json:"path"`
+}
+
+type NFS struct {
+	Server   string `json:"server"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+}
+
 type Volume struct {
 	Name                 string                `json:"name"`
-	AwsElasticBlockStore *AwsElasticBlockStore `json:"awsElasticBlockStore"`
+	AwsElasticBlockStore *AwsElasticBlockStore `json:"awsElasticBlockStore,omitempty"`
+	GCEPersistentDisk    *GCEPersistentDisk    `json:"gcePersistentDisk,omitempty"`
+	Cinder               *Cinder               `json:"cinder,omitempty"`
+	EmptyDir             *EmptyDir             `json:"emptyDir,omitempty"`
+	HostPath             *HostPath             `json:"hostPath,omitempty"`
+	NFS                  *NFS                  `json:"nfs,omitempty"`
 }
 
 type VolumeMount struct {
@@ -162,6 +191,7 @@ type PodSpec struct {
 	TerminationGracePeriodSeconds int                `json:"terminationGracePeriodSeconds"`
 	RestartPolicy                 string             `json:"restartPolicy"`
 	NodeName                      string             `json:"nodeName"`
+	NodeSelector                  map[string]string  `json:"nodeSelector,omitempty"`
 }
 
 type ContainerStateRunning struct {
@@ -188,7 +218,7 @@ type ContainerStatus struct {
 	Ready        bool            `json:"ready"`
 	RestartCount int             `json:"restartCount"`
 	State        *ContainerState `json:"state"`
-	LastState    *ContainerState `json:"state"`
+	LastState    *ContainerState `json:"lastState"`
 }
 
 type PodStatusCondition struct {
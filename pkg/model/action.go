@@ -0,0 +1,41 @@
+package model
+
+const (
+	ActionStateRunning   = "running"
+	ActionStateFailed    = "failed"
+	ActionStateCancelled = "cancelled"
+)
+
+// Action is the persisted record of an in-flight (or failed) core.Action. It
+// exists so that work like Kube provisioning can be picked back up after the
+// server restarts.
+type Action struct {
+	BaseModel
+
+	// ResourceUUID is the UUID of the Model the Action is performed on. There is
+	// only ever one Action per resource on a server, but a server other than
+	// the leader may perform one with Now() while the leader has another in
+	// flight.
+	ResourceUUID string `json:"resource_uuid" gorm:"not null;index"`
+	ResourceType string `json:"resource_type" gorm:"not null;index"`
+	ResourceID   *int64 `json:"resource_id"`
+
+	// RequesterID is the User that initiated the Action, when the Action needs
+	// one (Component deploys use the requester's API token).
+	RequesterID *int64 `json:"requester_id,omitempty"`
+
+	// Async is true when the Action was started in the background, which is the
+	// only kind of Action that is resumed on restart. Actions performed with
+	// Now() are steps of some other Action.
+	Async bool `json:"async"`
+
+	// Queued is true when the Action was started on a server other than the
+	// leader (or retried there), and is waiting for the leader to perform it.
+	Queued bool `json:"queued,omitempty"`
+
+	// State is one of running, failed, or cancelled. It is derived from the
+	// status when the Action is rendered.
+	State string `json:"state" gorm:"-"`
+
+	ActionStatus
+}
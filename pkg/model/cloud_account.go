@@ -8,9 +8,11 @@ type CloudAccount struct {
 
 	Name string `json:"name" validate:"nonzero" gorm:"not null;unique_index"`
 
-	Provider string `json:"provider" validate:"regexp=^(aws)$" gorm:"not null"`
+	// Provider is the name of a provider registered in core (ex. "aws").
+	Provider string `json:"provider" validate:"nonzero" gorm:"not null"`
 
-	// NOTE this is loose map to allow for multiple clouds (eventually)
+	// NOTE this is a loose map to allow for multiple clouds; the keys each
+	// provider takes are in its registered definition.
 	Credentials     map[string]string `json:"credentials,omitempty" gorm:"-" sg:"store_as_json_in=CredentialsJSON,private"`
 	CredentialsJSON []byte            `json:"-" gorm:"not null"`
 }
@@ -19,41 +19,206 @@ type Kube struct {
 	// has_many Volumes
 	Volumes []*Volume `json:"volumes,omitempty"`
 
+	// has_many Snapshots
+	Snapshots []*Snapshot `json:"snapshots,omitempty"`
+
 	Name string `json:"name" validate:"nonzero,max=12,regexp=^[a-z]([-a-z0-9]*[a-z0-9])?$" gorm:"not null;unique_index"`
 
-	MasterNodeSize string `json:"master_node_size" validate:"nonzero"`
+	// MasterNodeSize and NodeSizes are required of all but external Kubes,
+	// which core checks by provider.
+	MasterNodeSize string `json:"master_node_size"`
 
-	NodeSizes     []string `json:"node_sizes" gorm:"-" validate:"min=1" sg:"store_as_json_in=NodeSizesJSON"`
+	NodeSizes     []string `json:"node_sizes" gorm:"-" sg:"store_as_json_in=NodeSizesJSON"`
 	NodeSizesJSON []byte   `json:"-" gorm:"not null"`
 
+	// SpotPolicy makes the Kube's Nodes of some sizes spot servers, which are
+	// cheaper but may be interrupted by the cloud, on providers which have them.
+	SpotPolicy     *SpotPolicy `json:"spot_policy,omitempty" gorm:"-" sg:"store_as_json_in=SpotPolicyJSON"`
+	SpotPolicyJSON []byte      `json:"-"`
+
 	Username string `json:"username" validate:"nonzero"`
 	Password string `json:"password" validate:"nonzero"`
 
-	// NOTE due to how we marshal this as JSON, it's difficult to have this stored
-	// as an interface, because unmarshalling causes us to lose the underlying
-	// type. So, this is kindof like a whacky form of single-table inheritance.
-	AWSConfig     *AWSKubeConfig `json:"aws_config,omitempty" gorm:"-" sg:"store_as_json_in=AWSConfigJSON"`
-	AWSConfigJSON []byte         `json:"-"`
+	// ProviderConfig is the config of the Kube particular to its provider, of
+	// the type the provider registers in core (ex. *AWSKubeConfig). It is
+	// stored as JSON, which core decodes into that type by the provider of the
+	// Kube's CloudAccount, since unmarshalling into an interface loses it.
+	ProviderConfig     interface{} `json:"provider_config,omitempty" gorm:"-" sg:"store_as_json_in=ProviderConfigJSON"`
+	ProviderConfigJSON []byte      `json:"-"`
 
 	MasterPublicIP string `json:"master_public_ip" sg:"readonly"`
 
+	// CompletedSteps are the provisioning steps that have succeeded so far, which
+	// are skipped when provisioning is retried or resumed.
+	CompletedSteps     []string `json:"completed_steps,omitempty" gorm:"-" sg:"readonly,store_as_json_in=CompletedStepsJSON"`
+	CompletedStepsJSON []byte   `json:"-"`
+
 	Ready bool `json:"ready" sg:"readonly" gorm:"index"`
 }
 
+type SpotPolicy struct {
+	// NodeSizes are those of the Kube's NodeSizes whose Nodes are created as
+	// spot servers.
+	NodeSizes []string `json:"node_sizes" validate:"min=1"`
+
+	// MaxPrice is the most to pay per hour for a spot server, in USD (ex.
+	// "0.05"). It defaults to the provider's on-demand price.
+	MaxPrice string `json:"max_price"`
+
+	// OnDemandFallback creates a Node as an on-demand server when there is no
+	// spot capacity for it, or not at MaxPrice. Otherwise creating the Node
+	// fails, and is retried.
+	OnDemandFallback bool `json:"on_demand_fallback"`
+}
+
+// Spot returns true if Nodes of the size are spot servers.
+func (p *SpotPolicy) Spot(size string) bool {
+	if p == nil {
+		return false
+	}
+	for _, spotSize := range p.NodeSizes {
+		if spotSize == size {
+			return true
+		}
+	}
+	return false
+}
+
 type AWSKubeConfig struct {
-	Region              string `json:"region" validate:"nonzero,regexp=^[a-z]{2}-[a-z]+-[0-9]$"`
-	AvailabilityZone    string `json:"availability_zone" validate:"nonzero,regexp=^[a-z]{2}-[a-z]+-[0-9][a-z]$"`
-	VPCIPRange          string `json:"vpc_ip_range" validate:"nonzero" sg:"default=172.20.0.0/16"`
-	PublicSubnetIPRange string `json:"public_subnet_ip_range" validate:"nonzero" sg:"default=172.20.0.0/24"`
-	MasterPrivateIP     string `json:"master_private_ip" validate:"nonzero" sg:"default=172.20.0.9"`
-
-	PrivateKey                    string `json:"private_key,omitempty" sg:"readonly,private"`
-	VPCID                         string `json:"vpc_id" sg:"readonly"`
-	InternetGatewayID             string `json:"internet_gateway_id" sg:"readonly"`
-	PublicSubnetID                string `json:"public_subnet_id" sg:"readonly"`
-	RouteTableID                  string `json:"route_table_id" sg:"readonly"`
-	RouteTableSubnetAssociationID string `json:"route_table_subnet_association_id" sg:"readonly"`
-	ELBSecurityGroupID            string `json:"elb_security_group_id" sg:"readonly"`
-	NodeSecurityGroupID           string `json:"node_security_group_id" sg:"readonly"`
-	MasterID                      string `json:"master_id" sg:"readonly"`
+	Region string `json:"region" validate:"nonzero,regexp=^[a-z]{2}-[a-z]+-[0-9]$"`
+
+	// AvailabilityZones are the zones the Kube's Nodes are spread across, each
+	// with a public Subnet of the VPC. The master is in the first.
+	AvailabilityZones []*AWSAvailabilityZone `json:"availability_zones"`
+
+	// AvailabilityZone is the zone of the master. A Kube may be created with
+	// it (and PublicSubnetIPRange) in place of AvailabilityZones, to have just
+	// the one zone, as Kubes were before they had AvailabilityZones.
+	AvailabilityZone    string `json:"availability_zone" validate:"regexp=^([a-z]{2}-[a-z]+-[0-9][a-z])?$"`
+	PublicSubnetIPRange string `json:"public_subnet_ip_range,omitempty"`
+
+	VPCIPRange      string `json:"vpc_ip_range" validate:"nonzero" sg:"default=172.20.0.0/16"`
+	MasterPrivateIP string `json:"master_private_ip" validate:"nonzero" sg:"default=172.20.0.9"`
+
+	// PrivateNodes puts the Kube's Nodes in private Subnets, without public
+	// IPs, reaching the internet through a NAT Gateway in each zone. Only the
+	// master (and ELBs, and the bastion) are public.
+	PrivateNodes bool `json:"private_nodes"`
+
+	// BastionNodeSize is the size of a server to create in the first zone's
+	// public Subnet, to SSH to Nodes through. No bastion is created without it.
+	BastionNodeSize string `json:"bastion_node_size"`
+
+	// VPCID may be given to create the Kube in an existing VPC, along with the
+	// PublicSubnetID (and PrivateSubnetID) of each zone, instead of creating
+	// them. The route table of RouteTableID, if given, gets the route to the
+	// master. Otherwise they are created, and these are their IDs.
+	VPCID        string `json:"vpc_id" sg:"createonly"`
+	RouteTableID string `json:"route_table_id" sg:"createonly"`
+
+	// ELBSecurityGroupID and NodeSecurityGroupID may be given, both of groups
+	// of an existing VPC, instead of creating them.
+	ELBSecurityGroupID  string `json:"elb_security_group_id" sg:"createonly"`
+	NodeSecurityGroupID string `json:"node_security_group_id" sg:"createonly"`
+
+	// ExistingVPC and ExistingSecurityGroups are set when the Kube is created
+	// with them, so that they are left as they are when it is deleted.
+	ExistingVPC            bool `json:"existing_vpc" sg:"readonly"`
+	ExistingSecurityGroups bool `json:"existing_security_groups" sg:"readonly"`
+
+	PrivateKey        string `json:"private_key,omitempty" sg:"readonly,private"`
+	InternetGatewayID string `json:"internet_gateway_id" sg:"readonly"`
+	MasterID          string `json:"master_id" sg:"readonly"`
+	BastionID         string `json:"bastion_id" sg:"readonly"`
+	BastionPublicIP   string `json:"bastion_public_ip" sg:"readonly"`
+
+	// NOTE these are of the single zone of Kubes created before
+	// AvailabilityZones, which the provider moves into AvailabilityZones.
+	PublicSubnetID                string `json:"public_subnet_id,omitempty" sg:"readonly"`
+	RouteTableSubnetAssociationID string `json:"route_table_subnet_association_id,omitempty" sg:"readonly"`
+}
+
+type AWSAvailabilityZone struct {
+	Name string `json:"name" validate:"nonzero,regexp=^[a-z]{2}-[a-z]+-[0-9][a-z]$"`
+
+	// PublicSubnetIPRange defaults to the zone's /24 of the VPC, by its
+	// position in AvailabilityZones (ex. 172.20.1.0/24 for the second).
+	PublicSubnetIPRange string `json:"public_subnet_ip_range"`
+
+	// PrivateSubnetIPRange is of Kubes with PrivateNodes. It defaults to the
+	// /24 following those of all the zones' public Subnets.
+	PrivateSubnetIPRange string `json:"private_subnet_ip_range"`
+
+	// PublicSubnetID and PrivateSubnetID are given with the VPCID of an
+	// existing VPC.
+	PublicSubnetID  string `json:"public_subnet_id" sg:"createonly"`
+	PrivateSubnetID string `json:"private_subnet_id,omitempty" sg:"createonly"`
+
+	RouteTableSubnetAssociationID        string `json:"route_table_subnet_association_id" sg:"readonly"`
+	NATElasticIPAllocationID             string `json:"nat_elastic_ip_allocation_id,omitempty" sg:"readonly"`
+	NATGatewayID                         string `json:"nat_gateway_id,omitempty" sg:"readonly"`
+	PrivateRouteTableID                  string `json:"private_route_table_id,omitempty" sg:"readonly"`
+	PrivateRouteTableSubnetAssociationID string `json:"private_route_table_subnet_association_id,omitempty" sg:"readonly"`
+}
+
+type DOKubeConfig struct {
+	Region string `json:"region" validate:"nonzero,regexp=^[a-z]{3}[0-9]$"`
+	Image  string `json:"image" validate:"nonzero" sg:"default=debian-8-x64"`
+
+	// SSHKeyFingerprint is of a key in the DigitalOcean account to add to the
+	// Kube's droplets.
+	SSHKeyFingerprint string `json:"ssh_key_fingerprint"`
+
+	MasterID        int64  `json:"master_id" sg:"readonly"`
+	MasterPrivateIP string `json:"master_private_ip" sg:"readonly"`
+}
+
+type GCEKubeConfig struct {
+	Zone    string `json:"zone" validate:"nonzero,regexp=^[a-z]+-[a-z]+[0-9]-[a-z]$"`
+	Network string `json:"network" validate:"nonzero" sg:"default=default"`
+	Image   string `json:"image" validate:"nonzero" sg:"default=projects/debian-cloud/global/images/family/debian-8"`
+
+	MasterName      string `json:"master_name" sg:"readonly"`
+	MasterPrivateIP string `json:"master_private_ip" sg:"readonly"`
+}
+
+type OpenStackKubeConfig struct {
+	Region     string `json:"region" validate:"nonzero" sg:"default=RegionOne"`
+	TenantName string `json:"tenant_name" validate:"nonzero"`
+
+	// NetworkID is the network of the servers, and SubnetID the subnet of it
+	// that load balancers are created in.
+	NetworkID string `json:"network_id" validate:"nonzero"`
+	SubnetID  string `json:"subnet_id" validate:"nonzero"`
+
+	ImageID    string `json:"image_id" validate:"nonzero"`
+	KeyName    string `json:"key_name"`
+	VolumeType string `json:"volume_type"`
+
+	SecurityGroupID string `json:"security_group_id" sg:"readonly"`
+	MasterID        string `json:"master_id" sg:"readonly"`
+	MasterPrivateIP string `json:"master_private_ip" sg:"readonly"`
+}
+
+// ExternalKubeConfig is of a Kube which Supergiant did not provision, but
+// connects to. The API server is reached over HTTPS with the Kube's Username
+// and Password, BearerToken, or client certificate, whichever are set.
+type ExternalKubeConfig struct {
+	// Host is the address of the API server, e.g. "k8s.example.com:6443".
+	Host string `json:"host" validate:"nonzero"`
+
+	BearerToken string `json:"bearer_token,omitempty" sg:"private"`
+
+	// ClientCertificate and ClientKey are PEM-encoded.
+	ClientCertificate string `json:"client_certificate"`
+	ClientKey         string `json:"client_key,omitempty" sg:"private"`
+
+	// CertificateAuthority is the PEM-encoded CA the API server's certificate
+	// is verified with. Without it, the certificate is verified with the
+	// system's roots.
+	CertificateAuthority string `json:"certificate_authority"`
+
+	// InsecureSkipVerify turns off verifying the API server's certificate, for
+	// clusters whose CA isn't known. It can't be set with CertificateAuthority.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Lease is a named lock in the database, held by one Supergiant server at a
+// time for as long as it keeps renewing it. The "leader" Lease decides which
+// server runs RecurringServices and async Actions.
+type Lease struct {
+	BaseModel
+
+	Name      string    `json:"name" gorm:"not null;unique_index"`
+	HolderID  string    `json:"holder_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -31,6 +31,55 @@ type ActionStatus struct {
 	Retries     int    `json:"retries"`
 	Error       string `json:"error,omitempty"`
 	Cancelled   bool   `json:"cancelled,omitempty"`
+
+	// RetryPolicy controls how long to wait between retries, and which errors
+	// are not worth retrying. When nil, the default policy is used.
+	RetryPolicy     *RetryPolicy `json:"retry_policy,omitempty" gorm:"-" sg:"store_as_json_in=RetryPolicyJSON"`
+	RetryPolicyJSON []byte       `json:"-"`
+
+	// NextRetryAt is when a failed Action will be attempted again.
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+
+	// Steps are the named phases of the Action (for those that report them),
+	// such as each step of provisioning a Kube. They are reset on each attempt.
+	Steps     []*ActionStep `json:"steps,omitempty" gorm:"-" sg:"store_as_json_in=StepsJSON"`
+	StepsJSON []byte        `json:"-"`
+}
+
+const (
+	ActionStepStatePending   = "pending"
+	ActionStepStateRunning   = "running"
+	ActionStepStateCompleted = "completed"
+	ActionStepStateFailed    = "failed"
+	ActionStepStateSkipped   = "skipped"
+)
+
+type ActionStep struct {
+	Name       string     `json:"name"`
+	State      string     `json:"state"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type RetryPolicy struct {
+	// InitialDelay is the number of seconds to wait before the first retry.
+	InitialDelay int `json:"initial_delay"`
+
+	// Multiplier is applied to the delay after each retry.
+	Multiplier float64 `json:"multiplier"`
+
+	// MaxDelay is the most seconds to wait between retries.
+	MaxDelay int `json:"max_delay"`
+
+	// Jitter is the fraction (0 to 1) of the delay that is randomized, so that
+	// Actions which failed together don't all retry together.
+	Jitter float64 `json:"jitter"`
+
+	// FatalErrors are error message fragments (such as AWS error codes) which
+	// mean the error won't be fixed by retrying. An Action stops retrying when
+	// it fails with one of them.
+	FatalErrors []string `json:"fatal_errors,omitempty"`
 }
 
 func (m *BaseModel) GetID() interface{} {
@@ -92,6 +141,7 @@ type BelongsToField struct {
 type TaggedModelField struct {
 	Field         reflect.Value
 	Readonly      bool
+	Createonly    bool
 	Private       bool
 	Default       interface{}
 	StoreAsJsonIn *reflect.Value
@@ -115,6 +165,9 @@ func taggedModelFieldOf(obj reflect.Value, field reflect.StructField, fieldValue
 			case "readonly":
 				out.Readonly = true
 
+			case "createonly":
+				out.Createonly = true
+
 			case "private":
 				out.Private = true
 
@@ -201,6 +254,9 @@ func gatherTaggedModelFieldsInto(obj reflect.Value, taggedFields *[]*TaggedModel
 			}
 		case indirectFieldValue.Kind() == reflect.Struct:
 			gatherTaggedModelFieldsInto(indirectFieldValue, taggedFields)
+		case fieldValue.Kind() == reflect.Interface && fieldValue.Elem().Kind() == reflect.Ptr && fieldValue.Elem().Elem().Kind() == reflect.Struct:
+			// e.g. the ProviderConfig of a Kube
+			gatherTaggedModelFieldsInto(fieldValue.Elem().Elem(), taggedFields)
 		}
 	}
 }
@@ -221,6 +277,50 @@ func ZeroReadonlyFields(r Model) {
 	}
 }
 
+// KeepCreateonlyFields takes a Model with pointer, and the Model it updates,
+// and sets any fields with the tag sg:"createonly", which may be given when the
+// Model is created but not changed after, back to those of the old Model.
+// Elements of slices are matched by index; ones the old Model doesn't have keep
+// theirs.
+func KeepCreateonlyFields(r Model, old Model) {
+	keepCreateonlyFieldsOf(reflect.ValueOf(r).Elem(), reflect.ValueOf(old).Elem())
+}
+
+func keepCreateonlyFieldsOf(obj reflect.Value, old reflect.Value) {
+	objType := obj.Type()
+
+	for i := 0; i < obj.NumField(); i++ {
+		field := objType.Field(i)
+		fieldValue := obj.Field(i)
+		oldValue := old.Field(i)
+
+		if tag := field.Tag.Get("sg"); tag != "" && taggedModelFieldOf(obj, field, fieldValue).Createonly {
+			fieldValue.Set(oldValue)
+			continue
+		}
+
+		switch {
+		case fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() == reflect.Ptr && fieldValue.Type().Elem().Elem().Kind() == reflect.Struct:
+			for j := 0; j < fieldValue.Len() && j < oldValue.Len(); j++ {
+				if !fieldValue.Index(j).IsNil() && !oldValue.Index(j).IsNil() {
+					keepCreateonlyFieldsOf(fieldValue.Index(j).Elem(), oldValue.Index(j).Elem())
+				}
+			}
+		case fieldValue.Kind() == reflect.Struct:
+			keepCreateonlyFieldsOf(fieldValue, oldValue)
+		case fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct:
+			if !fieldValue.IsNil() && !oldValue.IsNil() {
+				keepCreateonlyFieldsOf(fieldValue.Elem(), oldValue.Elem())
+			}
+		case fieldValue.Kind() == reflect.Interface && fieldValue.Elem().Kind() == reflect.Ptr && fieldValue.Elem().Elem().Kind() == reflect.Struct:
+			// e.g. the ProviderConfig of a Kube
+			if !oldValue.IsNil() && oldValue.Elem().Type() == fieldValue.Elem().Type() && !fieldValue.Elem().IsNil() && !oldValue.Elem().IsNil() {
+				keepCreateonlyFieldsOf(fieldValue.Elem().Elem(), oldValue.Elem().Elem())
+			}
+		}
+	}
+}
+
 // ZeroPrivateFields takes a Model with pointer, and zeroes any fields with
 // the tag sg:"private".
 func ZeroPrivateFields(r Model) {
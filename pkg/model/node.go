@@ -12,6 +12,14 @@ type Node struct {
 	// This is the only input for Node
 	Size string `json:"size" validate:"nonzero"`
 
+	// AvailabilityZone is of Kubes whose provider has zones. It is chosen when
+	// the Node is created, unless given.
+	AvailabilityZone string `json:"availability_zone"`
+
+	// Spot is true of Nodes created as spot servers, per the Kube's SpotPolicy.
+	// It is false of those which fell back to on-demand.
+	Spot bool `json:"spot" sg:"readonly" gorm:"index"`
+
 	ProviderID                string    `json:"provider_id" sg:"readonly" gorm:"index"`
 	Name                      string    `json:"name" sg:"readonly" gorm:"index"`
 	ExternalIP                string    `json:"external_ip" sg:"readonly"`
@@ -0,0 +1,38 @@
+package model
+
+// Provider describes a cloud provider registered in core; what credentials
+// its CloudAccounts take, and the config its Kubes take. It is not stored.
+type Provider struct {
+	Name        string                `json:"name"`
+	Credentials []*ProviderCredential `json:"credentials"`
+	KubeConfig  *ProviderKubeConfig   `json:"kube_config,omitempty"`
+
+	// External providers connect to existing Kubernetes clusters, whose Kubes
+	// have no node sizes or managed Nodes.
+	External bool `json:"external"`
+}
+
+type ProviderCredential struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+
+	// Secret credentials should not be shown once entered.
+	Secret bool `json:"secret"`
+}
+
+// ProviderKubeConfig describes the provider_config of the provider's Kubes.
+type ProviderKubeConfig struct {
+	Fields []*ProviderKubeConfigField `json:"fields"`
+}
+
+type ProviderKubeConfigField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Default  string `json:"default,omitempty"`
+	Readonly bool   `json:"readonly"`
+
+	// Createonly fields may be given when a Kube is created, but not changed.
+	Createonly bool `json:"createonly,omitempty"`
+}
@@ -54,8 +54,93 @@ type ComponentConfig struct {
 
 type VolumeBlueprint struct {
 	Name string `json:"name" validate:"nonzero,regexp=^\\w[-\\w\\.]*$/"` // TODO max length
-	Type string `json:"type" validate:"regexp=^(gp2)$" sg:"default=gp2"` // TODO support other vol types
-	Size int    `json:"size" validate:"min=1"`
+
+	// Kind is what the Volume is; see the VolumeKind constants. Only ebs
+	// Volumes are disks of the Kube's provider, which have the Type, Size, IOPS,
+	// Throughput and Snapshot settings.
+	Kind string `json:"kind" validate:"regexp=^(ebs|empty_dir|host_path|nfs|efs)?$" sg:"default=ebs"`
+
+	Type string `json:"type" validate:"regexp=^(gp2|gp3|io1|io2|st1|sc1|standard|pd-standard|pd-ssd)$" sg:"default=gp2"`
+	Size int    `json:"size" validate:"min=0"`
+
+	// Server is the host of nfs Volumes, and the file system ID (fs-...) of efs
+	// Volumes.
+	Server string `json:"server,omitempty"`
+
+	// Path is the directory on the Node of host_path Volumes, and the exported
+	// directory of nfs and efs Volumes.
+	Path string `json:"path,omitempty"`
+
+	// ReadOnly nfs and efs Volumes are mounted read-only.
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// IOPS are provisioned for io1, io2 (which require them) and gp3 Volumes.
+	IOPS int `json:"iops,omitempty" validate:"min=0"`
+
+	// Throughput is provisioned for gp3 Volumes, in MiB/s.
+	Throughput int `json:"throughput,omitempty" validate:"min=0"`
+
+	// SnapshotID is of the Snapshot that Volumes created for new Instances are
+	// restored from. Existing Volumes are kept as they are.
+	SnapshotID *int64 `json:"snapshot_id,omitempty"`
+
+	// SnapshotPolicy, when set, has the Volumes snapshotted on a schedule.
+	SnapshotPolicy *SnapshotPolicy `json:"snapshot_policy,omitempty"`
+
+	// ReclaimPolicy is what becomes of the Volumes of Instances which are
+	// deleted; see the ReclaimPolicy constants. Releases from before it have
+	// none, which is taken as ReclaimPolicyDelete.
+	ReclaimPolicy string `json:"reclaim_policy" validate:"regexp=^(delete|retain|snapshot)?$" sg:"default=delete"`
+}
+
+const (
+	// VolumeKindEBS Volumes are disks of the Kube's provider; EBS volumes on
+	// AWS. Each Instance has its own.
+	VolumeKindEBS = "ebs"
+
+	// VolumeKindEmptyDir Volumes are empty directories on the Node, deleted with
+	// the pod of their Instance.
+	VolumeKindEmptyDir = "empty_dir"
+
+	// VolumeKindHostPath Volumes are a directory on the Node.
+	VolumeKindHostPath = "host_path"
+
+	// VolumeKindNFS Volumes are an NFS export, shared by every Instance.
+	VolumeKindNFS = "nfs"
+
+	// VolumeKindEFS Volumes are an EFS file system of the Kube's region, shared
+	// by every Instance.
+	VolumeKindEFS = "efs"
+)
+
+// KindOrDefault returns the Kind of the Volume. Releases from before Kind have
+// none, which is taken as VolumeKindEBS.
+func (v *VolumeBlueprint) KindOrDefault() string {
+	if v.Kind == "" {
+		return VolumeKindEBS
+	}
+	return v.Kind
+}
+
+const (
+	// ReclaimPolicyDelete deletes the Volumes with their Instance.
+	ReclaimPolicyDelete = "delete"
+
+	// ReclaimPolicyRetain keeps the Volumes after their Instance is deleted, to
+	// be reattached to the Instance of the same number when it is created again.
+	ReclaimPolicyRetain = "retain"
+
+	// ReclaimPolicySnapshot snapshots the Volumes before deleting them.
+	ReclaimPolicySnapshot = "snapshot"
+)
+
+type SnapshotPolicy struct {
+	// Interval is the number of hours between Snapshots.
+	Interval int `json:"interval" validate:"min=1"`
+
+	// Retention is the number of scheduled Snapshots kept of each Volume; older
+	// ones are deleted.
+	Retention int `json:"retention" validate:"min=1"`
 }
 
 type ContainerBlueprint struct {
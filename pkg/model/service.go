@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Service is the record of one of the RecurringServices Supergiant runs in the
+// background, like the capacity service. It is kept in the database so that
+// every server sharing it shows (and pauses) the services of the leader.
+type Service struct {
+	BaseModel
+
+	Name string `json:"name" gorm:"not null;unique_index" sg:"readonly"`
+
+	// Interval is the number of seconds between runs.
+	Interval int `json:"interval" sg:"readonly"`
+
+	// Paused services are not run until resumed (though they can still be run
+	// with run_now).
+	Paused bool `json:"paused" sg:"readonly"`
+
+	// RunRequested is set by run_now, and cleared when the service next runs.
+	RunRequested bool `json:"run_requested" sg:"readonly"`
+
+	LastRunAt *time.Time `json:"last_run_at,omitempty" sg:"readonly"`
+
+	// LastDuration is the number of seconds the last run took.
+	LastDuration float64 `json:"last_duration" sg:"readonly"`
+
+	LastError string `json:"last_error,omitempty" sg:"readonly"`
+}
@@ -0,0 +1,29 @@
+package model
+
+// Snapshot is a point-in-time copy of a Volume, which new Volumes can be
+// restored from. Snapshots outlive their Volume.
+type Snapshot struct {
+	BaseModel
+
+	// belongs_to Volume (see NOTE in Volume)
+	Volume   *Volume `json:"volume,omitempty" gorm:"ForeignKey:VolumeID"`
+	VolumeID *int64  `json:"volume_id" gorm:"not null;index" sg:"readonly"`
+
+	// belongs_to Kube
+	Kube   *Kube  `json:"kube,omitempty"`
+	KubeID *int64 `json:"kube_id" gorm:"not null;index" sg:"readonly"`
+
+	// VolumeName and Size are those of the Volume when it was snapshotted.
+	VolumeName string `json:"volume_name" sg:"readonly"`
+	Size       int    `json:"size" sg:"readonly"`
+
+	// Scheduled Snapshots are taken by the SnapshotPolicy of the Volume, and
+	// deleted once they are past its retention.
+	Scheduled bool `json:"scheduled" sg:"readonly"`
+
+	// Ready is set once the Snapshot is complete, and Volumes can be restored
+	// from it.
+	Ready bool `json:"ready" sg:"readonly"`
+
+	ProviderID string `json:"provider_id" sg:"readonly"`
+}
@@ -12,9 +12,39 @@ type Volume struct {
 	KubeID *int64 `json:"kube_id" gorm:"not null;index"`
 
 	// NOTE these are the same as VolumeBlueprint (we may want to repeat valiations)
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Size int    `json:"size"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int    `json:"size"`
+	IOPS       int    `json:"iops,omitempty"`
+	Throughput int    `json:"throughput,omitempty"`
+
+	// AvailabilityZone is of Kubes whose provider has zones. The Volumes of an
+	// Instance are all in one, which its pod is kept in.
+	AvailabilityZone string `json:"availability_zone"`
+
+	// belongs_to Snapshot (restored from)
+	//
+	// NOTE the foreign key is given, as gorm would otherwise take Snapshot for
+	// has_one by Snapshot.VolumeID.
+	Snapshot   *Snapshot `json:"snapshot,omitempty" gorm:"ForeignKey:SnapshotID"`
+	SnapshotID *int64    `json:"snapshot_id,omitempty"`
+
+	// Retained Volumes are kept after their Instance is deleted, by the
+	// ReclaimPolicy of its Release, until they are reattached to the Instance of
+	// the same Component and number (InstanceNum) when it is created again. The
+	// InstanceID is still that of the deleted Instance.
+	Retained    bool   `json:"retained" gorm:"index" sg:"readonly"`
+	ComponentID *int64 `json:"component_id" gorm:"index" sg:"readonly"`
+	InstanceNum int    `json:"instance_num" sg:"readonly"`
+
+	// GrowFilesystem is set once the volume has grown, until the pod of its
+	// Instance has grown the filesystem on it to the new size.
+	GrowFilesystem bool `json:"grow_filesystem" sg:"readonly"`
+
+	// ResizeSnapshotID is of the snapshot a resize is replacing the volume from,
+	// on providers that can't resize it in place, until it is replaced. A retry
+	// resumes from it.
+	ResizeSnapshotID string `json:"resize_snapshot_id,omitempty" sg:"readonly"`
 
 	ProviderID string `json:"provider_id" sg:"readonly"`
 }
@@ -2,11 +2,17 @@ package aws
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -17,7 +23,9 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/go-validator/validator"
 	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
 	"github.com/supergiant/supergiant/pkg/model"
 	"github.com/supergiant/supergiant/pkg/util"
 )
@@ -38,6 +46,29 @@ var AWSMasterAMIs = map[string]string{
 // TODO this and the similar concept in Kubes should be moved to core, not global vars
 var globalAWSSession = session.New()
 
+// UserdataDir is the directory of the servers' userdata templates.
+var UserdataDir = "config"
+
+// Endpoint, when set, is the URL the EC2 and IAM APIs are called at in place
+// of AWS's own. Tests point it at a local stand-in.
+var Endpoint = ""
+
+func init() {
+	core.RegisterProvider(&core.ProviderDefinition{
+		Name: "aws",
+		Credentials: []*model.ProviderCredential{
+			{Name: "access_key", Description: "AWS access key ID", Required: true},
+			{Name: "secret_key", Description: "AWS secret access key", Required: true, Secret: true},
+		},
+		NewKubeConfig:      func() interface{} { return new(model.AWSKubeConfig) },
+		IdempotentNodes:    true,
+		ValidateKubeConfig: validateKubeConfig,
+		New: func(c *core.Core, credentials map[string]string) core.Provider {
+			return &Provider{Core: c, Credentials: credentials}
+		},
+	})
+}
+
 type Provider struct {
 	Core        *core.Core
 	Credentials map[string]string
@@ -48,12 +79,21 @@ func (p *Provider) ValidateAccount(m *model.CloudAccount) error {
 	return err
 }
 
+// AvailabilityZones implements core.ZonedProvider.
+func (p *Provider) AvailabilityZones(m *model.Kube) (names []string) {
+	for _, zone := range availabilityZones(kubeConfig(m)) {
+		names = append(names, zone.Name)
+	}
+	return names
+}
+
 func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
-	iamS := p.iam(m.AWSConfig.Region)
-	ec2S := p.ec2(m.AWSConfig.Region)
-	provisioner := &provisioner{core: p.Core, kube: m}
+	iamS := p.iam(kubeConfig(m).Region)
+	ec2S := p.ec2(kubeConfig(m).Region)
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m, Action: action}
+	zones := availabilityZones(kubeConfig(m))
 
-	provisioner.addStep("preparing IAM Role kubernetes-master", func() error {
+	provisioner.AddStep("preparing IAM Role kubernetes-master", func() error {
 		policy := `{
       "Version": "2012-10-17",
       "Statement": [
@@ -68,7 +108,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return createIAMRole(iamS, "kubernetes-master", policy)
 	})
 
-	provisioner.addStep("preparing IAM Role Policy kubernetes-master", func() error {
+	provisioner.AddStep("preparing IAM Role Policy kubernetes-master", func() error {
 		policy := `{
       "Version": "2012-10-17",
       "Statement": [
@@ -94,11 +134,11 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return createIAMRolePolicy(iamS, "kubernetes-master", policy)
 	})
 
-	provisioner.addStep("preparing IAM Instance Profile kubernetes-minion", func() error {
-		return createIAMInstanceProfile(iamS, "kubernetes-minion")
+	provisioner.AddStep("preparing IAM Instance Profile kubernetes-master", func() error {
+		return createIAMInstanceProfile(iamS, "kubernetes-master")
 	})
 
-	provisioner.addStep("preparing IAM Role kubernetes-minion", func() error {
+	provisioner.AddStep("preparing IAM Role kubernetes-minion", func() error {
 		policy := `{
       "Version": "2012-10-17",
       "Statement": [
@@ -113,7 +153,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return createIAMRole(iamS, "kubernetes-minion", policy)
 	})
 
-	provisioner.addStep("preparing IAM Role Policy kubernetes-minion", func() error {
+	provisioner.AddStep("preparing IAM Role Policy kubernetes-minion", func() error {
 		policy := `{
       "Version": "2012-10-17",
       "Statement": [
@@ -144,12 +184,12 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return createIAMRolePolicy(iamS, "kubernetes-minion", policy)
 	})
 
-	provisioner.addStep("preparing IAM Instance Profile kubernetes-minion", func() error {
+	provisioner.AddStep("preparing IAM Instance Profile kubernetes-minion", func() error {
 		return createIAMInstanceProfile(iamS, "kubernetes-minion")
 	})
 
-	provisioner.addStep("creating SSH Key Pair", func() error {
-		if m.AWSConfig.PrivateKey != "" {
+	provisioner.AddStep("creating SSH Key Pair", func() error {
+		if kubeConfig(m).PrivateKey != "" {
 			return nil
 		}
 		input := &ec2.CreateKeyPairInput{
@@ -168,35 +208,42 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 			}
 			return err
 		}
-		m.AWSConfig.PrivateKey = *resp.KeyMaterial
+		kubeConfig(m).PrivateKey = *resp.KeyMaterial
 		return nil
 	})
 
-	provisioner.addStep("creating VPC", func() error {
-		if m.AWSConfig.VPCID != "" {
+	provisioner.AddStep("creating VPC", func() error {
+		if kubeConfig(m).VPCID != "" {
 			return nil
 		}
 		input := &ec2.CreateVpcInput{
-			CidrBlock: aws.String(m.AWSConfig.VPCIPRange),
+			CidrBlock: aws.String(kubeConfig(m).VPCIPRange),
 		}
 		resp, err := ec2S.CreateVpc(input)
 		if err != nil {
 			return err
 		}
-		m.AWSConfig.VPCID = *resp.Vpc.VpcId
+		kubeConfig(m).VPCID = *resp.Vpc.VpcId
 		return nil
 	})
 
-	provisioner.addStep("tagging VPC", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.VPCID, map[string]string{
+	provisioner.AddStep("tagging VPC", func() error {
+		// NOTE an existing VPC, its Subnets and routing are left as they are.
+		if kubeConfig(m).ExistingVPC {
+			return nil
+		}
+		return tagAWSResource(ec2S, kubeConfig(m).VPCID, map[string]string{
 			"KubernetesCluster": m.Name,
 			"Name":              m.Name + "-vpc",
 		})
 	})
 
-	provisioner.addStep("enabling VPC DNS", func() error {
+	provisioner.AddStep("enabling VPC DNS", func() error {
+		if kubeConfig(m).ExistingVPC {
+			return nil
+		}
 		input := &ec2.ModifyVpcAttributeInput{
-			VpcId:              aws.String(m.AWSConfig.VPCID),
+			VpcId:              aws.String(kubeConfig(m).VPCID),
 			EnableDnsHostnames: &ec2.AttributeBooleanValue{Value: aws.Bool(true)},
 		}
 		_, err := ec2S.ModifyVpcAttribute(input)
@@ -205,29 +252,35 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 
 	// Create Internet Gateway
 
-	provisioner.addStep("creating Internet Gateway", func() error {
-		if m.AWSConfig.InternetGatewayID != "" {
+	provisioner.AddStep("creating Internet Gateway", func() error {
+		if kubeConfig(m).ExistingVPC || kubeConfig(m).InternetGatewayID != "" {
 			return nil
 		}
 		resp, err := ec2S.CreateInternetGateway(new(ec2.CreateInternetGatewayInput))
 		if err != nil {
 			return err
 		}
-		m.AWSConfig.InternetGatewayID = *resp.InternetGateway.InternetGatewayId
+		kubeConfig(m).InternetGatewayID = *resp.InternetGateway.InternetGatewayId
 		return nil
 	})
 
-	provisioner.addStep("tagging Internet Gateway", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.InternetGatewayID, map[string]string{
+	provisioner.AddStep("tagging Internet Gateway", func() error {
+		if kubeConfig(m).ExistingVPC {
+			return nil
+		}
+		return tagAWSResource(ec2S, kubeConfig(m).InternetGatewayID, map[string]string{
 			"KubernetesCluster": m.Name,
 			"Name":              m.Name + "-ig",
 		})
 	})
 
-	provisioner.addStep("attaching Internet Gateway to VPC", func() error {
+	provisioner.AddStep("attaching Internet Gateway to VPC", func() error {
+		if kubeConfig(m).ExistingVPC {
+			return nil
+		}
 		input := &ec2.AttachInternetGatewayInput{
-			VpcId:             aws.String(m.AWSConfig.VPCID),
-			InternetGatewayId: aws.String(m.AWSConfig.InternetGatewayID),
+			VpcId:             aws.String(kubeConfig(m).VPCID),
+			InternetGatewayId: aws.String(kubeConfig(m).InternetGatewayID),
 		}
 		if _, err := ec2S.AttachInternetGateway(input); err != nil && !strings.Contains(err.Error(), "already attached") {
 			return err
@@ -235,86 +288,115 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return nil
 	})
 
-	// Create Subnet
+	// Create Subnets, one per zone
+
+	for i, zone := range zones {
+		i, zone := i, zone
 
-	provisioner.addStep("creating Subnet", func() error {
-		if m.AWSConfig.PublicSubnetID != "" {
+		provisioner.AddStep("creating Subnet in "+zone.Name, func() error {
+			if zone.PublicSubnetID != "" {
+				return nil
+			}
+			if zone.PublicSubnetIPRange == "" {
+				ipRange, err := subnetIPRange(kubeConfig(m).VPCIPRange, i)
+				if err != nil {
+					return &core.FatalError{Err: err}
+				}
+				zone.PublicSubnetIPRange = ipRange
+			}
+			input := &ec2.CreateSubnetInput{
+				VpcId:            aws.String(kubeConfig(m).VPCID),
+				CidrBlock:        aws.String(zone.PublicSubnetIPRange),
+				AvailabilityZone: aws.String(zone.Name),
+			}
+			resp, err := ec2S.CreateSubnet(input)
+			if err != nil {
+				return err
+			}
+			zone.PublicSubnetID = *resp.Subnet.SubnetId
 			return nil
-		}
-		input := &ec2.CreateSubnetInput{
-			VpcId:            aws.String(m.AWSConfig.VPCID),
-			CidrBlock:        aws.String(m.AWSConfig.PublicSubnetIPRange),
-			AvailabilityZone: aws.String(m.AWSConfig.AvailabilityZone),
-		}
-		resp, err := ec2S.CreateSubnet(input)
-		if err != nil {
-			return err
-		}
-		m.AWSConfig.PublicSubnetID = *resp.Subnet.SubnetId
-		return nil
-	})
+		})
 
-	provisioner.addStep("tagging Subnet", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.PublicSubnetID, map[string]string{
-			"KubernetesCluster": m.Name,
-			"Name":              m.Name + "-psub",
+		provisioner.AddStep("tagging Subnet in "+zone.Name, func() error {
+			if kubeConfig(m).ExistingVPC {
+				return nil
+			}
+			return tagAWSResource(ec2S, zone.PublicSubnetID, map[string]string{
+				"KubernetesCluster": m.Name,
+				"Name":              m.Name + "-psub-" + zone.Name,
+			})
 		})
-	})
 
-	provisioner.addStep("enabling public IP assignment setting of Subnet", func() error {
-		input := &ec2.ModifySubnetAttributeInput{
-			SubnetId:            aws.String(m.AWSConfig.PublicSubnetID),
-			MapPublicIpOnLaunch: &ec2.AttributeBooleanValue{Value: aws.Bool(true)},
-		}
-		_, err := ec2S.ModifySubnetAttribute(input)
-		return err
-	})
+		provisioner.AddStep("enabling public IP assignment setting of Subnet in "+zone.Name, func() error {
+			if kubeConfig(m).ExistingVPC {
+				return nil
+			}
+			input := &ec2.ModifySubnetAttributeInput{
+				SubnetId:            aws.String(zone.PublicSubnetID),
+				MapPublicIpOnLaunch: &ec2.AttributeBooleanValue{Value: aws.Bool(true)},
+			}
+			_, err := ec2S.ModifySubnetAttribute(input)
+			return err
+		})
+	}
 
 	// Route Table
 
-	provisioner.addStep("creating Route Table", func() error {
-		if m.AWSConfig.RouteTableID != "" {
+	provisioner.AddStep("creating Route Table", func() error {
+		if kubeConfig(m).ExistingVPC || kubeConfig(m).RouteTableID != "" {
 			return nil
 		}
 		input := &ec2.CreateRouteTableInput{
-			VpcId: aws.String(m.AWSConfig.VPCID),
+			VpcId: aws.String(kubeConfig(m).VPCID),
 		}
 		resp, err := ec2S.CreateRouteTable(input)
 		if err != nil {
 			return err
 		}
-		m.AWSConfig.RouteTableID = *resp.RouteTable.RouteTableId
+		kubeConfig(m).RouteTableID = *resp.RouteTable.RouteTableId
 		return nil
 	})
 
-	provisioner.addStep("tagging Route Table", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.RouteTableID, map[string]string{
+	provisioner.AddStep("tagging Route Table", func() error {
+		// NOTE the route table of an existing VPC is tagged all the same, as
+		// Kubernetes adds the routes of pods to the one of its cluster.
+		if kubeConfig(m).RouteTableID == "" {
+			return nil
+		}
+		return tagAWSResource(ec2S, kubeConfig(m).RouteTableID, map[string]string{
 			"KubernetesCluster": m.Name,
 			"Name":              m.Name + "-rt",
 		})
 	})
 
-	provisioner.addStep("associating Route Table with Subnet", func() error {
-		if m.AWSConfig.RouteTableSubnetAssociationID != "" {
+	for _, zone := range zones {
+		zone := zone
+
+		provisioner.AddStep("associating Route Table with Subnet in "+zone.Name, func() error {
+			if kubeConfig(m).ExistingVPC || zone.RouteTableSubnetAssociationID != "" {
+				return nil
+			}
+			input := &ec2.AssociateRouteTableInput{
+				RouteTableId: aws.String(kubeConfig(m).RouteTableID),
+				SubnetId:     aws.String(zone.PublicSubnetID),
+			}
+			resp, err := ec2S.AssociateRouteTable(input)
+			if err != nil {
+				return err
+			}
+			zone.RouteTableSubnetAssociationID = *resp.AssociationId
 			return nil
-		}
-		input := &ec2.AssociateRouteTableInput{
-			RouteTableId: aws.String(m.AWSConfig.RouteTableID),
-			SubnetId:     aws.String(m.AWSConfig.PublicSubnetID),
-		}
-		resp, err := ec2S.AssociateRouteTable(input)
-		if err != nil {
-			return err
-		}
-		m.AWSConfig.RouteTableSubnetAssociationID = *resp.AssociationId
-		return nil
-	})
+		})
+	}
 
-	provisioner.addStep("creating Route for Internet Gateway", func() error {
+	provisioner.AddStep("creating Route for Internet Gateway", func() error {
+		if kubeConfig(m).ExistingVPC {
+			return nil
+		}
 		input := &ec2.CreateRouteInput{
 			DestinationCidrBlock: aws.String("0.0.0.0/0"),
-			RouteTableId:         aws.String(m.AWSConfig.RouteTableID),
-			GatewayId:            aws.String(m.AWSConfig.InternetGatewayID),
+			RouteTableId:         aws.String(kubeConfig(m).RouteTableID),
+			GatewayId:            aws.String(kubeConfig(m).InternetGatewayID),
 		}
 		if _, err := ec2S.CreateRoute(input); err != nil && !strings.Contains(err.Error(), "InvalidPermission.Duplicate") {
 			return err
@@ -322,34 +404,186 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return nil
 	})
 
+	// Private Subnets, each with a NAT Gateway in the public Subnet of its zone
+
+	if kubeConfig(m).PrivateNodes && !kubeConfig(m).ExistingVPC {
+		for i, zone := range zones {
+			i, zone := i, zone
+
+			provisioner.AddStep("creating private Subnet in "+zone.Name, func() error {
+				if zone.PrivateSubnetID != "" {
+					return nil
+				}
+				if zone.PrivateSubnetIPRange == "" {
+					ipRange, err := subnetIPRange(kubeConfig(m).VPCIPRange, len(zones)+i)
+					if err != nil {
+						return &core.FatalError{Err: err}
+					}
+					zone.PrivateSubnetIPRange = ipRange
+				}
+				input := &ec2.CreateSubnetInput{
+					VpcId:            aws.String(kubeConfig(m).VPCID),
+					CidrBlock:        aws.String(zone.PrivateSubnetIPRange),
+					AvailabilityZone: aws.String(zone.Name),
+				}
+				resp, err := ec2S.CreateSubnet(input)
+				if err != nil {
+					return err
+				}
+				zone.PrivateSubnetID = *resp.Subnet.SubnetId
+				return nil
+			})
+
+			provisioner.AddStep("tagging private Subnet in "+zone.Name, func() error {
+				return tagAWSResource(ec2S, zone.PrivateSubnetID, map[string]string{
+					"KubernetesCluster": m.Name,
+					"Name":              m.Name + "-prsub-" + zone.Name,
+				})
+			})
+
+			provisioner.AddStep("allocating Elastic IP for NAT Gateway in "+zone.Name, func() error {
+				if zone.NATElasticIPAllocationID != "" {
+					return nil
+				}
+				input := &ec2.AllocateAddressInput{
+					Domain: aws.String("vpc"),
+				}
+				resp, err := ec2S.AllocateAddress(input)
+				if err != nil {
+					return err
+				}
+				zone.NATElasticIPAllocationID = *resp.AllocationId
+				return nil
+			})
+
+			provisioner.AddStep("creating NAT Gateway in "+zone.Name, func() error {
+				if zone.NATGatewayID != "" {
+					return nil
+				}
+				input := &ec2.CreateNatGatewayInput{
+					AllocationId: aws.String(zone.NATElasticIPAllocationID),
+					SubnetId:     aws.String(zone.PublicSubnetID),
+				}
+				resp, err := ec2S.CreateNatGateway(input)
+				if err != nil {
+					return err
+				}
+				zone.NATGatewayID = *resp.NatGateway.NatGatewayId
+				return nil
+			})
+
+			provisioner.AddStep("waiting for NAT Gateway in "+zone.Name, func() error {
+				input := &ec2.DescribeNatGatewaysInput{
+					NatGatewayIds: []*string{
+						aws.String(zone.NATGatewayID),
+					},
+				}
+				return action.CancellableWaitFor("NAT Gateway in "+zone.Name, 10*time.Minute, 5*time.Second, func() (bool, error) {
+					resp, err := ec2S.DescribeNatGateways(input)
+					if err != nil {
+						return false, err
+					}
+					if len(resp.NatGateways) == 0 {
+						return false, nil
+					}
+					gateway := resp.NatGateways[0]
+					if *gateway.State == "failed" {
+						// A failed NAT Gateway is deleted by AWS; the next attempt creates another.
+						zone.NATGatewayID = ""
+						return false, fmt.Errorf("NAT Gateway failed: %s", aws.StringValue(gateway.FailureMessage))
+					}
+					return *gateway.State == "available", nil
+				})
+			})
+
+			provisioner.AddStep("creating private Route Table in "+zone.Name, func() error {
+				if zone.PrivateRouteTableID != "" {
+					return nil
+				}
+				input := &ec2.CreateRouteTableInput{
+					VpcId: aws.String(kubeConfig(m).VPCID),
+				}
+				resp, err := ec2S.CreateRouteTable(input)
+				if err != nil {
+					return err
+				}
+				zone.PrivateRouteTableID = *resp.RouteTable.RouteTableId
+				return nil
+			})
+
+			provisioner.AddStep("tagging private Route Table in "+zone.Name, func() error {
+				// NOTE only the Route Table of RouteTableID is tagged KubernetesCluster,
+				// as Kubernetes refuses clusters with more than one.
+				return tagAWSResource(ec2S, zone.PrivateRouteTableID, map[string]string{
+					"Name": m.Name + "-prt-" + zone.Name,
+				})
+			})
+
+			provisioner.AddStep("associating private Route Table with Subnet in "+zone.Name, func() error {
+				if zone.PrivateRouteTableSubnetAssociationID != "" {
+					return nil
+				}
+				input := &ec2.AssociateRouteTableInput{
+					RouteTableId: aws.String(zone.PrivateRouteTableID),
+					SubnetId:     aws.String(zone.PrivateSubnetID),
+				}
+				resp, err := ec2S.AssociateRouteTable(input)
+				if err != nil {
+					return err
+				}
+				zone.PrivateRouteTableSubnetAssociationID = *resp.AssociationId
+				return nil
+			})
+
+			provisioner.AddStep("creating Route for NAT Gateway in "+zone.Name, func() error {
+				input := &ec2.CreateRouteInput{
+					DestinationCidrBlock: aws.String("0.0.0.0/0"),
+					RouteTableId:         aws.String(zone.PrivateRouteTableID),
+					NatGatewayId:         aws.String(zone.NATGatewayID),
+				}
+				if _, err := ec2S.CreateRoute(input); err != nil && !strings.Contains(err.Error(), "RouteAlreadyExists") {
+					return err
+				}
+				return nil
+			})
+		}
+	}
+
 	// Create Security Groups
 
-	provisioner.addStep("creating ELB Security Group", func() error {
-		if m.AWSConfig.ELBSecurityGroupID != "" {
+	provisioner.AddStep("creating ELB Security Group", func() error {
+		if kubeConfig(m).ELBSecurityGroupID != "" {
 			return nil
 		}
 		input := &ec2.CreateSecurityGroupInput{
 			GroupName:   aws.String(m.Name + "_elb_sg"),
 			Description: aws.String("Allow any external port through to internal 30-40k range"),
-			VpcId:       aws.String(m.AWSConfig.VPCID),
+			VpcId:       aws.String(kubeConfig(m).VPCID),
 		}
 		resp, err := ec2S.CreateSecurityGroup(input)
 		if err != nil {
 			return err
 		}
-		m.AWSConfig.ELBSecurityGroupID = *resp.GroupId
+		kubeConfig(m).ELBSecurityGroupID = *resp.GroupId
 		return nil
 	})
 
-	provisioner.addStep("tagging ELB Security Group", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.ELBSecurityGroupID, map[string]string{
+	provisioner.AddStep("tagging ELB Security Group", func() error {
+		// NOTE existing Security Groups are left as they are.
+		if kubeConfig(m).ExistingSecurityGroups {
+			return nil
+		}
+		return tagAWSResource(ec2S, kubeConfig(m).ELBSecurityGroupID, map[string]string{
 			"KubernetesCluster": m.Name,
 		})
 	})
 
-	provisioner.addStep("creating ELB Security Group ingress rules", func() error {
+	provisioner.AddStep("creating ELB Security Group ingress rules", func() error {
+		if kubeConfig(m).ExistingSecurityGroups {
+			return nil
+		}
 		input := &ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId: aws.String(m.AWSConfig.ELBSecurityGroupID),
+			GroupId: aws.String(kubeConfig(m).ELBSecurityGroupID),
 			IpPermissions: []*ec2.IpPermission{
 				{
 					FromPort:   aws.Int64(0),
@@ -369,9 +603,12 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return nil
 	})
 
-	provisioner.addStep("creating ELB Security Group egress rules", func() error {
+	provisioner.AddStep("creating ELB Security Group egress rules", func() error {
+		if kubeConfig(m).ExistingSecurityGroups {
+			return nil
+		}
 		input := &ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId: aws.String(m.AWSConfig.ELBSecurityGroupID),
+			GroupId: aws.String(kubeConfig(m).ELBSecurityGroupID),
 			IpPermissions: []*ec2.IpPermission{
 				{
 					FromPort:   aws.Int64(30000),
@@ -401,32 +638,38 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return nil
 	})
 
-	provisioner.addStep("creating Node Security Group", func() error {
-		if m.AWSConfig.NodeSecurityGroupID != "" {
+	provisioner.AddStep("creating Node Security Group", func() error {
+		if kubeConfig(m).NodeSecurityGroupID != "" {
 			return nil
 		}
 		input := &ec2.CreateSecurityGroupInput{
 			GroupName:   aws.String(m.Name + "_sg"),
 			Description: aws.String("Allow any traffic to 443 and 22, but only traffic from ELB for 10250 and 30k-40k"),
-			VpcId:       aws.String(m.AWSConfig.VPCID),
+			VpcId:       aws.String(kubeConfig(m).VPCID),
 		}
 		resp, err := ec2S.CreateSecurityGroup(input)
 		if err != nil {
 			return err
 		}
-		m.AWSConfig.NodeSecurityGroupID = *resp.GroupId
+		kubeConfig(m).NodeSecurityGroupID = *resp.GroupId
 		return nil
 	})
 
-	provisioner.addStep("tagging Node Security Group", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.NodeSecurityGroupID, map[string]string{
+	provisioner.AddStep("tagging Node Security Group", func() error {
+		if kubeConfig(m).ExistingSecurityGroups {
+			return nil
+		}
+		return tagAWSResource(ec2S, kubeConfig(m).NodeSecurityGroupID, map[string]string{
 			"KubernetesCluster": m.Name,
 		})
 	})
 
-	provisioner.addStep("creating Node Security Group ingress rules", func() error {
+	provisioner.AddStep("creating Node Security Group ingress rules", func() error {
+		if kubeConfig(m).ExistingSecurityGroups {
+			return nil
+		}
 		input := &ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId: aws.String(m.AWSConfig.NodeSecurityGroupID),
+			GroupId: aws.String(kubeConfig(m).NodeSecurityGroupID),
 			IpPermissions: []*ec2.IpPermission{
 				{
 					FromPort:   aws.Int64(0),
@@ -434,7 +677,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 					IpProtocol: aws.String("-1"),
 					UserIdGroupPairs: []*ec2.UserIdGroupPair{
 						{
-							GroupId: aws.String(m.AWSConfig.NodeSecurityGroupID), // ?? TODO is this correct? -- https://github.com/supergiant/terraform-assets/blob/master/aws/1.1.7/security_groups.tf#L39
+							GroupId: aws.String(kubeConfig(m).NodeSecurityGroupID), // ?? TODO is this correct? -- https://github.com/supergiant/terraform-assets/blob/master/aws/1.1.7/security_groups.tf#L39
 						},
 					},
 				},
@@ -464,7 +707,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 					IpProtocol: aws.String("tcp"),
 					UserIdGroupPairs: []*ec2.UserIdGroupPair{
 						{
-							GroupId: aws.String(m.AWSConfig.ELBSecurityGroupID),
+							GroupId: aws.String(kubeConfig(m).ELBSecurityGroupID),
 						},
 					},
 				},
@@ -474,7 +717,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 					IpProtocol: aws.String("tcp"),
 					UserIdGroupPairs: []*ec2.UserIdGroupPair{
 						{
-							GroupId: aws.String(m.AWSConfig.ELBSecurityGroupID),
+							GroupId: aws.String(kubeConfig(m).ELBSecurityGroupID),
 						},
 					},
 				},
@@ -486,9 +729,12 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return nil
 	})
 
-	provisioner.addStep("creating Node Security Group egress rules", func() error {
+	provisioner.AddStep("creating Node Security Group egress rules", func() error {
+		if kubeConfig(m).ExistingSecurityGroups {
+			return nil
+		}
 		input := &ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId: aws.String(m.AWSConfig.NodeSecurityGroupID),
+			GroupId: aws.String(kubeConfig(m).NodeSecurityGroupID),
 			IpPermissions: []*ec2.IpPermission{
 				{
 					FromPort:   aws.Int64(0),
@@ -510,12 +756,18 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 
 	// Master Instance
 
-	provisioner.addStep("creating Server for Kubernetes master", func() error {
-		if m.AWSConfig.MasterID != "" {
+	provisioner.AddStep("creating Server for Kubernetes master", func() error {
+		if kubeConfig(m).MasterID != "" {
 			return nil
 		}
 
-		userdataTemplate, err := ioutil.ReadFile("config/master_userdata.txt")
+		if kubeConfig(m).ExistingVPC {
+			if err := p.checkIPInSubnet(kubeConfig(m).Region, kubeConfig(m).MasterPrivateIP, zones[0].PublicSubnetID); err != nil {
+				return err
+			}
+		}
+
+		userdataTemplate, err := ioutil.ReadFile(filepath.Join(UserdataDir, "master_userdata.txt"))
 		if err != nil {
 			return err
 		}
@@ -532,7 +784,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		input := &ec2.RunInstancesInput{
 			MinCount:     aws.Int64(1),
 			MaxCount:     aws.Int64(1),
-			ImageId:      aws.String(AWSMasterAMIs[m.AWSConfig.Region]),
+			ImageId:      aws.String(AWSMasterAMIs[kubeConfig(m).Region]),
 			InstanceType: aws.String(m.MasterNodeSize),
 			KeyName:      aws.String(m.Name + "-key"),
 			NetworkInterfaces: []*ec2.InstanceNetworkInterfaceSpecification{
@@ -541,10 +793,10 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 					AssociatePublicIpAddress: aws.Bool(true),
 					DeleteOnTermination:      aws.Bool(true),
 					Groups: []*string{
-						aws.String(m.AWSConfig.NodeSecurityGroupID),
+						aws.String(kubeConfig(m).NodeSecurityGroupID),
 					},
-					SubnetId:         aws.String(m.AWSConfig.PublicSubnetID),
-					PrivateIpAddress: aws.String(m.AWSConfig.MasterPrivateIP),
+					SubnetId:         aws.String(zones[0].PublicSubnetID),
+					PrivateIpAddress: aws.String(kubeConfig(m).MasterPrivateIP),
 				},
 			},
 			IamInstanceProfile: &ec2.IamInstanceProfileSpecification{
@@ -569,12 +821,12 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 
 		instance := resp.Instances[0]
 
-		m.AWSConfig.MasterID = *instance.InstanceId
+		kubeConfig(m).MasterID = *instance.InstanceId
 		return nil
 	})
 
-	provisioner.addStep("tagging Kubernetes master", func() error {
-		return tagAWSResource(ec2S, m.AWSConfig.MasterID, map[string]string{
+	provisioner.AddStep("tagging Kubernetes master", func() error {
+		return tagAWSResource(ec2S, kubeConfig(m).MasterID, map[string]string{
 			"KubernetesCluster": m.Name,
 			"Name":              m.Name + "-master",
 			"Role":              m.Name + "-master",
@@ -583,10 +835,10 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 
 	// Wait for server to be ready
 
-	provisioner.addStep("waiting for Kubernetes master to launch", func() error {
+	provisioner.AddStep("waiting for Kubernetes master to launch", func() error {
 		input := &ec2.DescribeInstancesInput{
 			InstanceIds: []*string{
-				aws.String(m.AWSConfig.MasterID),
+				aws.String(kubeConfig(m).MasterID),
 			},
 		}
 
@@ -614,19 +866,101 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 
 	// Create route for master
 
-	provisioner.addStep("creating Route for Kubernetes master", func() error {
+	provisioner.AddStep("creating Route for Kubernetes master", func() error {
+		if kubeConfig(m).RouteTableID == "" {
+			return nil
+		}
 		input := &ec2.CreateRouteInput{
 			DestinationCidrBlock: aws.String("10.246.0.0/24"),
-			RouteTableId:         aws.String(m.AWSConfig.RouteTableID),
-			InstanceId:           aws.String(m.AWSConfig.MasterID),
+			RouteTableId:         aws.String(kubeConfig(m).RouteTableID),
+			InstanceId:           aws.String(kubeConfig(m).MasterID),
 		}
 		_, err := ec2S.CreateRoute(input)
 		return err
 	})
 
+	if kubeConfig(m).PrivateNodes && !kubeConfig(m).ExistingVPC {
+		for _, zone := range zones {
+			zone := zone
+
+			provisioner.AddStep("creating Route for Kubernetes master in private Route Table in "+zone.Name, func() error {
+				input := &ec2.CreateRouteInput{
+					DestinationCidrBlock: aws.String("10.246.0.0/24"),
+					RouteTableId:         aws.String(zone.PrivateRouteTableID),
+					InstanceId:           aws.String(kubeConfig(m).MasterID),
+				}
+				if _, err := ec2S.CreateRoute(input); err != nil && !strings.Contains(err.Error(), "RouteAlreadyExists") {
+					return err
+				}
+				return nil
+			})
+		}
+	}
+
+	// Bastion Instance
+
+	if kubeConfig(m).BastionNodeSize != "" {
+		provisioner.AddStep("creating bastion", func() error {
+			if kubeConfig(m).BastionID != "" {
+				return nil
+			}
+			input := &ec2.RunInstancesInput{
+				MinCount:     aws.Int64(1),
+				MaxCount:     aws.Int64(1),
+				ImageId:      aws.String(AWSMasterAMIs[kubeConfig(m).Region]),
+				InstanceType: aws.String(kubeConfig(m).BastionNodeSize),
+				KeyName:      aws.String(m.Name + "-key"),
+				NetworkInterfaces: []*ec2.InstanceNetworkInterfaceSpecification{
+					{
+						DeviceIndex:              aws.Int64(0),
+						AssociatePublicIpAddress: aws.Bool(true),
+						DeleteOnTermination:      aws.Bool(true),
+						Groups: []*string{
+							aws.String(kubeConfig(m).NodeSecurityGroupID),
+						},
+						SubnetId: aws.String(zones[0].PublicSubnetID),
+					},
+				},
+			}
+			resp, err := ec2S.RunInstances(input)
+			if err != nil {
+				return err
+			}
+			kubeConfig(m).BastionID = *resp.Instances[0].InstanceId
+			return nil
+		})
+
+		provisioner.AddStep("tagging bastion", func() error {
+			return tagAWSResource(ec2S, kubeConfig(m).BastionID, map[string]string{
+				"KubernetesCluster": m.Name,
+				"Name":              m.Name + "-bastion",
+				"Role":              m.Name + "-bastion",
+			})
+		})
+
+		provisioner.AddStep("waiting for bastion to launch", func() error {
+			input := &ec2.DescribeInstancesInput{
+				InstanceIds: []*string{
+					aws.String(kubeConfig(m).BastionID),
+				},
+			}
+			return action.CancellableWaitFor("bastion launch", 5*time.Minute, 3*time.Second, func() (bool, error) {
+				resp, err := ec2S.DescribeInstances(input)
+				if err != nil {
+					return false, err
+				}
+				instance := resp.Reservations[0].Instances[0]
+				if ip := instance.PublicIpAddress; ip != nil {
+					kubeConfig(m).BastionPublicIP = *ip
+				}
+				return *instance.State.Name == "running" && kubeConfig(m).BastionPublicIP != "", nil
+			})
+		})
+	}
+
 	// Create first minion
 
-	provisioner.addStep("creating Kubernetes minion", func() error {
+	provisioner.AddStep("creating Kubernetes minion", func() error {
 		node := &model.Node{
 			KubeID: m.ID,
 			Size:   m.NodeSizes[0],
@@ -634,7 +968,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		return p.Core.Nodes.Create(node)
 	})
 
-	provisioner.addStep("waiting for Kubernetes", func() error {
+	provisioner.AddStep("waiting for Kubernetes", func() error {
 		return action.CancellableWaitFor("Kubernetes API and first minion", 20*time.Minute, time.Second, func() (bool, error) {
 			nodes, err := p.Core.K8S(m).Nodes().List()
 			if err != nil {
@@ -644,7 +978,7 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 		})
 	})
 
-	if err := provisioner.run(); err != nil {
+	if err := provisioner.Run(); err != nil {
 		return err
 	}
 
@@ -652,17 +986,18 @@ func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
 }
 
 func (p *Provider) DeleteKube(m *model.Kube) error {
-	ec2S := p.ec2(m.AWSConfig.Region)
-	provisioner := &provisioner{core: p.Core, kube: m}
+	ec2S := p.ec2(kubeConfig(m).Region)
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m}
+	zones := availabilityZones(kubeConfig(m))
 
-	provisioner.addStep("deleting master", func() error {
-		if m.AWSConfig.MasterID == "" {
+	provisioner.AddStep("deleting master", func() error {
+		if kubeConfig(m).MasterID == "" {
 			return nil
 		}
 
 		input := &ec2.TerminateInstancesInput{
 			InstanceIds: []*string{
-				aws.String(m.AWSConfig.MasterID),
+				aws.String(kubeConfig(m).MasterID),
 			},
 		}
 		if _, err := ec2S.TerminateInstances(input); isErrAndNotAWSNotFound(err) {
@@ -672,7 +1007,7 @@ func (p *Provider) DeleteKube(m *model.Kube) error {
 		// Wait for termination
 		descinput := &ec2.DescribeInstancesInput{
 			InstanceIds: []*string{
-				aws.String(m.AWSConfig.MasterID),
+				aws.String(kubeConfig(m).MasterID),
 			},
 		}
 		waitErr := util.WaitFor("Kubernetes master termination", 5*time.Minute, 3*time.Second, func() (bool, error) { // TODO --------- use server() method
@@ -691,31 +1026,148 @@ func (p *Provider) DeleteKube(m *model.Kube) error {
 			return waitErr
 		}
 
-		m.AWSConfig.MasterID = ""
+		kubeConfig(m).MasterID = ""
 		return nil
 	})
 
-	provisioner.addStep("disassociating Route Table from Subnet", func() error {
-		if m.AWSConfig.RouteTableSubnetAssociationID == "" {
+	provisioner.AddStep("deleting bastion", func() error {
+		if kubeConfig(m).BastionID == "" {
 			return nil
 		}
-		input := &ec2.DisassociateRouteTableInput{
-			AssociationId: aws.String(m.AWSConfig.RouteTableSubnetAssociationID),
+		input := &ec2.TerminateInstancesInput{
+			InstanceIds: []*string{
+				aws.String(kubeConfig(m).BastionID),
+			},
 		}
-		if _, err := ec2S.DisassociateRouteTable(input); isErrAndNotAWSNotFound(err) {
+		if _, err := ec2S.TerminateInstances(input); isErrAndNotAWSNotFound(err) {
 			return err
 		}
-		m.AWSConfig.RouteTableSubnetAssociationID = ""
+
+		// The Node Security Group can't be deleted until the bastion is gone.
+		descinput := &ec2.DescribeInstancesInput{
+			InstanceIds: []*string{
+				aws.String(kubeConfig(m).BastionID),
+			},
+		}
+		waitErr := util.WaitFor("bastion termination", 5*time.Minute, 3*time.Second, func() (bool, error) {
+			resp, err := ec2S.DescribeInstances(descinput)
+			if err != nil {
+				return false, err
+			}
+			if len(resp.Reservations) == 0 || len(resp.Reservations[0].Instances) == 0 {
+				return true, nil
+			}
+			instance := resp.Reservations[0].Instances[0]
+			return *instance.State.Name == "terminated", nil
+		})
+		if waitErr != nil {
+			return waitErr
+		}
+
+		kubeConfig(m).BastionID = ""
+		kubeConfig(m).BastionPublicIP = ""
 		return nil
 	})
 
-	provisioner.addStep("deleting Internet Gateway", func() error {
-		if m.AWSConfig.InternetGatewayID == "" {
+	for _, zone := range zones {
+		zone := zone
+
+		// NOTE NAT Gateways are deleted before the Internet Gateway, which can't
+		// be detached while their Elastic IPs are mapped.
+
+		provisioner.AddStep("deleting NAT Gateway in "+zone.Name, func() error {
+			if zone.NATGatewayID == "" {
+				return nil
+			}
+			input := &ec2.DeleteNatGatewayInput{
+				NatGatewayId: aws.String(zone.NATGatewayID),
+			}
+			if _, err := ec2S.DeleteNatGateway(input); isErrAndNotAWSNotFound(err) {
+				return err
+			}
+			descinput := &ec2.DescribeNatGatewaysInput{
+				NatGatewayIds: []*string{
+					aws.String(zone.NATGatewayID),
+				},
+			}
+			waitErr := util.WaitFor("NAT Gateway deletion", 10*time.Minute, 5*time.Second, func() (bool, error) {
+				resp, err := ec2S.DescribeNatGateways(descinput)
+				if isErrAndNotAWSNotFound(err) {
+					return false, err
+				}
+				return err != nil || len(resp.NatGateways) == 0 || *resp.NatGateways[0].State == "deleted", nil
+			})
+			if waitErr != nil {
+				return waitErr
+			}
+			zone.NATGatewayID = ""
+			return nil
+		})
+
+		provisioner.AddStep("releasing NAT Elastic IP in "+zone.Name, func() error {
+			if zone.NATElasticIPAllocationID == "" {
+				return nil
+			}
+			input := &ec2.ReleaseAddressInput{
+				AllocationId: aws.String(zone.NATElasticIPAllocationID),
+			}
+			if _, err := ec2S.ReleaseAddress(input); isErrAndNotAWSNotFound(err) {
+				return err
+			}
+			zone.NATElasticIPAllocationID = ""
+			return nil
+		})
+
+		provisioner.AddStep("disassociating private Route Table from Subnet in "+zone.Name, func() error {
+			if zone.PrivateRouteTableSubnetAssociationID == "" {
+				return nil
+			}
+			input := &ec2.DisassociateRouteTableInput{
+				AssociationId: aws.String(zone.PrivateRouteTableSubnetAssociationID),
+			}
+			if _, err := ec2S.DisassociateRouteTable(input); isErrAndNotAWSNotFound(err) {
+				return err
+			}
+			zone.PrivateRouteTableSubnetAssociationID = ""
+			return nil
+		})
+
+		provisioner.AddStep("deleting private Route Table in "+zone.Name, func() error {
+			if zone.PrivateRouteTableID == "" {
+				return nil
+			}
+			input := &ec2.DeleteRouteTableInput{
+				RouteTableId: aws.String(zone.PrivateRouteTableID),
+			}
+			if _, err := ec2S.DeleteRouteTable(input); isErrAndNotAWSNotFound(err) {
+				return err
+			}
+			zone.PrivateRouteTableID = ""
+			return nil
+		})
+
+		provisioner.AddStep("disassociating Route Table from Subnet in "+zone.Name, func() error {
+			if zone.RouteTableSubnetAssociationID == "" {
+				return nil
+			}
+			input := &ec2.DisassociateRouteTableInput{
+				AssociationId: aws.String(zone.RouteTableSubnetAssociationID),
+			}
+			if _, err := ec2S.DisassociateRouteTable(input); isErrAndNotAWSNotFound(err) {
+				return err
+			}
+			zone.RouteTableSubnetAssociationID = ""
+			return nil
+		})
+	}
+
+	provisioner.AddStep("deleting Internet Gateway", func() error {
+		if kubeConfig(m).InternetGatewayID == "" {
 			return nil
 		}
 		diginput := &ec2.DetachInternetGatewayInput{
-			InternetGatewayId: aws.String(m.AWSConfig.InternetGatewayID),
-			VpcId:             aws.String(m.AWSConfig.VPCID),
+			InternetGatewayId: aws.String(kubeConfig(m).InternetGatewayID),
+			VpcId:             aws.String(kubeConfig(m).VPCID),
 		}
 
 		// NOTE we do this (maybe we should just describe, not spam detach) because
@@ -734,94 +1186,135 @@ func (p *Provider) DeleteKube(m *model.Kube) error {
 		}
 
 		input := &ec2.DeleteInternetGatewayInput{
-			InternetGatewayId: aws.String(m.AWSConfig.InternetGatewayID),
+			InternetGatewayId: aws.String(kubeConfig(m).InternetGatewayID),
 		}
 		if _, err := ec2S.DeleteInternetGateway(input); isErrAndNotAWSNotFound(err) {
 			return err
 		}
-		m.AWSConfig.InternetGatewayID = ""
+		kubeConfig(m).InternetGatewayID = ""
 		return nil
 	})
 
-	provisioner.addStep("deleting Route Table", func() error {
-		if m.AWSConfig.RouteTableID == "" {
+	provisioner.AddStep("deleting Route for Kubernetes master", func() error {
+		// The Route Table itself is of the VPC, below, unless that existed.
+		if !kubeConfig(m).ExistingVPC || kubeConfig(m).RouteTableID == "" {
 			return nil
 		}
-		input := &ec2.DeleteRouteTableInput{
-			RouteTableId: aws.String(m.AWSConfig.RouteTableID),
+		input := &ec2.DeleteRouteInput{
+			DestinationCidrBlock: aws.String("10.246.0.0/24"),
+			RouteTableId:         aws.String(kubeConfig(m).RouteTableID),
 		}
-		if _, err := ec2S.DeleteRouteTable(input); isErrAndNotAWSNotFound(err) {
+		if _, err := ec2S.DeleteRoute(input); isErrAndNotAWSNotFound(err) {
 			return err
 		}
-		m.AWSConfig.RouteTableID = ""
 		return nil
 	})
 
-	provisioner.addStep("deleting public Subnet", func() error {
-		if m.AWSConfig.PublicSubnetID == "" {
+	provisioner.AddStep("deleting Route Table", func() error {
+		if kubeConfig(m).ExistingVPC || kubeConfig(m).RouteTableID == "" {
 			return nil
 		}
-		input := &ec2.DeleteSubnetInput{
-			SubnetId: aws.String(m.AWSConfig.PublicSubnetID),
+		input := &ec2.DeleteRouteTableInput{
+			RouteTableId: aws.String(kubeConfig(m).RouteTableID),
+		}
+		if _, err := ec2S.DeleteRouteTable(input); isErrAndNotAWSNotFound(err) {
+			return err
 		}
+		kubeConfig(m).RouteTableID = ""
+		return nil
+	})
 
-		waitErr := util.WaitFor("Public Subnet to delete", 2*time.Minute, 5*time.Second, func() (bool, error) {
-			if _, err := ec2S.DeleteSubnet(input); isErrAndNotAWSNotFound(err) {
-				return false, nil
+	for _, zone := range zones {
+		zone := zone
+
+		provisioner.AddStep("deleting public Subnet in "+zone.Name, func() error {
+			if kubeConfig(m).ExistingVPC || zone.PublicSubnetID == "" {
+				return nil
 			}
-			return true, nil
+			input := &ec2.DeleteSubnetInput{
+				SubnetId: aws.String(zone.PublicSubnetID),
+			}
+
+			waitErr := util.WaitFor("Public Subnet to delete", 2*time.Minute, 5*time.Second, func() (bool, error) {
+				if _, err := ec2S.DeleteSubnet(input); isErrAndNotAWSNotFound(err) {
+					return false, nil
+				}
+				return true, nil
+			})
+			if waitErr != nil {
+				return waitErr
+			}
+
+			zone.PublicSubnetID = ""
+			return nil
 		})
-		if waitErr != nil {
-			return waitErr
-		}
 
-		m.AWSConfig.PublicSubnetID = ""
-		return nil
-	})
+		provisioner.AddStep("deleting private Subnet in "+zone.Name, func() error {
+			if kubeConfig(m).ExistingVPC || zone.PrivateSubnetID == "" {
+				return nil
+			}
+			input := &ec2.DeleteSubnetInput{
+				SubnetId: aws.String(zone.PrivateSubnetID),
+			}
+
+			waitErr := util.WaitFor("Private Subnet to delete", 2*time.Minute, 5*time.Second, func() (bool, error) {
+				if _, err := ec2S.DeleteSubnet(input); isErrAndNotAWSNotFound(err) {
+					return false, nil
+				}
+				return true, nil
+			})
+			if waitErr != nil {
+				return waitErr
+			}
+
+			zone.PrivateSubnetID = ""
+			return nil
+		})
+	}
 
-	provisioner.addStep("deleting Node Security Group", func() error {
-		if m.AWSConfig.NodeSecurityGroupID == "" {
+	provisioner.AddStep("deleting Node Security Group", func() error {
+		if kubeConfig(m).ExistingSecurityGroups || kubeConfig(m).NodeSecurityGroupID == "" {
 			return nil
 		}
 		input := &ec2.DeleteSecurityGroupInput{
-			GroupId: aws.String(m.AWSConfig.NodeSecurityGroupID),
+			GroupId: aws.String(kubeConfig(m).NodeSecurityGroupID),
 		}
 		if _, err := ec2S.DeleteSecurityGroup(input); isErrAndNotAWSNotFound(err) {
 			return err
 		}
-		m.AWSConfig.NodeSecurityGroupID = ""
+		kubeConfig(m).NodeSecurityGroupID = ""
 		return nil
 	})
 
-	provisioner.addStep("deleting ELB Security Group", func() error {
-		if m.AWSConfig.ELBSecurityGroupID == "" {
+	provisioner.AddStep("deleting ELB Security Group", func() error {
+		if kubeConfig(m).ExistingSecurityGroups || kubeConfig(m).ELBSecurityGroupID == "" {
 			return nil
 		}
 		input := &ec2.DeleteSecurityGroupInput{
-			GroupId: aws.String(m.AWSConfig.ELBSecurityGroupID),
+			GroupId: aws.String(kubeConfig(m).ELBSecurityGroupID),
 		}
 		if _, err := ec2S.DeleteSecurityGroup(input); isErrAndNotAWSNotFound(err) {
 			return err
 		}
-		m.AWSConfig.ELBSecurityGroupID = ""
+		kubeConfig(m).ELBSecurityGroupID = ""
 		return nil
 	})
 
-	provisioner.addStep("deleting VPC", func() error {
-		if m.AWSConfig.VPCID == "" {
+	provisioner.AddStep("deleting VPC", func() error {
+		if kubeConfig(m).ExistingVPC || kubeConfig(m).VPCID == "" {
 			return nil
 		}
 		input := &ec2.DeleteVpcInput{
-			VpcId: aws.String(m.AWSConfig.VPCID),
+			VpcId: aws.String(kubeConfig(m).VPCID),
 		}
 		if _, err := ec2S.DeleteVpc(input); isErrAndNotAWSNotFound(err) {
 			return err
 		}
-		m.AWSConfig.VPCID = ""
+		kubeConfig(m).VPCID = ""
 		return nil
 	})
 
-	provisioner.addStep("deleting SSH Key Pair", func() error {
+	provisioner.AddStep("deleting SSH Key Pair", func() error {
 		input := &ec2.DeleteKeyPairInput{
 			KeyName: aws.String(m.Name + "-key"),
 		}
@@ -831,15 +1324,20 @@ func (p *Provider) DeleteKube(m *model.Kube) error {
 		return nil
 	})
 
-	return provisioner.run()
+	return provisioner.Run()
 }
 
 func (p *Provider) CreateNode(m *model.Node, action *core.Action) error {
-	server, err := p.createServer(m)
+	zone, err := availabilityZone(kubeConfig(m.Kube), m.AvailabilityZone)
+	if err != nil {
+		return err
+	}
+	server, err := p.createServer(m, zone)
 	if err != nil {
 		return err
 	}
 	p.setAttrsFromServer(m, server)
+	m.AvailabilityZone = zone.Name
 	if err := p.Core.DB.Save(m); err != nil {
 		return err
 	}
@@ -855,23 +1353,79 @@ func (p *Provider) DeleteNode(m *model.Node) error {
 	return p.deleteServer(m)
 }
 
+// SpotInterrupted implements core.SpotProvider. Spot servers are terminated
+// when interrupted, with the state reason Server.SpotInstanceTermination.
+// Servers terminated otherwise (ex. by hand), or no longer described at all,
+// aren't taken to be interrupted.
+func (p *Provider) SpotInterrupted(m *model.Node) (bool, error) {
+	input := &ec2.DescribeInstancesInput{
+		InstanceIds: []*string{aws.String(m.ProviderID)},
+	}
+	resp, err := p.ec2(kubeConfig(m.Kube).Region).DescribeInstances(input)
+	if err != nil {
+		if isErrAndNotAWSNotFound(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			state := aws.StringValue(instance.State.Name)
+			if state != ec2.InstanceStateNameShuttingDown && state != ec2.InstanceStateNameTerminated {
+				continue
+			}
+			if instance.StateReason != nil && aws.StringValue(instance.StateReason.Code) == spotInstanceTerminationCode {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// CreateVolume creates the EBS volume of a Volume, from the EBS snapshot of its
+// Snapshot if it is restored from one. A Volume whose Snapshot has been deleted
+// isn't created empty in its place.
 func (p *Provider) CreateVolume(m *model.Volume, action *core.Action) error {
-	return p.createVolume(m, nil)
+	if m.SnapshotID != nil && m.Snapshot == nil {
+		return &core.FatalError{Err: fmt.Errorf("Snapshot %d of Volume %s no longer exists", *m.SnapshotID, m.Name)}
+	}
+	if m.Snapshot == nil {
+		return p.createVolume(m, nil)
+	}
+	// The filesystem of a volume larger than its snapshot has to be grown.
+	m.GrowFilesystem = m.Size > m.Snapshot.Size
+	return p.createVolume(m, aws.String(m.Snapshot.ProviderID))
 }
 
+// ResizeVolume modifies the EBS volume in place, even while it is attached,
+// and marks its filesystem to be grown when the pod of its Instance starts.
+// Volumes that can't be modified, such as those modified in the last 6 hours,
+// are replaced by one created from a snapshot instead. A retry of a replacement
+// resumes from the snapshot it took.
 func (p *Provider) ResizeVolume(m *model.Volume, action *core.Action) error {
-	snapshot, err := p.createSnapshot(m)
+	if m.ResizeSnapshotID != "" {
+		return p.replaceVolume(m)
+	}
+
+	awsVol, err := p.describeVolume(m)
 	if err != nil {
 		return err
 	}
-	if err := p.deleteVolume(m); err != nil {
-		return err
+	oldSize := int(*awsVol.Size)
+	if m.Size < oldSize {
+		return &core.FatalError{Err: fmt.Errorf("EBS volume of %s can't shrink from %d to %d GiB", m.Name, oldSize, m.Size)}
 	}
-	if err := p.createVolume(m, snapshot.SnapshotId); err != nil {
+
+	modified, err := p.modifyVolume(m, action)
+	if err != nil {
 		return err
 	}
-	if err := p.deleteSnapshot(m, snapshot); err != nil {
-		p.Core.Log.Errorf("Error deleting snapshot %s: %s", *snapshot.SnapshotId, err.Error())
+	if !modified {
+		return p.replaceVolume(m)
+	}
+
+	if m.Size > oldSize {
+		return p.Core.DB.Model(m).Update("grow_filesystem", true).Error
 	}
 	return nil
 }
@@ -884,6 +1438,91 @@ func (p *Provider) DeleteVolume(m *model.Volume) error {
 	return p.deleteVolume(m)
 }
 
+// KubeVolume is the EBS volume, which Kubernetes attaches to the Node of the
+// pod.
+func (p *Provider) KubeVolume(m *model.Volume) (*guber.Volume, error) {
+	return &guber.Volume{
+		AwsElasticBlockStore: &guber.AwsElasticBlockStore{
+			VolumeID: m.ProviderID,
+			FSType:   "ext4",
+		},
+	}, nil
+}
+
+// CreateSnapshot snapshots the EBS volume of a Snapshot's Volume, and waits
+// until the snapshot is completed.
+func (p *Provider) CreateSnapshot(m *model.Snapshot, action *core.Action) error {
+	if m.Volume == nil || m.Volume.ProviderID == "" {
+		return &core.FatalError{Err: fmt.Errorf("Volume of Snapshot %d has no EBS volume", *m.ID)}
+	}
+	ec2S := p.ec2(kubeConfig(m.Kube).Region)
+
+	if m.ProviderID == "" {
+		// A retry finds the EBS snapshot an earlier attempt created by its tag.
+		awsSnap, err := p.findSnapshot(m)
+		if err != nil {
+			return err
+		}
+		if awsSnap == nil {
+			input := &ec2.CreateSnapshotInput{
+				Description: aws.String(fmt.Sprintf("Supergiant Snapshot of %s (%s)", m.VolumeName, m.UUID)),
+				VolumeId:    aws.String(m.Volume.ProviderID),
+				TagSpecifications: []*ec2.TagSpecification{
+					tagSpecification(ec2.ResourceTypeSnapshot, map[string]string{
+						"KubernetesCluster": m.Kube.Name,
+						"Name":              m.VolumeName,
+						"SupergiantUUID":    m.UUID,
+					}),
+				},
+			}
+			if awsSnap, err = ec2S.CreateSnapshot(input); err != nil {
+				return err
+			}
+		}
+		m.ProviderID = *awsSnap.SnapshotId
+		if err := p.Core.DB.Save(m); err != nil {
+			return err
+		}
+	}
+
+	input := &ec2.DescribeSnapshotsInput{
+		SnapshotIds: []*string{aws.String(m.ProviderID)},
+	}
+	err := action.CancellableWaitFor("EBS snapshot "+m.ProviderID+" to complete", 4*time.Hour, 10*time.Second, func() (bool, error) {
+		resp, err := ec2S.DescribeSnapshots(input)
+		if err != nil {
+			return false, err
+		}
+		if len(resp.Snapshots) == 0 {
+			return false, fmt.Errorf("EBS snapshot %s not found", m.ProviderID)
+		}
+		switch *resp.Snapshots[0].State {
+		case ec2.SnapshotStateCompleted:
+			return true, nil
+		case ec2.SnapshotStateError:
+			return false, &core.FatalError{Err: fmt.Errorf("EBS snapshot %s failed", m.ProviderID)}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	return p.Core.DB.Model(m).Update("ready", true).Error
+}
+
+func (p *Provider) DeleteSnapshot(m *model.Snapshot) error {
+	if m.ProviderID == "" {
+		return nil
+	}
+	input := &ec2.DeleteSnapshotInput{
+		SnapshotId: aws.String(m.ProviderID),
+	}
+	if _, err := p.ec2(kubeConfig(m.Kube).Region).DeleteSnapshot(input); isErrAndNotAWSNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 func (p *Provider) CreateEntrypoint(m *model.Entrypoint, action *core.Action) error {
 	return p.createELB(m)
 }
@@ -899,7 +1538,7 @@ func (p *Provider) AddPortToEntrypoint(m *model.Entrypoint, lbPort int64, nodePo
 			},
 		},
 	}
-	_, err := p.elb(m.Kube.AWSConfig.Region).CreateLoadBalancerListeners(input)
+	_, err := p.elb(kubeConfig(m.Kube).Region).CreateLoadBalancerListeners(input)
 	return err
 }
 
@@ -910,7 +1549,7 @@ func (p *Provider) RemovePortFromEntrypoint(m *model.Entrypoint, lbPort int64) e
 			aws.Int64(lbPort),
 		},
 	}
-	_, err := p.elb(m.Kube.AWSConfig.Region).DeleteLoadBalancerListeners(params)
+	_, err := p.elb(kubeConfig(m.Kube).Region).DeleteLoadBalancerListeners(params)
 	if isErrAndNotAWSNotFound(err) {
 		return err
 	}
@@ -928,7 +1567,11 @@ func (p *Provider) DeleteEntrypoint(m *model.Entrypoint) error {
 func (p *Provider) awsConfig(region string) *aws.Config {
 	creds := credentials.NewStaticCredentials(p.Credentials["access_key"], p.Credentials["secret_key"], "")
 	creds.Get()
-	return aws.NewConfig().WithRegion(region).WithCredentials(creds)
+	config := aws.NewConfig().WithRegion(region).WithCredentials(creds)
+	if Endpoint != "" {
+		config = config.WithEndpoint(Endpoint)
+	}
+	return config
 }
 
 func (p *Provider) ec2(region string) *ec2.EC2 {
@@ -981,7 +1624,7 @@ func (p *Provider) filteredServers(m *model.Kube, filters map[string][]string) (
 	input := &ec2.DescribeInstancesInput{
 		Filters: ec2Filters,
 	}
-	resp, err := p.ec2(m.AWSConfig.Region).DescribeInstances(input)
+	resp, err := p.ec2(kubeConfig(m).Region).DescribeInstances(input)
 	if err != nil {
 		return nil, err
 	}
@@ -998,12 +1641,13 @@ func (p *Provider) setAttrsFromServer(m *model.Node, server *ec2.Instance) {
 	m.Name = *server.PrivateDnsName
 	m.Size = *server.InstanceType
 	m.ProviderCreationTimestamp = *server.LaunchTime
+	m.Spot = aws.StringValue(server.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot
 }
 
-func (p *Provider) createServer(m *model.Node) (*ec2.Instance, error) {
+func (p *Provider) createServer(m *model.Node, zone *model.AWSAvailabilityZone) (*ec2.Instance, error) {
 
 	// TODO move to init outside of func
-	userdataTemplate, err := ioutil.ReadFile("config/minion_userdata.txt")
+	userdataTemplate, err := ioutil.ReadFile(filepath.Join(UserdataDir, "minion_userdata.txt"))
 	if err != nil {
 		return nil, err
 	}
@@ -1017,15 +1661,42 @@ func (p *Provider) createServer(m *model.Node) (*ec2.Instance, error) {
 	}
 	encodedUserdata := base64.StdEncoding.EncodeToString(userdata.Bytes())
 
+	// A retry finds the server an earlier attempt created by its token, which
+	// AWS also uses to create it only once. Spot Nodes which fall back to
+	// on-demand are created with a token of their own, as AWS refuses a token
+	// given again with other parameters.
+	token := clientToken(m.UUID)
+	onDemandToken := clientToken(m.UUID, "on-demand")
+	servers, err := p.filteredServers(m.Kube, map[string][]string{
+		"client-token": []string{token, onDemandToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) > 0 {
+		return servers[0], nil
+	}
+
 	input := &ec2.RunInstancesInput{
+		ClientToken:  aws.String(token),
 		MinCount:     aws.Int64(1),
 		MaxCount:     aws.Int64(1),
 		InstanceType: aws.String(m.Size),
-		ImageId:      aws.String(AWSMasterAMIs[m.Kube.AWSConfig.Region]),
+		ImageId:      aws.String(AWSMasterAMIs[kubeConfig(m.Kube).Region]),
 		EbsOptimized: aws.Bool(true),
 		KeyName:      aws.String(m.Kube.Name + "-key"),
-		SecurityGroupIds: []*string{
-			aws.String(m.Kube.AWSConfig.NodeSecurityGroupID),
+		// NOTE the public IP is asked for, rather than left to the Subnet, as
+		// the Subnets of an existing VPC may not assign them.
+		NetworkInterfaces: []*ec2.InstanceNetworkInterfaceSpecification{
+			{
+				DeviceIndex:              aws.Int64(0),
+				AssociatePublicIpAddress: aws.Bool(!kubeConfig(m.Kube).PrivateNodes),
+				DeleteOnTermination:      aws.Bool(true),
+				Groups: []*string{
+					aws.String(kubeConfig(m.Kube).NodeSecurityGroupID),
+				},
+				SubnetId: aws.String(nodeSubnetID(kubeConfig(m.Kube), zone)),
+			},
 		},
 		IamInstanceProfile: &ec2.IamInstanceProfileSpecification{
 			Name: aws.String("kubernetes-minion"),
@@ -1050,29 +1721,47 @@ func (p *Provider) createServer(m *model.Node) (*ec2.Instance, error) {
 			},
 		},
 		UserData: aws.String(encodedUserdata),
-		SubnetId: aws.String(m.Kube.AWSConfig.PublicSubnetID),
+		TagSpecifications: []*ec2.TagSpecification{
+			tagSpecification(ec2.ResourceTypeInstance, map[string]string{
+				"KubernetesCluster": m.Kube.Name,
+				"Name":              m.Kube.Name + "-minion",
+				"Role":              m.Kube.Name + "-minion",
+				"SupergiantUUID":    m.UUID,
+			}),
+		},
 	}
 
-	ec2S := p.ec2(m.Kube.AWSConfig.Region)
+	// Nodes of the sizes of the Kube's SpotPolicy are asked for as spot
+	// servers, falling back to on-demand ones when there is no spot capacity.
+	if policy := m.Kube.SpotPolicy; policy.Spot(m.Size) {
+		input.InstanceMarketOptions = &ec2.InstanceMarketOptionsRequest{
+			MarketType: aws.String(ec2.MarketTypeSpot),
+			SpotOptions: &ec2.SpotMarketOptions{
+				SpotInstanceType:             aws.String(ec2.SpotInstanceTypeOneTime),
+				InstanceInterruptionBehavior: aws.String(ec2.InstanceInterruptionBehaviorTerminate),
+			},
+		}
+		if policy.MaxPrice != "" {
+			input.InstanceMarketOptions.SpotOptions.MaxPrice = aws.String(policy.MaxPrice)
+		}
 
-	resp, err := ec2S.RunInstances(input)
-	if err != nil {
-		return nil, err
+		resp, err := p.ec2(kubeConfig(m.Kube).Region).RunInstances(input)
+		if err == nil {
+			return resp.Instances[0], nil
+		}
+		if !policy.OnDemandFallback || !isErrNoSpotCapacity(err) {
+			return nil, err
+		}
+		p.Core.Log.Warnf("Creating %s Node on-demand, as there is no spot capacity: %s", m.Size, err)
+		input.InstanceMarketOptions = nil
+		input.ClientToken = aws.String(onDemandToken)
 	}
 
-	server := resp.Instances[0]
-
-	err = tagAWSResource(ec2S, *server.InstanceId, map[string]string{
-		"KubernetesCluster": m.Kube.Name,
-		"Name":              m.Kube.Name + "-minion",
-		"Role":              m.Kube.Name + "-minion",
-	})
+	resp, err := p.ec2(kubeConfig(m.Kube).Region).RunInstances(input)
 	if err != nil {
-		// TODO
-		p.Core.Log.Error("Failed to tag EC2 Instance " + *server.InstanceId)
+		return nil, err
 	}
-
-	return server, nil
+	return resp.Instances[0], nil
 }
 
 func (p *Provider) deleteServer(m *model.Node) error {
@@ -1086,7 +1775,7 @@ func (p *Provider) deleteServer(m *model.Node) error {
 	input := &ec2.TerminateInstancesInput{
 		InstanceIds: []*string{aws.String(m.ProviderID)},
 	}
-	_, err := p.ec2(m.Kube.AWSConfig.Region).TerminateInstances(input)
+	_, err := p.ec2(kubeConfig(m.Kube).Region).TerminateInstances(input)
 	if isErrAndNotAWSNotFound(err) {
 		return err
 	}
@@ -1094,6 +1783,11 @@ func (p *Provider) deleteServer(m *model.Node) error {
 }
 
 func (p *Provider) createELB(m *model.Entrypoint) error {
+	// The ELB spans the Subnets of all the Kube's zones.
+	var subnets []*string
+	for _, zone := range availabilityZones(kubeConfig(m.Kube)) {
+		subnets = append(subnets, aws.String(zone.PublicSubnetID))
+	}
 	params := &elb.CreateLoadBalancerInput{
 		Listeners: []*elb.Listener{ // NOTE we must provide at least 1 listener, it is currently arbitrary
 			{
@@ -1105,13 +1799,11 @@ func (p *Provider) createELB(m *model.Entrypoint) error {
 		LoadBalancerName: aws.String(m.ProviderID),
 		Scheme:           aws.String("internet-facing"),
 		SecurityGroups: []*string{
-			aws.String(m.Kube.AWSConfig.ELBSecurityGroupID),
-		},
-		Subnets: []*string{
-			aws.String(m.Kube.AWSConfig.PublicSubnetID),
+			aws.String(kubeConfig(m.Kube).ELBSecurityGroupID),
 		},
+		Subnets: subnets,
 	}
-	resp, err := p.elb(m.Kube.AWSConfig.Region).CreateLoadBalancer(params)
+	resp, err := p.elb(kubeConfig(m.Kube).Region).CreateLoadBalancer(params)
 	if err != nil {
 		return err
 	}
@@ -1137,7 +1829,21 @@ func (p *Provider) createELB(m *model.Entrypoint) error {
 			Timeout:            aws.Int64(5),
 		},
 	}
-	_, err = p.elb(m.Kube.AWSConfig.Region).ConfigureHealthCheck(healthParams)
+	if _, err = p.elb(kubeConfig(m.Kube).Region).ConfigureHealthCheck(healthParams); err != nil {
+		return err
+	}
+
+	// Balance across the Nodes of all zones, rather than evenly across zones
+	// which may have different numbers of Nodes.
+	attrsParams := &elb.ModifyLoadBalancerAttributesInput{
+		LoadBalancerName: aws.String(m.ProviderID),
+		LoadBalancerAttributes: &elb.LoadBalancerAttributes{
+			CrossZoneLoadBalancing: &elb.CrossZoneLoadBalancing{
+				Enabled: aws.Bool(true),
+			},
+		},
+	}
+	_, err = p.elb(kubeConfig(m.Kube).Region).ModifyLoadBalancerAttributes(attrsParams)
 	return err
 }
 
@@ -1152,7 +1858,7 @@ func (p *Provider) registerNodes(m *model.Entrypoint, nodes ...*model.Node) erro
 		LoadBalancerName: aws.String(m.ProviderID),
 		Instances:        elbInstances,
 	}
-	_, err := p.elb(m.Kube.AWSConfig.Region).RegisterInstancesWithLoadBalancer(input)
+	_, err := p.elb(kubeConfig(m.Kube).Region).RegisterInstancesWithLoadBalancer(input)
 	return err
 }
 
@@ -1161,7 +1867,7 @@ func (p *Provider) deleteELB(m *model.Entrypoint) error {
 	params := &elb.DeleteLoadBalancerInput{
 		LoadBalancerName: aws.String(m.ProviderID),
 	}
-	_, err := p.elb(m.Kube.AWSConfig.Region).DeleteLoadBalancer(params)
+	_, err := p.elb(kubeConfig(m.Kube).Region).DeleteLoadBalancer(params)
 	if isErrAndNotAWSNotFound(err) {
 		return err
 	}
@@ -1169,36 +1875,235 @@ func (p *Provider) deleteELB(m *model.Entrypoint) error {
 }
 
 func (p *Provider) createVolume(volume *model.Volume, snapshotID *string) error {
-	volInput := &ec2.CreateVolumeInput{
-		AvailabilityZone: aws.String(volume.Kube.AWSConfig.AvailabilityZone),
-		VolumeType:       aws.String(volume.Type),
-		Size:             aws.Int64(int64(volume.Size)),
-		SnapshotId:       snapshotID,
+	zone, err := availabilityZone(kubeConfig(volume.Kube), volume.AvailabilityZone)
+	if err != nil {
+		return err
+	}
+	// The token differs for each snapshot a Volume is restored from, as a
+	// resize replaces the Volume's EBS volume with one from a snapshot.
+	token := clientToken(volume.UUID)
+	if snapshotID != nil {
+		token = clientToken(volume.UUID, *snapshotID)
 	}
-	awsVol, err := p.ec2(volume.Kube.AWSConfig.Region).CreateVolume(volInput)
+
+	// A retry finds the EBS volume an earlier attempt created by its tag.
+	awsVol, err := p.findVolume(volume, snapshotID)
 	if err != nil {
 		return err
 	}
+	if awsVol == nil {
+		volInput := &ec2.CreateVolumeInput{
+			ClientToken:      aws.String(token),
+			AvailabilityZone: aws.String(zone.Name),
+			VolumeType:       aws.String(volume.Type),
+			Size:             aws.Int64(int64(volume.Size)),
+			Iops:             positiveInt64(volume.IOPS),
+			Throughput:       positiveInt64(volume.Throughput),
+			SnapshotId:       snapshotID,
+			TagSpecifications: []*ec2.TagSpecification{
+				tagSpecification(ec2.ResourceTypeVolume, map[string]string{
+					"KubernetesCluster": volume.Kube.Name,
+					"Name":              volume.Name,
+					"SupergiantUUID":    volume.UUID,
+				}),
+			},
+		}
+		awsVol, err = p.ec2(kubeConfig(volume.Kube).Region).CreateVolume(volInput)
+		if err != nil {
+			return err
+		}
+	}
 
 	volume.ProviderID = *awsVol.VolumeId
 	volume.Size = int(*awsVol.Size)
-	if err := p.Core.DB.Save(volume); err != nil {
-		return err
+	volume.AvailabilityZone = zone.Name
+	return p.Core.DB.Save(volume)
+}
+
+// findVolume returns the live EBS volume tagged with the Volume's UUID (and
+// restored from the snapshot, if given), or nil if there is none.
+func (p *Provider) findVolume(volume *model.Volume, snapshotID *string) (*ec2.Volume, error) {
+	input := &ec2.DescribeVolumesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:SupergiantUUID"),
+				Values: []*string{aws.String(volume.UUID)},
+			},
+			{
+				Name:   aws.String("status"),
+				Values: []*string{aws.String("creating"), aws.String("available"), aws.String("in-use")},
+			},
+		},
+	}
+	if snapshotID != nil {
+		input.Filters = append(input.Filters, &ec2.Filter{
+			Name:   aws.String("snapshot-id"),
+			Values: []*string{snapshotID},
+		})
+	}
+	resp, err := p.ec2(kubeConfig(volume.Kube).Region).DescribeVolumes(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Volumes) == 0 {
+		return nil, nil
 	}
+	return resp.Volumes[0], nil
+}
 
-	tagsInput := &ec2.CreateTagsInput{
-		Resources: []*string{
-			awsVol.VolumeId,
+// findSnapshot returns the EBS snapshot tagged with the Snapshot's UUID, or nil
+// if there is none.
+func (p *Provider) findSnapshot(m *model.Snapshot) (*ec2.Snapshot, error) {
+	input := &ec2.DescribeSnapshotsInput{
+		OwnerIds: []*string{aws.String("self")},
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:SupergiantUUID"),
+				Values: []*string{aws.String(m.UUID)},
+			},
 		},
-		Tags: []*ec2.Tag{
+	}
+	resp, err := p.ec2(kubeConfig(m.Kube).Region).DescribeSnapshots(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Snapshots) == 0 {
+		return nil, nil
+	}
+	return resp.Snapshots[0], nil
+}
+
+func (p *Provider) describeVolume(volume *model.Volume) (*ec2.Volume, error) {
+	input := &ec2.DescribeVolumesInput{
+		VolumeIds: []*string{aws.String(volume.ProviderID)},
+	}
+	resp, err := p.ec2(kubeConfig(volume.Kube).Region).DescribeVolumes(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Volumes) == 0 {
+		return nil, fmt.Errorf("EBS volume %s not found", volume.ProviderID)
+	}
+	return resp.Volumes[0], nil
+}
+
+// modifyVolume changes the type, size, IOPS and throughput of the Volume's EBS
+// volume in place, and waits until the change is being optimized, by which
+// time the volume has its new size. It returns false if the volume can't be
+// modified.
+func (p *Provider) modifyVolume(volume *model.Volume, action *core.Action) (bool, error) {
+	ec2S := p.ec2(kubeConfig(volume.Kube).Region)
+
+	input := &ec2.ModifyVolumeInput{
+		VolumeId:   aws.String(volume.ProviderID),
+		VolumeType: aws.String(volume.Type),
+		Size:       aws.Int64(int64(volume.Size)),
+		Iops:       positiveInt64(volume.IOPS),
+		Throughput: positiveInt64(volume.Throughput),
+	}
+	if _, err := ec2S.ModifyVolume(input); err != nil {
+		if isErrVolumeUnmodifiable(err) {
+			p.Core.Log.Warnf("Replacing EBS volume of %s from a snapshot, as it can't be modified: %s", volume.Name, err)
+			return false, nil
+		}
+		return false, err
+	}
+
+	var failure string
+	err := action.CancellableWaitFor("EBS volume of "+volume.Name+" to be modified", 30*time.Minute, 5*time.Second, func() (bool, error) {
+		resp, err := ec2S.DescribeVolumesModifications(&ec2.DescribeVolumesModificationsInput{
+			VolumeIds: []*string{aws.String(volume.ProviderID)},
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(resp.VolumesModifications) == 0 {
+			return false, nil
+		}
+		modification := resp.VolumesModifications[0]
+		switch aws.StringValue(modification.ModificationState) {
+		case ec2.VolumeModificationStateOptimizing, ec2.VolumeModificationStateCompleted:
+			return true, nil
+		case ec2.VolumeModificationStateFailed:
+			failure = aws.StringValue(modification.StatusMessage)
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if failure != "" {
+		p.Core.Log.Warnf("Replacing EBS volume of %s from a snapshot, as modifying it failed: %s", volume.Name, failure)
+		return false, nil
+	}
+	return true, nil
+}
+
+// replaceVolume replaces the Volume's EBS volume with one of its type, size,
+// IOPS and throughput, created from a snapshot of it. The snapshot is kept on
+// the Volume until the old volume is deleted, and the new one is created
+// before that, so that the data is never only in a volume being deleted.
+func (p *Provider) replaceVolume(volume *model.Volume) error {
+	if volume.ResizeSnapshotID == "" {
+		snapshot, err := p.createSnapshot(volume)
+		if err != nil {
+			return err
+		}
+		if err := p.Core.DB.Model(volume).Update("resize_snapshot_id", *snapshot.SnapshotId).Error; err != nil {
+			return err
+		}
+	}
+	snapshot, err := p.waitForSnapshot(volume, volume.ResizeSnapshotID)
+	if err != nil {
+		return err
+	}
+
+	oldIDs := []string{volume.ProviderID}
+	if err := p.createVolume(volume, snapshot.SnapshotId); err != nil {
+		return err
+	}
+	// The old volume is the one the Volume had, unless a retry already replaced
+	// it, or any other live one tagged with the Volume's UUID.
+	resp, err := p.ec2(kubeConfig(volume.Kube).Region).DescribeVolumes(&ec2.DescribeVolumesInput{
+		Filters: []*ec2.Filter{
 			{
-				Key:   aws.String("Name"),
-				Value: aws.String(volume.Name),
+				Name:   aws.String("tag:SupergiantUUID"),
+				Values: []*string{aws.String(volume.UUID)},
+			},
+			{
+				Name:   aws.String("status"),
+				Values: []*string{aws.String("creating"), aws.String("available"), aws.String("in-use")},
 			},
 		},
+	})
+	if err != nil {
+		return err
 	}
-	_, err = p.ec2(volume.Kube.AWSConfig.Region).CreateTags(tagsInput)
-	return err
+	for _, awsVol := range resp.Volumes {
+		if *awsVol.VolumeId != oldIDs[0] {
+			oldIDs = append(oldIDs, *awsVol.VolumeId)
+		}
+	}
+	for _, id := range oldIDs {
+		if id == volume.ProviderID {
+			continue
+		}
+		old := *volume
+		old.ProviderID = id
+		if err := p.deleteVolume(&old); err != nil {
+			return err
+		}
+	}
+
+	if err := p.deleteSnapshot(volume, snapshot); err != nil {
+		p.Core.Log.Errorf("Error deleting snapshot %s: %s", *snapshot.SnapshotId, err.Error())
+	}
+	updates := map[string]interface{}{"resize_snapshot_id": ""}
+	if volume.Size > int(*snapshot.VolumeSize) {
+		updates["grow_filesystem"] = true
+	}
+	return p.Core.DB.Model(volume).Updates(updates).Error
 }
 
 func (p *Provider) deleteVolume(volume *model.Volume) error {
@@ -1208,7 +2113,7 @@ func (p *Provider) deleteVolume(volume *model.Volume) error {
 	input := &ec2.DeleteVolumeInput{
 		VolumeId: aws.String(volume.ProviderID),
 	}
-	if _, err := p.ec2(volume.Kube.AWSConfig.Region).DeleteVolume(input); isErrAndNotAWSNotFound(err) {
+	if _, err := p.ec2(kubeConfig(volume.Kube).Region).DeleteVolume(input); isErrAndNotAWSNotFound(err) {
 		return err
 	}
 	return nil
@@ -1226,7 +2131,7 @@ func (p *Provider) waitForAvailable(volume *model.Volume) error {
 		},
 	}
 
-	resp, err := p.ec2(volume.Kube.AWSConfig.Region).DescribeVolumes(input)
+	resp, err := p.ec2(kubeConfig(volume.Kube).Region).DescribeVolumes(input)
 	if err != nil {
 		return err
 	}
@@ -1235,7 +2140,7 @@ func (p *Provider) waitForAvailable(volume *model.Volume) error {
 	}
 
 	p.Core.Log.Debugf("Waiting for EBS volume %s to be available", volume.Name)
-	return p.ec2(volume.Kube.AWSConfig.Region).WaitUntilVolumeAvailable(input)
+	return p.ec2(kubeConfig(volume.Kube).Region).WaitUntilVolumeAvailable(input)
 }
 
 func (p *Provider) forceDetachVolume(volume *model.Volume) error {
@@ -1243,7 +2148,7 @@ func (p *Provider) forceDetachVolume(volume *model.Volume) error {
 		VolumeId: aws.String(volume.ProviderID),
 		Force:    aws.Bool(true),
 	}
-	if _, err := p.ec2(volume.Kube.AWSConfig.Region).DetachVolume(input); isErrAndNotAWSNotFound(err) {
+	if _, err := p.ec2(kubeConfig(volume.Kube).Region).DetachVolume(input); isErrAndNotAWSNotFound(err) {
 		return err
 	}
 	return nil
@@ -1254,24 +2159,33 @@ func (p *Provider) createSnapshot(volume *model.Volume) (*ec2.Snapshot, error) {
 		Description: aws.String(fmt.Sprintf("%s-%d", volume.Name, volume.Instance.ReleaseID)),
 		VolumeId:    aws.String(volume.ProviderID),
 	}
-	snapshot, err := p.ec2(volume.Kube.AWSConfig.Region).CreateSnapshot(input)
-	if err != nil {
+	return p.ec2(kubeConfig(volume.Kube).Region).CreateSnapshot(input)
+}
+
+// waitForSnapshot waits until the snapshot of the ID is completed, and returns
+// it.
+func (p *Provider) waitForSnapshot(volume *model.Volume, snapshotID string) (*ec2.Snapshot, error) {
+	input := &ec2.DescribeSnapshotsInput{
+		SnapshotIds: []*string{aws.String(snapshotID)},
+	}
+	if err := p.ec2(kubeConfig(volume.Kube).Region).WaitUntilSnapshotCompleted(input); err != nil {
 		return nil, err
 	}
-	waitInput := &ec2.DescribeSnapshotsInput{
-		SnapshotIds: []*string{snapshot.SnapshotId},
+	resp, err := p.ec2(kubeConfig(volume.Kube).Region).DescribeSnapshots(input)
+	if err != nil {
+		return nil, err
 	}
-	if err := p.ec2(volume.Kube.AWSConfig.Region).WaitUntilSnapshotCompleted(waitInput); err != nil {
-		return nil, err // TODO destroy snapshot that failed to complete?
+	if len(resp.Snapshots) == 0 {
+		return nil, fmt.Errorf("EBS snapshot %s not found", snapshotID)
 	}
-	return snapshot, nil
+	return resp.Snapshots[0], nil
 }
 
 func (p *Provider) deleteSnapshot(volume *model.Volume, snapshot *ec2.Snapshot) error {
 	input := &ec2.DeleteSnapshotInput{
 		SnapshotId: snapshot.SnapshotId,
 	}
-	if _, err := p.ec2(volume.Kube.AWSConfig.Region).DeleteSnapshot(input); isErrAndNotAWSNotFound(err) {
+	if _, err := p.ec2(kubeConfig(volume.Kube).Region).DeleteSnapshot(input); isErrAndNotAWSNotFound(err) {
 		return err
 	}
 	return nil
@@ -1279,41 +2193,191 @@ func (p *Provider) deleteSnapshot(volume *model.Volume, snapshot *ec2.Snapshot)
 
 //------------------------------------------------------------------------------
 
-type provisioner struct {
-	core  *core.Core
-	kube  *model.Kube
-	steps []*step
-}
+// validateKubeConfig checks the zones of a new Kube; that it has at least one,
+// either in AvailabilityZones or as AvailabilityZone, and each is valid and in
+// the Kube's region. It also checks that existing network resources are given
+// with the VPC they belong to, and marks them existing.
+func validateKubeConfig(m *model.Kube) error {
+	config := kubeConfig(m)
+	errs := make(validator.ErrorMap)
+
+	if len(config.AvailabilityZones) == 0 {
+		if config.AvailabilityZone == "" {
+			errs["ProviderConfig.AvailabilityZones"] = validator.ErrorArray{errors.New("must have at least one zone")}
+		}
+	} else if config.AvailabilityZone != "" || config.PublicSubnetIPRange != "" {
+		errs["ProviderConfig.AvailabilityZone"] = validator.ErrorArray{errors.New("must be empty when AvailabilityZones are given")}
+	}
+
+	if config.VPCID == "" {
+		if config.RouteTableID != "" {
+			errs["ProviderConfig.RouteTableID"] = validator.ErrorArray{errors.New("must be empty without VPCID")}
+		}
+		if config.ELBSecurityGroupID != "" || config.NodeSecurityGroupID != "" {
+			errs["ProviderConfig.NodeSecurityGroupID"] = validator.ErrorArray{errors.New("must be empty without VPCID")}
+		}
+	} else if len(config.AvailabilityZones) == 0 {
+		errs["ProviderConfig.AvailabilityZones"] = validator.ErrorArray{errors.New("must be given with VPCID, with their Subnets")}
+	}
+	if (config.ELBSecurityGroupID == "") != (config.NodeSecurityGroupID == "") {
+		errs["ProviderConfig.NodeSecurityGroupID"] = validator.ErrorArray{errors.New("must be given with ELBSecurityGroupID, or neither")}
+	}
+
+	seen := make(map[string]bool)
+	for i, zone := range config.AvailabilityZones {
+		key := fmt.Sprintf("ProviderConfig.AvailabilityZones[%d]", i)
+		if err := validator.Validate(zone); err != nil {
+			for field, fieldErrs := range err.(validator.ErrorMap) {
+				errs[key+"."+field] = fieldErrs
+			}
+			continue
+		}
+		if zone.PrivateSubnetIPRange != "" && !config.PrivateNodes {
+			errs[key+".PrivateSubnetIPRange"] = validator.ErrorArray{errors.New("must be empty without PrivateNodes")}
+		}
+		if config.VPCID == "" {
+			if zone.PublicSubnetID != "" || zone.PrivateSubnetID != "" {
+				errs[key+".PublicSubnetID"] = validator.ErrorArray{errors.New("must be empty without VPCID")}
+			}
+		} else {
+			if zone.PublicSubnetID == "" {
+				errs[key+".PublicSubnetID"] = validator.ErrorArray{errors.New("is required with VPCID")}
+			}
+			if config.PrivateNodes && zone.PrivateSubnetID == "" {
+				errs[key+".PrivateSubnetID"] = validator.ErrorArray{errors.New("is required with VPCID and PrivateNodes")}
+			}
+		}
+		if !strings.HasPrefix(zone.Name, config.Region) {
+			errs[key+".Name"] = validator.ErrorArray{fmt.Errorf("is not in region %s", config.Region)}
+		} else if seen[zone.Name] {
+			errs[key+".Name"] = validator.ErrorArray{errors.New("is repeated")}
+		}
+		seen[zone.Name] = true
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
 
-type step struct {
-	desc string
-	fn   func() error
+	config.ExistingVPC = config.VPCID != ""
+	config.ExistingSecurityGroups = config.NodeSecurityGroupID != ""
+	return nil
 }
 
-func (p *provisioner) addStep(desc string, fn func() error) {
-	p.steps = append(p.steps, &step{desc, fn})
+// kubeConfig returns the AWS config of a Kube.
+func kubeConfig(m *model.Kube) *model.AWSKubeConfig {
+	return m.ProviderConfig.(*model.AWSKubeConfig)
 }
 
-func (p *provisioner) run() error {
-	for _, step := range p.steps {
-		p.core.Log.Infof("Running step of Kube provisioner: %s", step.desc)
-		if err := step.fn(); err != nil {
-			return err
+// availabilityZones returns the zones of a Kube, first moving the single zone
+// of a Kube created before AvailabilityZones into them.
+func availabilityZones(config *model.AWSKubeConfig) []*model.AWSAvailabilityZone {
+	if len(config.AvailabilityZones) == 0 && config.AvailabilityZone != "" {
+		config.AvailabilityZones = []*model.AWSAvailabilityZone{
+			{
+				Name:                          config.AvailabilityZone,
+				PublicSubnetIPRange:           config.PublicSubnetIPRange,
+				PublicSubnetID:                config.PublicSubnetID,
+				RouteTableSubnetAssociationID: config.RouteTableSubnetAssociationID,
+			},
 		}
-		if err := p.core.DB.Save(p.kube); err != nil {
-			return err
+		config.PublicSubnetIPRange = ""
+		config.PublicSubnetID = ""
+		config.RouteTableSubnetAssociationID = ""
+	}
+	if len(config.AvailabilityZones) > 0 {
+		config.AvailabilityZone = config.AvailabilityZones[0].Name
+	}
+	return config.AvailabilityZones
+}
+
+// availabilityZone returns a Kube's zone by name, or its first zone when name
+// is empty, as for Nodes and Volumes created before the Kube had zones.
+func availabilityZone(config *model.AWSKubeConfig, name string) (*model.AWSAvailabilityZone, error) {
+	zones := availabilityZones(config)
+	if len(zones) == 0 {
+		return nil, &core.FatalError{Err: errors.New("Kube has no availability zones")}
+	}
+	if name == "" {
+		return zones[0], nil
+	}
+	for _, zone := range zones {
+		if zone.Name == name {
+			return zone, nil
 		}
 	}
+	return nil, &core.FatalError{Err: fmt.Errorf("%s is not an availability zone of the Kube", name)}
+}
+
+// subnetIPRange returns the nth /24 of a VPC's IP range.
+// checkIPInSubnet returns a FatalError if ip is not in the IP range of the
+// Subnet.
+func (p *Provider) checkIPInSubnet(region string, ip string, subnetID string) error {
+	input := &ec2.DescribeSubnetsInput{
+		SubnetIds: []*string{
+			aws.String(subnetID),
+		},
+	}
+	resp, err := p.ec2(region).DescribeSubnets(input)
+	if err != nil {
+		return err
+	}
+	if len(resp.Subnets) == 0 {
+		return &core.FatalError{Err: fmt.Errorf("Subnet %s does not exist", subnetID)}
+	}
+	_, network, err := net.ParseCIDR(*resp.Subnets[0].CidrBlock)
+	if err != nil {
+		return err
+	}
+	if !network.Contains(net.ParseIP(ip)) {
+		return &core.FatalError{Err: fmt.Errorf("%s is not in Subnet %s (%s)", ip, subnetID, network)}
+	}
 	return nil
 }
 
-//------------------------------------------------------------------------------
+// nodeSubnetID returns the Subnet of the zone that Nodes are created in.
+func nodeSubnetID(config *model.AWSKubeConfig, zone *model.AWSAvailabilityZone) string {
+	if config.PrivateNodes {
+		return zone.PrivateSubnetID
+	}
+	return zone.PublicSubnetID
+}
+
+func subnetIPRange(vpcIPRange string, n int) (string, error) {
+	_, network, err := net.ParseCIDR(vpcIPRange)
+	if err != nil {
+		return "", err
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 32 || ones > 24 || n >= 1<<uint(24-ones) {
+		return "", fmt.Errorf("VPC IP range %s has no /24 Subnet for zone %d", vpcIPRange, n+1)
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(network.IP.To4())+uint32(n)<<8)
+	return ip.String() + "/24", nil
+}
 
 // is it NOT Not Found
 func isErrAndNotAWSNotFound(err error) bool {
 	return err != nil && !regexp.MustCompile(`([Nn]ot *[Ff]ound|404)`).MatchString(err.Error())
 }
 
+// spotInstanceTerminationCode is the state reason of spot servers terminated by
+// an interruption.
+const spotInstanceTerminationCode = "Server.SpotInstanceTermination"
+
+// isErrNoSpotCapacity returns true of errors creating spot servers which
+// creating them on-demand instead gets around.
+func isErrNoSpotCapacity(err error) bool {
+	return regexp.MustCompile(`(InsufficientInstanceCapacity|SpotMaxPriceTooLow|MaxSpotInstanceCountExceeded)`).MatchString(err.Error())
+}
+
+// isErrVolumeUnmodifiable returns true of errors modifying EBS volumes which
+// replacing them instead gets around.
+func isErrVolumeUnmodifiable(err error) bool {
+	return regexp.MustCompile(`(UnsupportedOperation|IncorrectModificationState|VolumeModificationRateExceeded)`).MatchString(err.Error())
+}
+
 func createIAMRole(iamS *iam.IAM, name string, policy string) error {
 	getInput := &iam.GetRoleInput{
 		RoleName: aws.String(name),
@@ -1383,17 +2447,56 @@ func createIAMInstanceProfile(iamS *iam.IAM, name string) error {
 }
 
 func tagAWSResource(ec2S *ec2.EC2, idstr string, tags map[string]string) error {
-	var ec2Tags []*ec2.Tag
-	for key, val := range tags {
-		ec2Tags = append(ec2Tags, &ec2.Tag{
-			Key:   aws.String(key),
-			Value: aws.String(val),
-		})
-	}
 	input := &ec2.CreateTagsInput{
 		Resources: []*string{aws.String(idstr)},
-		Tags:      ec2Tags,
+		Tags:      ec2Tags(tags),
 	}
 	_, err := ec2S.CreateTags(input)
 	return err
 }
+
+// tagSpecification tags a resource of the type as it is created, so that it is
+// never found untagged.
+func tagSpecification(resourceType string, tags map[string]string) *ec2.TagSpecification {
+	return &ec2.TagSpecification{
+		ResourceType: aws.String(resourceType),
+		Tags:         ec2Tags(tags),
+	}
+}
+
+// ec2Tags returns the tags sorted by key, so that the requests of each attempt
+// to create a resource are the same, as AWS requires of a repeated ClientToken.
+func ec2Tags(tags map[string]string) (out []*ec2.Tag) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out = append(out, &ec2.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(tags[key]),
+		})
+	}
+	return out
+}
+
+// clientToken returns the idempotency token of the creation of a resource of
+// the model with the UUID; the same on every attempt.
+func clientToken(uuid string, parts ...string) string {
+	token := strings.Join(append([]string{uuid}, parts...), "-")
+	if len(token) > 64 { // the most AWS allows
+		sum := sha256.Sum256([]byte(token))
+		token = hex.EncodeToString(sum[:])
+	}
+	return token
+}
+
+// positiveInt64 returns nil for zero, so that optional settings of a Volume
+// which aren't given aren't sent.
+func positiveInt64(n int) *int64 {
+	if n <= 0 {
+		return nil
+	}
+	return aws.Int64(int64(n))
+}
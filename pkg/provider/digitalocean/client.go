@@ -0,0 +1,290 @@
+package digitalocean
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+)
+
+// APIURL is the base URL of the DigitalOcean API. Tests point it at a local
+// stand-in.
+var APIURL = "https://api.digitalocean.com/v2"
+
+// APIError is an error response from the DigitalOcean API.
+type APIError struct {
+	Status  int    `json:"-"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("DigitalOcean API error %d (%s): %s", err.Status, err.ID, err.Message)
+}
+
+type Droplet struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	SizeSlug  string    `json:"size_slug"`
+	CreatedAt time.Time `json:"created_at"`
+	Networks  struct {
+		V4 []*DropletNetwork `json:"v4"`
+	} `json:"networks"`
+	Tags []string `json:"tags"`
+}
+
+type DropletNetwork struct {
+	IPAddress string `json:"ip_address"`
+	Type      string `json:"type"`
+}
+
+// IP returns the droplet's IPv4 address of the given type ("public" or
+// "private"), or "" if it has none yet.
+func (d *Droplet) IP(networkType string) string {
+	for _, network := range d.Networks.V4 {
+		if network.Type == networkType {
+			return network.IPAddress
+		}
+	}
+	return ""
+}
+
+type DropletCreateRequest struct {
+	Name              string   `json:"name"`
+	Region            string   `json:"region"`
+	Size              string   `json:"size"`
+	Image             string   `json:"image"`
+	SSHKeys           []string `json:"ssh_keys,omitempty"`
+	PrivateNetworking bool     `json:"private_networking"`
+	UserData          string   `json:"user_data"`
+	Tags              []string `json:"tags,omitempty"`
+}
+
+type Volume struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	SizeGigabytes int     `json:"size_gigabytes"`
+	DropletIDs    []int64 `json:"droplet_ids"`
+}
+
+type VolumeCreateRequest struct {
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Region        string `json:"region"`
+	SizeGigabytes int    `json:"size_gigabytes"`
+}
+
+type VolumeActionRequest struct {
+	Type          string `json:"type"`
+	Region        string `json:"region,omitempty"`
+	SizeGigabytes int    `json:"size_gigabytes,omitempty"`
+	DropletID     int64  `json:"droplet_id,omitempty"`
+}
+
+type Action struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+type LoadBalancer struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	IP              string            `json:"ip"`
+	Status          string            `json:"status"`
+	Region          string            `json:"region,omitempty"`
+	Tag             string            `json:"tag,omitempty"`
+	ForwardingRules []*ForwardingRule `json:"forwarding_rules"`
+	HealthCheck     *HealthCheck      `json:"health_check,omitempty"`
+}
+
+type ForwardingRule struct {
+	EntryProtocol  string `json:"entry_protocol"`
+	EntryPort      int64  `json:"entry_port"`
+	TargetProtocol string `json:"target_protocol"`
+	TargetPort     int64  `json:"target_port"`
+}
+
+type HealthCheck struct {
+	Protocol               string `json:"protocol"`
+	Port                   int64  `json:"port"`
+	CheckIntervalSeconds   int    `json:"check_interval_seconds"`
+	ResponseTimeoutSeconds int    `json:"response_timeout_seconds"`
+	HealthyThreshold       int    `json:"healthy_threshold"`
+	UnhealthyThreshold     int    `json:"unhealthy_threshold"`
+}
+
+//------------------------------------------------------------------------------
+
+// client is a minimal client of the parts of the DigitalOcean API the provider
+// uses.
+type client struct {
+	token string
+}
+
+func (c *client) account() error {
+	return c.request("GET", "/account", nil, nil)
+}
+
+func (c *client) createDroplet(req *DropletCreateRequest) (*Droplet, error) {
+	out := new(struct {
+		Droplet *Droplet `json:"droplet"`
+	})
+	if err := c.request("POST", "/droplets", req, out); err != nil {
+		return nil, err
+	}
+	return out.Droplet, nil
+}
+
+func (c *client) droplet(id int64) (*Droplet, error) {
+	out := new(struct {
+		Droplet *Droplet `json:"droplet"`
+	})
+	if err := c.request("GET", fmt.Sprintf("/droplets/%d", id), nil, out); err != nil {
+		return nil, err
+	}
+	return out.Droplet, nil
+}
+
+func (c *client) deleteDroplet(id int64) error {
+	return c.request("DELETE", fmt.Sprintf("/droplets/%d", id), nil, nil)
+}
+
+func (c *client) createVolume(req *VolumeCreateRequest) (*Volume, error) {
+	out := new(struct {
+		Volume *Volume `json:"volume"`
+	})
+	if err := c.request("POST", "/volumes", req, out); err != nil {
+		return nil, err
+	}
+	return out.Volume, nil
+}
+
+func (c *client) volume(id string) (*Volume, error) {
+	out := new(struct {
+		Volume *Volume `json:"volume"`
+	})
+	if err := c.request("GET", "/volumes/"+id, nil, out); err != nil {
+		return nil, err
+	}
+	return out.Volume, nil
+}
+
+func (c *client) volumeAction(id string, req *VolumeActionRequest) (*Action, error) {
+	out := new(struct {
+		Action *Action `json:"action"`
+	})
+	if err := c.request("POST", "/volumes/"+id+"/actions", req, out); err != nil {
+		return nil, err
+	}
+	return out.Action, nil
+}
+
+func (c *client) deleteVolume(id string) error {
+	return c.request("DELETE", "/volumes/"+id, nil, nil)
+}
+
+func (c *client) action(id int64) (*Action, error) {
+	out := new(struct {
+		Action *Action `json:"action"`
+	})
+	if err := c.request("GET", fmt.Sprintf("/actions/%d", id), nil, out); err != nil {
+		return nil, err
+	}
+	return out.Action, nil
+}
+
+func (c *client) createLoadBalancer(req *LoadBalancer) (*LoadBalancer, error) {
+	out := new(struct {
+		LoadBalancer *LoadBalancer `json:"load_balancer"`
+	})
+	if err := c.request("POST", "/load_balancers", req, out); err != nil {
+		return nil, err
+	}
+	return out.LoadBalancer, nil
+}
+
+// loadBalancerByName returns nil if there is no load balancer of the name.
+//
+// NOTE only the first page (of 200) is searched.
+func (c *client) loadBalancerByName(name string) (*LoadBalancer, error) {
+	out := new(struct {
+		LoadBalancers []*LoadBalancer `json:"load_balancers"`
+	})
+	if err := c.request("GET", "/load_balancers?per_page=200", nil, out); err != nil {
+		return nil, err
+	}
+	for _, lb := range out.LoadBalancers {
+		if lb.Name == name {
+			return lb, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) addForwardingRules(id string, rules ...*ForwardingRule) error {
+	in := map[string][]*ForwardingRule{"forwarding_rules": rules}
+	return c.request("POST", "/load_balancers/"+id+"/forwarding_rules", in, nil)
+}
+
+func (c *client) removeForwardingRules(id string, rules ...*ForwardingRule) error {
+	in := map[string][]*ForwardingRule{"forwarding_rules": rules}
+	return c.request("DELETE", "/load_balancers/"+id+"/forwarding_rules", in, nil)
+}
+
+func (c *client) deleteLoadBalancer(id string) error {
+	return c.request("DELETE", "/load_balancers/"+id, nil, nil)
+}
+
+func (c *client) request(method string, path string, in interface{}, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, APIURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &APIError{Status: resp.StatusCode}
+		if err := json.Unmarshal(respBody, apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = string(respBody)
+		}
+		// Bad credentials or requests won't be fixed by retrying.
+		switch resp.StatusCode {
+		case 401, 403, 422:
+			return &core.FatalError{Err: apiErr}
+		}
+		return apiErr
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// is it NOT Not Found
+func isErrAndNotDONotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return err != nil && !(ok && apiErr.Status == 404)
+}
@@ -0,0 +1,460 @@
+package digitalocean
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/util"
+)
+
+// UserdataDir is the directory of the droplets' userdata templates.
+var UserdataDir = "config"
+
+// volumeMountDir is where minions mount the block storage volumes attached to
+// them, by name, for pods to use by host path. Kubernetes has no volume
+// source of DigitalOcean volumes.
+const volumeMountDir = "/mnt/supergiant/volumes"
+
+func init() {
+	core.RegisterProvider(&core.ProviderDefinition{
+		Name: "digitalocean",
+		Credentials: []*model.ProviderCredential{
+			{Name: "token", Description: "DigitalOcean API token (read and write)", Required: true, Secret: true},
+		},
+		NewKubeConfig: func() interface{} { return new(model.DOKubeConfig) },
+		New: func(c *core.Core, credentials map[string]string) core.Provider {
+			return &Provider{Core: c, Credentials: credentials}
+		},
+	})
+}
+
+type Provider struct {
+	Core        *core.Core
+	Credentials map[string]string
+}
+
+func (p *Provider) ValidateAccount(m *model.CloudAccount) error {
+	return p.client().account()
+}
+
+func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
+	c := p.client()
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m, Action: action}
+
+	provisioner.AddStep("creating Droplet for Kubernetes master", func() error {
+		if kubeConfig(m).MasterID != 0 {
+			return nil
+		}
+		userdata, err := renderUserdata("master", m)
+		if err != nil {
+			return err
+		}
+		droplet, err := c.createDroplet(&DropletCreateRequest{
+			Name:              m.Name + "-master",
+			Region:            kubeConfig(m).Region,
+			Size:              m.MasterNodeSize,
+			Image:             kubeConfig(m).Image,
+			SSHKeys:           sshKeys(m),
+			PrivateNetworking: true,
+			UserData:          userdata,
+			Tags:              []string{m.Name + "-master"},
+		})
+		if err != nil {
+			return err
+		}
+		kubeConfig(m).MasterID = droplet.ID
+		return nil
+	})
+
+	provisioner.AddStep("waiting for Kubernetes master to launch", func() error {
+		return action.CancellableWaitFor("Kubernetes master launch", 5*time.Minute, 3*time.Second, func() (bool, error) {
+			droplet, err := c.droplet(kubeConfig(m).MasterID)
+			if err != nil {
+				return false, err
+			}
+			if droplet.Status != "active" || droplet.IP("public") == "" || droplet.IP("private") == "" {
+				return false, nil
+			}
+
+			// Save IPs when ready
+			m.MasterPublicIP = droplet.IP("public")
+			kubeConfig(m).MasterPrivateIP = droplet.IP("private")
+			return true, p.Core.DB.Save(m)
+		})
+	})
+
+	provisioner.AddStep("creating Kubernetes minion", func() error {
+		node := &model.Node{
+			KubeID: m.ID,
+			Size:   m.NodeSizes[0],
+		}
+		return p.Core.Nodes.Create(node)
+	})
+
+	provisioner.AddStep("waiting for Kubernetes", func() error {
+		return action.CancellableWaitFor("Kubernetes API and first minion", 20*time.Minute, time.Second, func() (bool, error) {
+			nodes, err := p.Core.K8S(m).Nodes().List()
+			if err != nil {
+				return false, nil
+			}
+			return len(nodes.Items) > 0, nil
+		})
+	})
+
+	if err := provisioner.Run(); err != nil {
+		return err
+	}
+
+	return p.Core.DB.Model(m).Update("ready", true).Error
+}
+
+func (p *Provider) DeleteKube(m *model.Kube) error {
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m}
+
+	provisioner.AddStep("deleting master", func() error {
+		if kubeConfig(m).MasterID == 0 {
+			return nil
+		}
+		if err := p.client().deleteDroplet(kubeConfig(m).MasterID); isErrAndNotDONotFound(err) {
+			return err
+		}
+		kubeConfig(m).MasterID = 0
+		return nil
+	})
+
+	return provisioner.Run()
+}
+
+func (p *Provider) CreateNode(m *model.Node, action *core.Action) error {
+	userdata, err := renderUserdata("minion", m.Kube)
+	if err != nil {
+		return err
+	}
+	droplet, err := p.client().createDroplet(&DropletCreateRequest{
+		Name:              m.Kube.Name + "-minion-" + strings.ToLower(util.RandomString(5)),
+		Region:            kubeConfig(m.Kube).Region,
+		Size:              m.Size,
+		Image:             kubeConfig(m.Kube).Image,
+		SSHKeys:           sshKeys(m.Kube),
+		PrivateNetworking: true,
+		UserData:          userdata,
+
+		// Load balancers of the Kube's Entrypoints target droplets by this tag,
+		// so the Node doesn't have to be registered with each of them.
+		Tags: []string{minionTag(m.Kube)},
+	})
+	if err != nil {
+		return err
+	}
+
+	m.ProviderID = strconv.FormatInt(droplet.ID, 10)
+	m.Name = droplet.Name
+	m.ProviderCreationTimestamp = droplet.CreatedAt
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) DeleteNode(m *model.Node) error {
+	id, err := strconv.ParseInt(m.ProviderID, 10, 64)
+	if err != nil {
+		return err
+	}
+	if err := p.client().deleteDroplet(id); isErrAndNotDONotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) CreateVolume(m *model.Volume, action *core.Action) error {
+	volume, err := p.client().createVolume(&VolumeCreateRequest{
+		Name:          volumeName(m),
+		Description:   m.Name,
+		Region:        kubeConfig(m.Kube).Region,
+		SizeGigabytes: m.Size,
+	})
+	if err != nil {
+		return err
+	}
+	m.ProviderID = volume.ID
+	m.Size = volume.SizeGigabytes
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) WaitForVolumeAvailable(m *model.Volume, action *core.Action) error {
+	return action.CancellableWaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, p.volumeAvailable(m))
+}
+
+// ResizeVolume grows the block storage volume in place; the filesystem on it
+// is expanded by the Node it is next mounted on.
+func (p *Provider) ResizeVolume(m *model.Volume, action *core.Action) error {
+	return p.volumeAction(m, action, &VolumeActionRequest{
+		Type:          "resize",
+		Region:        kubeConfig(m.Kube).Region,
+		SizeGigabytes: m.Size,
+	})
+}
+
+// KubeVolume is the directory the minion the volume is attached to mounts it
+// on.
+func (p *Provider) KubeVolume(m *model.Volume) (*guber.Volume, error) {
+	return &guber.Volume{
+		HostPath: &guber.HostPath{Path: volumeMountDir + "/" + volumeName(m)},
+	}, nil
+}
+
+// AttachVolume attaches the block storage volume to the droplet of the Node.
+// The minion mounts it once attached.
+func (p *Provider) AttachVolume(m *model.Volume, node *model.Node, action *core.Action) error {
+	dropletID, err := strconv.ParseInt(node.ProviderID, 10, 64)
+	if err != nil {
+		return err
+	}
+	volume, err := p.client().volume(m.ProviderID)
+	if err != nil {
+		return err
+	}
+	for _, attachedID := range volume.DropletIDs {
+		if attachedID == dropletID {
+			return nil
+		}
+	}
+	if err := p.DetachVolume(m, action); err != nil {
+		return err
+	}
+	return p.volumeAction(m, action, &VolumeActionRequest{
+		Type:      "attach",
+		Region:    kubeConfig(m.Kube).Region,
+		DropletID: dropletID,
+	})
+}
+
+// DetachVolume detaches the block storage volume from any droplet it is
+// attached to. The minion unmounts it once detached.
+func (p *Provider) DetachVolume(m *model.Volume, action *core.Action) error {
+	volume, err := p.client().volume(m.ProviderID)
+	if err != nil {
+		if isErrAndNotDONotFound(err) {
+			return err
+		}
+		return nil
+	}
+	for _, dropletID := range volume.DropletIDs {
+		err := p.volumeAction(m, action, &VolumeActionRequest{
+			Type:      "detach",
+			Region:    kubeConfig(m.Kube).Region,
+			DropletID: dropletID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) DeleteVolume(m *model.Volume) error {
+	if m.ProviderID == "" {
+		return nil
+	}
+	if err := util.WaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, p.volumeAvailable(m)); err != nil {
+		return err
+	}
+	if err := p.client().deleteVolume(m.ProviderID); isErrAndNotDONotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) CreateEntrypoint(m *model.Entrypoint, action *core.Action) error {
+	c := p.client()
+
+	// The load balancer is found by name, which is the Entrypoint's ProviderID,
+	// so that retrying doesn't create another.
+	lb, err := c.loadBalancerByName(m.ProviderID)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		lb, err = c.createLoadBalancer(&LoadBalancer{
+			Name:   m.ProviderID,
+			Region: kubeConfig(m.Kube).Region,
+			Tag:    minionTag(m.Kube),
+			ForwardingRules: []*ForwardingRule{ // NOTE we must provide at least 1 rule, it is currently arbitrary
+				tcpForwardingRule(420, 420),
+			},
+			HealthCheck: &HealthCheck{
+				Protocol:               "tcp",
+				Port:                   10250,
+				CheckIntervalSeconds:   30,
+				ResponseTimeoutSeconds: 5,
+				HealthyThreshold:       2,
+				UnhealthyThreshold:     10,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = action.CancellableWaitFor("load balancer "+m.ProviderID+" IP", 10*time.Minute, 5*time.Second, func() (bool, error) {
+		if lb.IP != "" {
+			return true, nil
+		}
+		lb, err = c.loadBalancerByName(m.ProviderID)
+		if err != nil {
+			return false, err
+		}
+		if lb == nil {
+			return false, errors.New("Load balancer " + m.ProviderID + " disappeared")
+		}
+		return lb.IP != "", nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Save Address
+	m.Address = lb.IP
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) AddPortToEntrypoint(m *model.Entrypoint, lbPort int64, nodePort int64) error {
+	lb, err := p.loadBalancer(m)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		return fmt.Errorf("Load balancer %s not found", m.ProviderID)
+	}
+	return p.client().addForwardingRules(lb.ID, tcpForwardingRule(lbPort, nodePort))
+}
+
+func (p *Provider) RemovePortFromEntrypoint(m *model.Entrypoint, lbPort int64) error {
+	lb, err := p.loadBalancer(m)
+	if err != nil || lb == nil {
+		return err
+	}
+	for _, rule := range lb.ForwardingRules {
+		if rule.EntryPort == lbPort {
+			if err := p.client().removeForwardingRules(lb.ID, rule); isErrAndNotDONotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Provider) DeleteEntrypoint(m *model.Entrypoint) error {
+	lb, err := p.loadBalancer(m)
+	if err != nil || lb == nil {
+		return err
+	}
+	if err := p.client().deleteLoadBalancer(lb.ID); isErrAndNotDONotFound(err) {
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func (p *Provider) client() *client {
+	return &client{token: p.Credentials["token"]}
+}
+
+func (p *Provider) loadBalancer(m *model.Entrypoint) (*LoadBalancer, error) {
+	return p.client().loadBalancerByName(m.ProviderID)
+}
+
+// volumeAction performs an action on the Volume's block storage volume, and
+// waits until it has completed.
+func (p *Provider) volumeAction(m *model.Volume, action *core.Action, req *VolumeActionRequest) error {
+	c := p.client()
+	volAction, err := c.volumeAction(m.ProviderID, req)
+	if err != nil {
+		return err
+	}
+	return action.CancellableWaitFor("Volume "+m.Name+" to "+req.Type, 5*time.Minute, 3*time.Second, func() (bool, error) {
+		volAction, err := c.action(volAction.ID)
+		if err != nil {
+			return false, err
+		}
+		if volAction.Status == "errored" {
+			return false, fmt.Errorf("%s of Volume %s errored", strings.Title(req.Type), m.Name)
+		}
+		return volAction.Status == "completed", nil
+	})
+}
+
+// volumeAvailable is a WaitFor func, true when the volume is attached to no
+// droplet (or doesn't exist).
+func (p *Provider) volumeAvailable(m *model.Volume) func() (bool, error) {
+	return func() (bool, error) {
+		volume, err := p.client().volume(m.ProviderID)
+		if err != nil {
+			if isErrAndNotDONotFound(err) {
+				return false, err
+			}
+			return true, nil
+		}
+		return len(volume.DropletIDs) == 0, nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// kubeConfig returns the DigitalOcean config of a Kube.
+func kubeConfig(m *model.Kube) *model.DOKubeConfig {
+	return m.ProviderConfig.(*model.DOKubeConfig)
+}
+
+// renderUserdata renders the generic userdata of the role ("master" or
+// "minion") for a droplet of the Kube.
+func renderUserdata(role string, m *model.Kube) (string, error) {
+	return core.RenderUserdata(UserdataDir, "generic_"+role+"_userdata.txt", &core.Userdata{
+		Kube:               m,
+		Zone:               kubeConfig(m).Region,
+		HostnameCommand:    "curl --silent http://169.254.169.254/metadata/v1/hostname",
+		VolumeMountDir:     volumeMountDir,
+		VolumeDevicePrefix: "scsi-0DO_Volume_",
+	})
+}
+
+func sshKeys(m *model.Kube) []string {
+	if kubeConfig(m).SSHKeyFingerprint == "" {
+		return nil
+	}
+	return []string{kubeConfig(m).SSHKeyFingerprint}
+}
+
+func minionTag(m *model.Kube) string {
+	return m.Name + "-minion"
+}
+
+var invalidVolumeNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// volumeName is the name of the Volume's block storage volume, which must be
+// unique in the region, and only lowercase letters, numbers and hyphens.
+func volumeName(m *model.Volume) string {
+	name := fmt.Sprintf("%s-%s-%d", m.Kube.Name, m.Name, *m.ID)
+	name = invalidVolumeNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 64 {
+		name = name[len(name)-64:]
+	}
+	return strings.TrimLeft(name, "-")
+}
+
+func tcpForwardingRule(lbPort int64, nodePort int64) *ForwardingRule {
+	return &ForwardingRule{
+		EntryProtocol:  "tcp",
+		EntryPort:      lbPort,
+		TargetProtocol: "tcp",
+		TargetPort:     nodePort,
+	}
+}
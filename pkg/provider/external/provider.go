@@ -0,0 +1,107 @@
+// Package external is the provider of Kubes that Supergiant did not create;
+// existing Kubernetes clusters which it connects to with the
+// model.ExternalKubeConfig of the Kube, to manage Apps on them.
+//
+// Nothing is provisioned in any cloud. Kubes are made ready once their API
+// server answers, and their Nodes, Volumes and Entrypoints are not managed.
+package external
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+var (
+	errVolumesUnsupported     = errors.New("Volumes of external Kubes are not supported")
+	errEntrypointsUnsupported = errors.New("Entrypoints of external Kubes are not supported")
+)
+
+func init() {
+	core.RegisterProvider(&core.ProviderDefinition{
+		Name:          "external",
+		NewKubeConfig: func() interface{} { return new(model.ExternalKubeConfig) },
+		External:      true,
+		New: func(c *core.Core, credentials map[string]string) core.Provider {
+			return &Provider{Core: c}
+		},
+	})
+}
+
+type Provider struct {
+	Core *core.Core
+}
+
+// ValidateAccount accepts any CloudAccount; it has no credentials, since each
+// Kube has its own.
+func (p *Provider) ValidateAccount(m *model.CloudAccount) error {
+	return nil
+}
+
+// CreateKube checks that the Kube's API server can be reached with its
+// credentials, and marks it ready.
+func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
+	if _, err := p.Core.K8S(m).Namespaces().List(); err != nil {
+		// guber reports unsuccessful responses as "Status: 401 Unauthorized, ..."
+		if strings.HasPrefix(err.Error(), "Status: 401") || strings.HasPrefix(err.Error(), "Status: 403") {
+			return &core.FatalError{Err: err}
+		}
+		return err
+	}
+	return p.Core.DB.Model(m).Update("ready", true).Error
+}
+
+// DeleteKube leaves the cluster as it is.
+func (p *Provider) DeleteKube(m *model.Kube) error {
+	return nil
+}
+
+func (p *Provider) CreateNode(m *model.Node, action *core.Action) error {
+	return &core.FatalError{Err: core.ErrorKubeExternal}
+}
+
+func (p *Provider) DeleteNode(m *model.Node) error {
+	return &core.FatalError{Err: core.ErrorKubeExternal}
+}
+
+func (p *Provider) CreateVolume(m *model.Volume, action *core.Action) error {
+	return &core.FatalError{Err: errVolumesUnsupported}
+}
+
+func (p *Provider) WaitForVolumeAvailable(m *model.Volume, action *core.Action) error {
+	return &core.FatalError{Err: errVolumesUnsupported}
+}
+
+func (p *Provider) ResizeVolume(m *model.Volume, action *core.Action) error {
+	return &core.FatalError{Err: errVolumesUnsupported}
+}
+
+// DeleteVolume has nothing to delete, so that the records of Volumes which
+// failed to provision can be removed.
+func (p *Provider) DeleteVolume(m *model.Volume) error {
+	return nil
+}
+
+func (p *Provider) KubeVolume(m *model.Volume) (*guber.Volume, error) {
+	return nil, &core.FatalError{Err: errVolumesUnsupported}
+}
+
+func (p *Provider) CreateEntrypoint(m *model.Entrypoint, action *core.Action) error {
+	return &core.FatalError{Err: errEntrypointsUnsupported}
+}
+
+func (p *Provider) AddPortToEntrypoint(m *model.Entrypoint, lbPort int64, nodePort int64) error {
+	return &core.FatalError{Err: errEntrypointsUnsupported}
+}
+
+func (p *Provider) RemovePortFromEntrypoint(m *model.Entrypoint, lbPort int64) error {
+	return &core.FatalError{Err: errEntrypointsUnsupported}
+}
+
+// DeleteEntrypoint has nothing to delete, like DeleteVolume.
+func (p *Provider) DeleteEntrypoint(m *model.Entrypoint) error {
+	return nil
+}
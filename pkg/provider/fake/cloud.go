@@ -0,0 +1,405 @@
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	VolumeStateAvailable = "available"
+	VolumeStateInUse     = "in-use"
+)
+
+var (
+	ErrorServerNotFound       = errors.New("Fake Server not found")
+	ErrorVolumeNotFound       = errors.New("Fake Volume not found")
+	ErrorSnapshotNotFound     = errors.New("Fake Snapshot not found")
+	ErrorLoadBalancerNotFound = errors.New("Fake LoadBalancer not found")
+	ErrorVolumeInUse          = errors.New("Fake Volume is in use")
+	ErrorVolumeInOtherZone    = errors.New("Fake Volume is in another availability zone than the Server")
+	ErrorVolumeShrink         = errors.New("Fake Volume can't shrink")
+	ErrorServerNotSpot        = errors.New("Fake Server isn't spot")
+	ErrorNoSpotCapacity       = errors.New("Fake spot capacity is unavailable")
+)
+
+// Cloud is the in-memory state of the fake provider: the servers, volumes,
+// snapshots and load balancers it has "created". One Cloud is shared by every fake
+// CloudAccount.
+type Cloud struct {
+	mutex sync.Mutex
+
+	servers       map[string]*Server
+	volumes       map[string]*Volume
+	snapshots     map[string]*Snapshot
+	loadBalancers map[string]*LoadBalancer
+
+	// interrupted has the IDs of the spot Servers the Cloud has interrupted.
+	interrupted map[string]bool
+
+	lastID int
+}
+
+type Server struct {
+	ID         string
+	Name       string
+	Size       string
+	PrivateIP  string
+	PublicIP   string
+	Zone       string
+	LaunchTime time.Time
+
+	// Spot is true of Servers which the Cloud may interrupt.
+	Spot bool
+
+	// ClientToken identifies the request that created the Server; a request
+	// with the same token returns it instead of creating another, like on AWS.
+	ClientToken string
+}
+
+type Volume struct {
+	ID         string
+	Type       string
+	Size       int
+	IOPS       int
+	Throughput int
+	State      string
+	Zone       string
+	AttachedTo string
+
+	// SnapshotID is of the Snapshot the Volume was restored from.
+	SnapshotID string
+
+	// ClientToken is like that of Server.
+	ClientToken string
+}
+
+type Snapshot struct {
+	ID       string
+	VolumeID string
+	Size     int
+
+	// ClientToken is like that of Server.
+	ClientToken string
+}
+
+type LoadBalancer struct {
+	Name      string
+	DNSName   string
+	Listeners map[int64]int64 // LB port -> instance port
+	ServerIDs []string
+}
+
+func NewCloud() *Cloud {
+	return &Cloud{
+		servers:       make(map[string]*Server),
+		volumes:       make(map[string]*Volume),
+		snapshots:     make(map[string]*Snapshot),
+		loadBalancers: make(map[string]*LoadBalancer),
+		interrupted:   make(map[string]bool),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (c *Cloud) Server(id string) (*Server, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	server, ok := c.servers[id]
+	if !ok {
+		return nil, ErrorServerNotFound
+	}
+	copy := *server
+	return &copy, nil
+}
+
+func (c *Cloud) Volume(id string) (*Volume, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	volume, ok := c.volumes[id]
+	if !ok {
+		return nil, ErrorVolumeNotFound
+	}
+	copy := *volume
+	return &copy, nil
+}
+
+func (c *Cloud) Snapshot(id string) (*Snapshot, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	snapshot, ok := c.snapshots[id]
+	if !ok {
+		return nil, ErrorSnapshotNotFound
+	}
+	copy := *snapshot
+	return &copy, nil
+}
+
+func (c *Cloud) LoadBalancer(name string) (*LoadBalancer, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	lb, ok := c.loadBalancers[name]
+	if !ok {
+		return nil, ErrorLoadBalancerNotFound
+	}
+	copy := *lb
+	copy.Listeners = make(map[int64]int64)
+	for lbPort, instancePort := range lb.Listeners {
+		copy.Listeners[lbPort] = instancePort
+	}
+	copy.ServerIDs = append([]string{}, lb.ServerIDs...)
+	return &copy, nil
+}
+
+// AttachVolume attaches a Volume to a Server, as Kubernetes would when a pod
+// using the Volume is scheduled there.
+func (c *Cloud) AttachVolume(volumeID string, serverID string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	volume, ok := c.volumes[volumeID]
+	if !ok {
+		return ErrorVolumeNotFound
+	}
+	server, ok := c.servers[serverID]
+	if !ok {
+		return ErrorServerNotFound
+	}
+	if volume.Zone != server.Zone {
+		return ErrorVolumeInOtherZone
+	}
+	if volume.State == VolumeStateInUse && volume.AttachedTo != serverID {
+		return ErrorVolumeInUse
+	}
+	volume.State = VolumeStateInUse
+	volume.AttachedTo = serverID
+	return nil
+}
+
+// InterruptServer terminates a spot Server, as the cloud reclaiming it would.
+func (c *Cloud) InterruptServer(id string) error {
+	server, err := c.Server(id)
+	if err != nil {
+		return err
+	}
+	if !server.Spot {
+		return ErrorServerNotSpot
+	}
+	c.deleteServer(id)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.interrupted[id] = true
+	return nil
+}
+
+// Interrupted returns true of a Server which InterruptServer terminated, rather
+// than being deleted.
+func (c *Cloud) Interrupted(id string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.interrupted[id]
+}
+
+// DetachVolume makes a Volume available again.
+func (c *Cloud) DetachVolume(volumeID string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	volume, ok := c.volumes[volumeID]
+	if !ok {
+		return ErrorVolumeNotFound
+	}
+	volume.State = VolumeStateAvailable
+	volume.AttachedTo = ""
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Cloud) nextID() int {
+	c.lastID++
+	return c.lastID
+}
+
+func (c *Cloud) createServer(size string, zone string, spot bool, token string) *Server {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, server := range c.servers {
+		if token != "" && server.ClientToken == token {
+			return server
+		}
+	}
+	n := c.nextID()
+	privateIP := fmt.Sprintf("10.0.%d.%d", n/256, n%256)
+	server := &Server{
+		ID:          fmt.Sprintf("fake-i-%08d", n),
+		Name:        fmt.Sprintf("ip-10-0-%d-%d.fake.internal", n/256, n%256),
+		Size:        size,
+		PrivateIP:   privateIP,
+		PublicIP:    fmt.Sprintf("203.0.%d.%d", n/256%256, n%256), // TEST-NET-3
+		Zone:        zone,
+		LaunchTime:  time.Now(),
+		Spot:        spot,
+		ClientToken: token,
+	}
+	c.servers[server.ID] = server
+	return server
+}
+
+// deleteServer terminates a Server, detaching its Volumes and removing it from
+// load balancers.
+func (c *Cloud) deleteServer(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.servers, id)
+	for _, volume := range c.volumes {
+		if volume.AttachedTo == id {
+			volume.State = VolumeStateAvailable
+			volume.AttachedTo = ""
+		}
+	}
+	for _, lb := range c.loadBalancers {
+		lb.ServerIDs = without(lb.ServerIDs, id)
+	}
+}
+
+// createVolume creates a Volume of the type, size, IOPS, throughput, zone and
+// snapshot of the spec, unless one was already created with its ClientToken.
+func (c *Cloud) createVolume(spec Volume) (*Volume, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, volume := range c.volumes {
+		if spec.ClientToken != "" && volume.ClientToken == spec.ClientToken {
+			return volume, nil
+		}
+	}
+	if snapshot, ok := c.snapshots[spec.SnapshotID]; spec.SnapshotID != "" {
+		if !ok {
+			return nil, ErrorSnapshotNotFound
+		}
+		if spec.Size < snapshot.Size {
+			return nil, ErrorVolumeShrink
+		}
+	}
+	volume := &Volume{
+		ID:          fmt.Sprintf("fake-vol-%08d", c.nextID()),
+		Type:        spec.Type,
+		Size:        spec.Size,
+		IOPS:        spec.IOPS,
+		Throughput:  spec.Throughput,
+		State:       VolumeStateAvailable,
+		Zone:        spec.Zone,
+		SnapshotID:  spec.SnapshotID,
+		ClientToken: spec.ClientToken,
+	}
+	c.volumes[volume.ID] = volume
+	return volume, nil
+}
+
+// resizeVolume changes the type, size, IOPS and throughput of a Volume to
+// those of the spec.
+func (c *Cloud) resizeVolume(id string, spec Volume) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	volume, ok := c.volumes[id]
+	if !ok {
+		return ErrorVolumeNotFound
+	}
+	if volume.State == VolumeStateInUse {
+		return ErrorVolumeInUse
+	}
+	if spec.Size < volume.Size {
+		return ErrorVolumeShrink
+	}
+	volume.Type = spec.Type
+	volume.Size = spec.Size
+	volume.IOPS = spec.IOPS
+	volume.Throughput = spec.Throughput
+	return nil
+}
+
+func (c *Cloud) deleteVolume(id string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	volume, ok := c.volumes[id]
+	if !ok {
+		return nil
+	}
+	if volume.State == VolumeStateInUse {
+		return ErrorVolumeInUse
+	}
+	delete(c.volumes, id)
+	return nil
+}
+
+// createSnapshot snapshots a Volume, unless a Snapshot was already created
+// with the token.
+func (c *Cloud) createSnapshot(volumeID string, token string) (*Snapshot, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, snapshot := range c.snapshots {
+		if token != "" && snapshot.ClientToken == token {
+			return snapshot, nil
+		}
+	}
+	volume, ok := c.volumes[volumeID]
+	if !ok {
+		return nil, ErrorVolumeNotFound
+	}
+	snapshot := &Snapshot{
+		ID:          fmt.Sprintf("fake-snap-%08d", c.nextID()),
+		VolumeID:    volume.ID,
+		Size:        volume.Size,
+		ClientToken: token,
+	}
+	c.snapshots[snapshot.ID] = snapshot
+	return snapshot, nil
+}
+
+func (c *Cloud) deleteSnapshot(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.snapshots, id)
+}
+
+func (c *Cloud) createLoadBalancer(name string) *LoadBalancer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if lb, ok := c.loadBalancers[name]; ok {
+		return lb
+	}
+	lb := &LoadBalancer{
+		Name:      name,
+		DNSName:   name + ".elb.fake",
+		Listeners: make(map[int64]int64),
+	}
+	c.loadBalancers[name] = lb
+	return lb
+}
+
+func (c *Cloud) updateLoadBalancer(name string, fn func(*LoadBalancer)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	lb, ok := c.loadBalancers[name]
+	if !ok {
+		return ErrorLoadBalancerNotFound
+	}
+	fn(lb)
+	return nil
+}
+
+func (c *Cloud) deleteLoadBalancer(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.loadBalancers, name)
+}
+
+func without(ids []string, id string) (out []string) {
+	for _, existing := range ids {
+		if existing != id {
+			out = append(out, existing)
+		}
+	}
+	return
+}
@@ -0,0 +1,412 @@
+// Package fake is an in-memory cloud provider, for trying Supergiant out and
+// for testing, which creates nothing but records in a shared Cloud.
+//
+// The Credentials of a fake CloudAccount configure its behavior:
+//
+//	latency             how long each operation takes, e.g. "2s" (default none)
+//	failure_rate        the fraction of operations that fail, from 0 to 1
+//	fail_operations     comma-separated operations that always fail, e.g.
+//	                    "CreateNode,DeleteVolume"
+//	availability_zones  comma-separated zones the Kubes span, e.g.
+//	                    "fake-1a,fake-1b" (default none)
+//	spot_unavailable    "true" to have no spot capacity for Nodes of the
+//	                    Kubes' SpotPolicies (default false)
+package fake
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// DefaultCloud is the Cloud of fake CloudAccounts.
+var DefaultCloud = NewCloud()
+
+func init() {
+	core.RegisterProvider(&core.ProviderDefinition{
+		Name: "fake",
+		Credentials: []*model.ProviderCredential{
+			{Name: "latency", Description: "How long each operation takes, e.g. 2s"},
+			{Name: "failure_rate", Description: "The fraction of operations that fail, from 0 to 1"},
+			{Name: "fail_operations", Description: "Comma-separated operations that always fail"},
+			{Name: "availability_zones", Description: "Comma-separated availability zones the Kubes span"},
+			{Name: "spot_unavailable", Description: "Whether there is no spot capacity, true or false"},
+		},
+		IdempotentNodes: true,
+		New: func(c *core.Core, credentials map[string]string) core.Provider {
+			return &Provider{Core: c, Cloud: DefaultCloud, Credentials: credentials}
+		},
+	})
+}
+
+type Provider struct {
+	Core        *core.Core
+	Cloud       *Cloud
+	Credentials map[string]string
+}
+
+func (p *Provider) ValidateAccount(m *model.CloudAccount) error {
+	if _, err := p.latency(); err != nil {
+		return err
+	}
+	if _, err := p.failureRate(); err != nil {
+		return err
+	}
+	if _, err := p.spotUnavailable(); err != nil {
+		return err
+	}
+	return p.simulate("ValidateAccount")
+}
+
+// AvailabilityZones implements core.ZonedProvider, with the zones of the
+// availability_zones credential.
+func (p *Provider) AvailabilityZones(m *model.Kube) (zones []string) {
+	for _, zone := range strings.Split(p.Credentials["availability_zones"], ",") {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			zones = append(zones, zone)
+		}
+	}
+	return zones
+}
+
+func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m, Action: action}
+
+	provisioner.AddStep("creating Kubernetes master", func() error {
+		if err := p.simulate("CreateKube"); err != nil {
+			return err
+		}
+		if m.MasterPublicIP != "" {
+			return nil
+		}
+		master := p.Cloud.createServer(m.MasterNodeSize, firstOrEmpty(p.AvailabilityZones(m)), false, "")
+		return p.Core.DB.Model(m).Update("master_public_ip", master.PublicIP).Error
+	})
+
+	provisioner.AddStep("creating Kubernetes minion", func() error {
+		if err := p.simulate("CreateNode"); err != nil {
+			return err
+		}
+		node := &model.Node{
+			KubeID: m.ID,
+			Size:   m.NodeSizes[0],
+		}
+		return p.Core.Nodes.Create(node)
+	})
+
+	if err := provisioner.Run(); err != nil {
+		return err
+	}
+	return p.Core.DB.Model(m).Update("ready", true).Error
+}
+
+func (p *Provider) DeleteKube(m *model.Kube) error {
+	if err := p.simulate("DeleteKube"); err != nil {
+		return err
+	}
+	// NOTE the master isn't tracked by ID, like the AWS provider does, so it is
+	// left in the Cloud.
+	return nil
+}
+
+func (p *Provider) CreateNode(m *model.Node, action *core.Action) error {
+	if err := p.simulate("CreateNode"); err != nil {
+		return err
+	}
+	spot := m.Kube.SpotPolicy.Spot(m.Size)
+	if spot {
+		unavailable, err := p.spotUnavailable()
+		if err != nil {
+			return err
+		}
+		if unavailable {
+			if !m.Kube.SpotPolicy.OnDemandFallback {
+				return ErrorNoSpotCapacity
+			}
+			spot = false
+		}
+	}
+	server := p.Cloud.createServer(m.Size, m.AvailabilityZone, spot, m.UUID)
+
+	m.ProviderID = server.ID
+	m.Spot = server.Spot
+	m.Name = server.Name
+	m.ExternalIP = server.PublicIP
+	m.ProviderCreationTimestamp = server.LaunchTime
+	if err := p.Core.DB.Save(m); err != nil {
+		return err
+	}
+
+	for _, entrypoint := range m.Kube.Entrypoints {
+		err := p.Cloud.updateLoadBalancer(entrypoint.ProviderID, func(lb *LoadBalancer) {
+			lb.ServerIDs = append(lb.ServerIDs, server.ID)
+		})
+		if err != nil && err != ErrorLoadBalancerNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) DeleteNode(m *model.Node) error {
+	if err := p.simulate("DeleteNode"); err != nil {
+		return err
+	}
+	p.Cloud.deleteServer(m.ProviderID)
+	return nil
+}
+
+// SpotInterrupted implements core.SpotProvider. Like on AWS, only Servers the
+// Cloud interrupted were, not those deleted otherwise.
+func (p *Provider) SpotInterrupted(m *model.Node) (bool, error) {
+	if err := p.simulate("SpotInterrupted"); err != nil {
+		return false, err
+	}
+	return p.Cloud.Interrupted(m.ProviderID), nil
+}
+
+func (p *Provider) CreateVolume(m *model.Volume, action *core.Action) error {
+	if err := p.simulate("CreateVolume"); err != nil {
+		return err
+	}
+	if m.SnapshotID != nil && m.Snapshot == nil {
+		return &core.FatalError{Err: fmt.Errorf("Snapshot %d of Volume %s no longer exists", *m.SnapshotID, m.Name)}
+	}
+	if m.AvailabilityZone == "" {
+		m.AvailabilityZone = firstOrEmpty(p.AvailabilityZones(m.Kube))
+	}
+	volume, err := p.Cloud.createVolume(volumeSpec(m))
+	if err != nil {
+		return err
+	}
+	m.ProviderID = volume.ID
+	// Like an EBS volume restored from a smaller snapshot, its filesystem has
+	// to be grown.
+	if m.Snapshot != nil && m.Size > m.Snapshot.Size {
+		m.GrowFilesystem = true
+	}
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) WaitForVolumeAvailable(m *model.Volume, action *core.Action) error {
+	if err := p.simulate("WaitForVolumeAvailable"); err != nil {
+		return err
+	}
+	return action.CancellableWaitFor("Volume "+m.Name+" to be available", 5*time.Minute, time.Second, func() (bool, error) {
+		volume, err := p.Cloud.Volume(m.ProviderID)
+		if err == ErrorVolumeNotFound {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return volume.State == VolumeStateAvailable, nil
+	})
+}
+
+func (p *Provider) ResizeVolume(m *model.Volume, action *core.Action) error {
+	if err := p.simulate("ResizeVolume"); err != nil {
+		return err
+	}
+	volume, err := p.Cloud.Volume(m.ProviderID)
+	if err != nil {
+		return err
+	}
+	if err := p.Cloud.resizeVolume(m.ProviderID, volumeSpec(m)); err != nil {
+		return err
+	}
+	// Like an EBS volume, the filesystem on it has to be grown.
+	if m.Size > volume.Size {
+		return p.Core.DB.Model(m).Update("grow_filesystem", true).Error
+	}
+	return nil
+}
+
+func (p *Provider) DeleteVolume(m *model.Volume) error {
+	if err := p.simulate("DeleteVolume"); err != nil {
+		return err
+	}
+	return p.Cloud.deleteVolume(m.ProviderID)
+}
+
+// KubeVolume is the volume as an EBS volume, which the fake Kubernetes API
+// attaches to the Node of the pod in the Cloud.
+func (p *Provider) KubeVolume(m *model.Volume) (*guber.Volume, error) {
+	return &guber.Volume{
+		AwsElasticBlockStore: &guber.AwsElasticBlockStore{
+			VolumeID: m.ProviderID,
+			FSType:   "ext4",
+		},
+	}, nil
+}
+
+func (p *Provider) CreateSnapshot(m *model.Snapshot, action *core.Action) error {
+	if err := p.simulate("CreateSnapshot"); err != nil {
+		return err
+	}
+	if m.Volume == nil || m.Volume.ProviderID == "" {
+		return &core.FatalError{Err: ErrorVolumeNotFound}
+	}
+	snapshot, err := p.Cloud.createSnapshot(m.Volume.ProviderID, m.UUID)
+	if err != nil {
+		return err
+	}
+	m.ProviderID = snapshot.ID
+	m.Ready = true
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) DeleteSnapshot(m *model.Snapshot) error {
+	if err := p.simulate("DeleteSnapshot"); err != nil {
+		return err
+	}
+	p.Cloud.deleteSnapshot(m.ProviderID)
+	return nil
+}
+
+func (p *Provider) CreateEntrypoint(m *model.Entrypoint, action *core.Action) error {
+	if err := p.simulate("CreateEntrypoint"); err != nil {
+		return err
+	}
+	lb := p.Cloud.createLoadBalancer(m.ProviderID)
+
+	// Save Address
+	m.Address = lb.DNSName
+	if err := p.Core.DB.Save(m); err != nil {
+		return err
+	}
+
+	return p.Cloud.updateLoadBalancer(m.ProviderID, func(lb *LoadBalancer) {
+		for _, node := range m.Kube.Nodes {
+			lb.ServerIDs = append(without(lb.ServerIDs, node.ProviderID), node.ProviderID)
+		}
+	})
+}
+
+func (p *Provider) AddPortToEntrypoint(m *model.Entrypoint, lbPort int64, nodePort int64) error {
+	if err := p.simulate("AddPortToEntrypoint"); err != nil {
+		return err
+	}
+	return p.Cloud.updateLoadBalancer(m.ProviderID, func(lb *LoadBalancer) {
+		lb.Listeners[lbPort] = nodePort
+	})
+}
+
+func (p *Provider) RemovePortFromEntrypoint(m *model.Entrypoint, lbPort int64) error {
+	if err := p.simulate("RemovePortFromEntrypoint"); err != nil {
+		return err
+	}
+	err := p.Cloud.updateLoadBalancer(m.ProviderID, func(lb *LoadBalancer) {
+		delete(lb.Listeners, lbPort)
+	})
+	if err != nil && err != ErrorLoadBalancerNotFound {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) DeleteEntrypoint(m *model.Entrypoint) error {
+	if err := p.simulate("DeleteEntrypoint"); err != nil {
+		return err
+	}
+	p.Cloud.deleteLoadBalancer(m.ProviderID)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+// simulate waits out the configured latency, then fails if the operation is
+// one of fail_operations, or by chance per failure_rate.
+func (p *Provider) simulate(operation string) error {
+	latency, err := p.latency()
+	if err != nil {
+		return err
+	}
+	time.Sleep(latency)
+
+	for _, op := range strings.Split(p.Credentials["fail_operations"], ",") {
+		if strings.TrimSpace(op) == operation {
+			return fmt.Errorf("Simulated failure of %s", operation)
+		}
+	}
+
+	rate, err := p.failureRate()
+	if err != nil {
+		return err
+	}
+	if rand.Float64() < rate {
+		return fmt.Errorf("Simulated failure of %s", operation)
+	}
+	return nil
+}
+
+func (p *Provider) latency() (time.Duration, error) {
+	str := p.Credentials["latency"]
+	if str == "" {
+		return 0, nil
+	}
+	latency, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, &core.FatalError{Err: fmt.Errorf("Invalid latency %q: %s", str, err)}
+	}
+	return latency, nil
+}
+
+func (p *Provider) failureRate() (float64, error) {
+	str := p.Credentials["failure_rate"]
+	if str == "" {
+		return 0, nil
+	}
+	rate, err := strconv.ParseFloat(str, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, &core.FatalError{Err: fmt.Errorf("Invalid failure_rate %q, must be from 0 to 1", str)}
+	}
+	return rate, nil
+}
+
+func (p *Provider) spotUnavailable() (bool, error) {
+	str := p.Credentials["spot_unavailable"]
+	if str == "" {
+		return false, nil
+	}
+	unavailable, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, &core.FatalError{Err: fmt.Errorf("Invalid spot_unavailable %q, must be true or false", str)}
+	}
+	return unavailable, nil
+}
+
+func firstOrEmpty(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	return strs[0]
+}
+
+// volumeSpec is the fake Volume a Volume is created or resized as.
+func volumeSpec(m *model.Volume) Volume {
+	return Volume{
+		Type:        m.Type,
+		Size:        m.Size,
+		IOPS:        m.IOPS,
+		Throughput:  m.Throughput,
+		Zone:        m.AvailabilityZone,
+		SnapshotID:  snapshotProviderID(m),
+		ClientToken: m.UUID,
+	}
+}
+
+func snapshotProviderID(m *model.Volume) string {
+	if m.Snapshot == nil {
+		return ""
+	}
+	return m.Snapshot.ProviderID
+}
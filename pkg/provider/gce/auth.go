@@ -0,0 +1,149 @@
+package gce
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+)
+
+const computeScope = "https://www.googleapis.com/auth/compute"
+
+// serviceAccount is the JSON key of a Google service account, as downloaded
+// from the Cloud Console.
+type serviceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func parseServiceAccount(str string) (*serviceAccount, error) {
+	account := new(serviceAccount)
+	if err := json.Unmarshal([]byte(str), account); err != nil {
+		return nil, fmt.Errorf("Invalid service_account_json: %s", err)
+	}
+	if account.Type != "service_account" || account.ProjectID == "" || account.PrivateKey == "" || account.ClientEmail == "" {
+		return nil, errors.New("Invalid service_account_json: must be the JSON key of a service account, with project_id, private_key and client_email")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://accounts.google.com/o/oauth2/token"
+	}
+	return account, nil
+}
+
+//------------------------------------------------------------------------------
+
+type accessToken struct {
+	value   string
+	expires time.Time
+}
+
+// Access tokens are cached by service account (and key), since a Provider is
+// made for every operation.
+var (
+	accessTokensMutex sync.Mutex
+	accessTokens      = make(map[string]*accessToken)
+)
+
+// token returns an OAuth2 access token for the service account, from the
+// cache if it has one that isn't about to expire.
+func (a *serviceAccount) token() (string, error) {
+	accessTokensMutex.Lock()
+	defer accessTokensMutex.Unlock()
+
+	keyHash := sha256.Sum256([]byte(a.PrivateKey))
+	key := a.ClientEmail + " " + a.TokenURI + " " + string(keyHash[:])
+	if cached := accessTokens[key]; cached != nil && time.Now().Before(cached.expires.Add(-time.Minute)) {
+		return cached.value, nil
+	}
+
+	assertion, err := a.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.PostForm(a.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	out := new(struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	})
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 || out.AccessToken == "" {
+		err := fmt.Errorf("Error authenticating service account %s: %s %s", a.ClientEmail, out.Error, out.ErrorDescription)
+		// A rejected key won't be accepted on retry.
+		if resp.StatusCode == 400 || resp.StatusCode == 401 {
+			return "", &core.FatalError{Err: err}
+		}
+		return "", err
+	}
+
+	accessTokens[key] = &accessToken{
+		value:   out.AccessToken,
+		expires: time.Now().Add(time.Duration(out.ExpiresIn) * time.Second),
+	}
+	return out.AccessToken, nil
+}
+
+// jwt returns the signed assertion exchanged for an access token.
+func (a *serviceAccount) jwt(now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(a.PrivateKey))
+	if block == nil {
+		return "", errors.New("Invalid private_key of service account " + a.ClientEmail)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", err
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("The private_key of service account " + a.ClientEmail + " is not an RSA key")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   a.ClientEmail,
+		"scope": computeScope,
+		"aud":   a.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := encodeSegment(header) + "." + encodeSegment(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + encodeSegment(signature), nil
+}
+
+func encodeSegment(b []byte) string {
+	return strings.TrimRight(base64.URLEncoding.EncodeToString(b), "=")
+}
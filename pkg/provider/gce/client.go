@@ -0,0 +1,300 @@
+package gce
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/util"
+)
+
+// ComputeURL is the base URL of the Compute Engine API. Tests point it at a
+// local stand-in.
+var ComputeURL = "https://www.googleapis.com/compute/v1"
+
+// httpClient makes the requests of the provider, to the Compute Engine API and
+// for tokens.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// APIError is an error response from the Compute Engine API.
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("GCE API error %d: %s", err.Code, err.Message)
+}
+
+type Operation struct {
+	Name   string `json:"name"`
+	Zone   string `json:"zone,omitempty"`
+	Region string `json:"region,omitempty"`
+	Status string `json:"status"`
+	Error  *struct {
+		Errors []*struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error,omitempty"`
+}
+
+type Instance struct {
+	Name              string                     `json:"name"`
+	SelfLink          string                     `json:"selfLink,omitempty"`
+	MachineType       string                     `json:"machineType"`
+	Status            string                     `json:"status,omitempty"`
+	CreationTimestamp *time.Time                 `json:"creationTimestamp,omitempty"`
+	Tags              *Tags                      `json:"tags,omitempty"`
+	Disks             []*AttachedDisk            `json:"disks,omitempty"`
+	NetworkInterfaces []*NetworkInterface        `json:"networkInterfaces"`
+	Metadata          *Metadata                  `json:"metadata,omitempty"`
+	ServiceAccounts   []*InstanceServiceAccount  `json:"serviceAccounts,omitempty"`
+	Scheduling        map[string]json.RawMessage `json:"scheduling,omitempty"`
+}
+
+// PublicIP is the external IP of the instance, or "" if it has none (yet).
+func (i *Instance) PublicIP() string {
+	for _, iface := range i.NetworkInterfaces {
+		for _, config := range iface.AccessConfigs {
+			if config.NatIP != "" {
+				return config.NatIP
+			}
+		}
+	}
+	return ""
+}
+
+// PrivateIP is the internal IP of the instance, or "" if it has none (yet).
+func (i *Instance) PrivateIP() string {
+	for _, iface := range i.NetworkInterfaces {
+		if iface.NetworkIP != "" {
+			return iface.NetworkIP
+		}
+	}
+	return ""
+}
+
+type Tags struct {
+	Items []string `json:"items"`
+}
+
+type AttachedDisk struct {
+	Boot             bool                    `json:"boot"`
+	AutoDelete       bool                    `json:"autoDelete"`
+	InitializeParams *AttachedDiskInitParams `json:"initializeParams,omitempty"`
+}
+
+type AttachedDiskInitParams struct {
+	SourceImage string `json:"sourceImage"`
+	DiskSizeGb  int64  `json:"diskSizeGb,string,omitempty"`
+}
+
+type NetworkInterface struct {
+	Network       string          `json:"network"`
+	NetworkIP     string          `json:"networkIP,omitempty"`
+	AccessConfigs []*AccessConfig `json:"accessConfigs"`
+}
+
+type AccessConfig struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	NatIP string `json:"natIP,omitempty"`
+}
+
+type Metadata struct {
+	Items []*MetadataItem `json:"items"`
+}
+
+type MetadataItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type InstanceServiceAccount struct {
+	Email  string   `json:"email"`
+	Scopes []string `json:"scopes"`
+}
+
+type Disk struct {
+	Name   string   `json:"name"`
+	SizeGb int64    `json:"sizeGb,string"`
+	Status string   `json:"status,omitempty"`
+	Type   string   `json:"type,omitempty"`
+	Users  []string `json:"users,omitempty"`
+}
+
+type Firewall struct {
+	Name         string             `json:"name"`
+	Network      string             `json:"network"`
+	Allowed      []*FirewallAllowed `json:"allowed"`
+	SourceRanges []string           `json:"sourceRanges"`
+	TargetTags   []string           `json:"targetTags"`
+}
+
+type FirewallAllowed struct {
+	IPProtocol string   `json:"IPProtocol"`
+	Ports      []string `json:"ports,omitempty"`
+}
+
+type Address struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+}
+
+type TargetPool struct {
+	Name      string   `json:"name"`
+	SelfLink  string   `json:"selfLink,omitempty"`
+	Instances []string `json:"instances"`
+}
+
+type ForwardingRule struct {
+	Name       string `json:"name"`
+	IPAddress  string `json:"IPAddress"`
+	IPProtocol string `json:"IPProtocol"`
+	PortRange  string `json:"portRange"`
+	Target     string `json:"target"`
+}
+
+//------------------------------------------------------------------------------
+
+// client is a minimal client of the parts of the Compute Engine API the
+// provider uses, for a single project.
+type client struct {
+	account *serviceAccount
+}
+
+func (c *client) project() error {
+	return c.request("GET", "", nil, nil)
+}
+
+// url is the full URL of a resource in the project, such as
+// "zones/us-central1-a/instances/kube-master".
+func (c *client) url(path string) string {
+	return ComputeURL + "/projects/" + c.account.ProjectID + "/" + path
+}
+
+func (c *client) get(path string, out interface{}) error {
+	return c.request("GET", path, nil, out)
+}
+
+// insert creates a resource of the collection at path, and waits for it.
+func (c *client) insert(path string, in interface{}) error {
+	op := new(Operation)
+	if err := c.request("POST", path, in, op); err != nil {
+		return err
+	}
+	return c.wait(op)
+}
+
+// do runs a custom method (ex. "disks/foo/resize"), and waits for it.
+func (c *client) do(path string, in interface{}) error {
+	return c.insert(path, in)
+}
+
+// delete deletes the resource at path, and waits for it.
+func (c *client) delete(path string) error {
+	op := new(Operation)
+	if err := c.request("DELETE", path, nil, op); err != nil {
+		return err
+	}
+	return c.wait(op)
+}
+
+// wait waits for an Operation to be done, returning its error if it failed.
+func (c *client) wait(op *Operation) error {
+	path := "global/operations/" + op.Name
+	if op.Zone != "" {
+		path = "zones/" + lastSegment(op.Zone) + "/operations/" + op.Name
+	} else if op.Region != "" {
+		path = "regions/" + lastSegment(op.Region) + "/operations/" + op.Name
+	}
+
+	return util.WaitFor("GCE operation "+op.Name, 10*time.Minute, 2*time.Second, func() (bool, error) {
+		if op.Status == "DONE" {
+			if op.Error != nil && len(op.Error.Errors) > 0 {
+				var msgs []string
+				for _, e := range op.Error.Errors {
+					msgs = append(msgs, e.Code+": "+e.Message)
+				}
+				return false, fmt.Errorf("GCE operation %s failed: %s", op.Name, strings.Join(msgs, ", "))
+			}
+			return true, nil
+		}
+		return false, c.get(path, op)
+	})
+}
+
+func (c *client) request(method string, path string, in interface{}, out interface{}) error {
+	token, err := c.account.token()
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.url(path), "/"), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		wrapper := new(struct {
+			Error *APIError `json:"error"`
+		})
+		apiErr := &APIError{Code: resp.StatusCode, Message: string(respBody)}
+		if err := json.Unmarshal(respBody, wrapper); err == nil && wrapper.Error != nil {
+			apiErr = wrapper.Error
+			apiErr.Code = resp.StatusCode
+		}
+		// Bad credentials or requests won't be fixed by retrying.
+		switch resp.StatusCode {
+		case 400, 401, 403:
+			return &core.FatalError{Err: apiErr}
+		}
+		return apiErr
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+//------------------------------------------------------------------------------
+
+func lastSegment(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// is it NOT Not Found
+func isErrAndNotGCENotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return err != nil && !(ok && apiErr.Code == 404)
+}
+
+// is it NOT Already Exists
+func isErrAndNotGCEAlreadyExists(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return err != nil && !(ok && apiErr.Code == 409)
+}
@@ -0,0 +1,513 @@
+package gce
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/util"
+)
+
+// UserdataDir is the directory of the instances' startup script templates.
+var UserdataDir = "config"
+
+func init() {
+	core.RegisterProvider(&core.ProviderDefinition{
+		Name: "gce",
+		Credentials: []*model.ProviderCredential{
+			{Name: "service_account_json", Description: "JSON key of a service account with the Compute Instance Admin and Compute Network Admin roles (the project is taken from it)", Required: true, Secret: true},
+		},
+		NewKubeConfig: func() interface{} { return new(model.GCEKubeConfig) },
+		New: func(c *core.Core, credentials map[string]string) core.Provider {
+			return &Provider{Core: c, Credentials: credentials}
+		},
+	})
+}
+
+type Provider struct {
+	Core        *core.Core
+	Credentials map[string]string
+}
+
+func (p *Provider) ValidateAccount(m *model.CloudAccount) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	return c.project()
+}
+
+func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m, Action: action}
+
+	provisioner.AddStep("creating firewall rule", func() error {
+		err := c.insert("global/firewalls", &Firewall{
+			Name:    firewallName(m),
+			Network: "global/networks/" + kubeConfig(m).Network,
+			Allowed: []*FirewallAllowed{
+				{IPProtocol: "tcp", Ports: []string{"22", "443", "10250", "30000-32767"}},
+				{IPProtocol: "udp", Ports: []string{"30000-32767"}},
+			},
+			SourceRanges: []string{"0.0.0.0/0"},
+			TargetTags:   []string{m.Name},
+		})
+		if isErrAndNotGCEAlreadyExists(err) {
+			return err
+		}
+		return nil
+	})
+
+	provisioner.AddStep("creating Kubernetes master instance", func() error {
+		if kubeConfig(m).MasterName != "" {
+			return nil
+		}
+		instance, err := p.instance(m, m.Name+"-master", m.MasterNodeSize, "master", []string{m.Name, m.Name + "-master"})
+		if err != nil {
+			return err
+		}
+		if err := c.insert(zonePath(m, "instances"), instance); isErrAndNotGCEAlreadyExists(err) {
+			return err
+		}
+		kubeConfig(m).MasterName = instance.Name
+		return nil
+	})
+
+	provisioner.AddStep("waiting for Kubernetes master to launch", func() error {
+		return action.CancellableWaitFor("Kubernetes master launch", 5*time.Minute, 3*time.Second, func() (bool, error) {
+			instance := new(Instance)
+			if err := c.get(zonePath(m, "instances/"+kubeConfig(m).MasterName), instance); err != nil {
+				return false, err
+			}
+			if instance.Status != "RUNNING" || instance.PublicIP() == "" || instance.PrivateIP() == "" {
+				return false, nil
+			}
+
+			// Save IPs when ready
+			m.MasterPublicIP = instance.PublicIP()
+			kubeConfig(m).MasterPrivateIP = instance.PrivateIP()
+			return true, p.Core.DB.Save(m)
+		})
+	})
+
+	provisioner.AddStep("creating Kubernetes minion", func() error {
+		node := &model.Node{
+			KubeID: m.ID,
+			Size:   m.NodeSizes[0],
+		}
+		return p.Core.Nodes.Create(node)
+	})
+
+	provisioner.AddStep("waiting for Kubernetes", func() error {
+		return action.CancellableWaitFor("Kubernetes API and first minion", 20*time.Minute, time.Second, func() (bool, error) {
+			nodes, err := p.Core.K8S(m).Nodes().List()
+			if err != nil {
+				return false, nil
+			}
+			return len(nodes.Items) > 0, nil
+		})
+	})
+
+	if err := provisioner.Run(); err != nil {
+		return err
+	}
+
+	return p.Core.DB.Model(m).Update("ready", true).Error
+}
+
+func (p *Provider) DeleteKube(m *model.Kube) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m}
+
+	provisioner.AddStep("deleting master", func() error {
+		if kubeConfig(m).MasterName == "" {
+			return nil
+		}
+		if err := c.delete(zonePath(m, "instances/"+kubeConfig(m).MasterName)); isErrAndNotGCENotFound(err) {
+			return err
+		}
+		kubeConfig(m).MasterName = ""
+		return nil
+	})
+
+	provisioner.AddStep("deleting firewall rule", func() error {
+		if err := c.delete("global/firewalls/" + firewallName(m)); isErrAndNotGCENotFound(err) {
+			return err
+		}
+		return nil
+	})
+
+	return provisioner.Run()
+}
+
+func (p *Provider) CreateNode(m *model.Node, action *core.Action) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	name := m.Kube.Name + "-minion-" + strings.ToLower(util.RandomString(5))
+	instance, err := p.instance(m.Kube, name, m.Size, "minion", []string{m.Kube.Name, m.Kube.Name + "-minion"})
+	if err != nil {
+		return err
+	}
+	if err := c.insert(zonePath(m.Kube, "instances"), instance); err != nil {
+		return err
+	}
+	if err := c.get(zonePath(m.Kube, "instances/"+name), instance); err != nil {
+		return err
+	}
+
+	m.ProviderID = instance.Name
+	m.Name = instance.Name
+	if instance.CreationTimestamp != nil {
+		m.ProviderCreationTimestamp = *instance.CreationTimestamp
+	}
+	if err := p.Core.DB.Save(m); err != nil {
+		return err
+	}
+
+	// Target pools list their instances, so the Node has to be added to the
+	// pool of each of the Kube's Entrypoints.
+	entrypoints, err := p.entrypoints(m.Kube)
+	if err != nil {
+		return err
+	}
+	for _, entrypoint := range entrypoints {
+		err := c.do(regionPath(m.Kube, "targetPools/"+resourceName(entrypoint.ProviderID)+"/addInstance"), instanceReferences(c, m.Kube, m.ProviderID))
+		if isErrAndNotGCENotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) DeleteNode(m *model.Node) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	entrypoints, err := p.entrypoints(m.Kube)
+	if err != nil {
+		return err
+	}
+	for _, entrypoint := range entrypoints {
+		err := c.do(regionPath(m.Kube, "targetPools/"+resourceName(entrypoint.ProviderID)+"/removeInstance"), instanceReferences(c, m.Kube, m.ProviderID))
+		if isErrAndNotGCENotFound(err) {
+			return err
+		}
+	}
+	if err := c.delete(zonePath(m.Kube, "instances/"+m.ProviderID)); isErrAndNotGCENotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) CreateVolume(m *model.Volume, action *core.Action) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	disk := &Disk{
+		Name:   resourceName(fmt.Sprintf("%s-%s-%d", m.Kube.Name, m.Name, *m.ID)),
+		SizeGb: int64(m.Size),
+		Type:   zonePath(m.Kube, "diskTypes/"+diskType(m)),
+	}
+	// The disk name is derived from the Volume, so a retry finds the disk it
+	// already created.
+	if err := c.insert(zonePath(m.Kube, "disks"), disk); isErrAndNotGCEAlreadyExists(err) {
+		return err
+	}
+	m.ProviderID = disk.Name
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) WaitForVolumeAvailable(m *model.Volume, action *core.Action) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	return action.CancellableWaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, diskAvailable(c, m))
+}
+
+// ResizeVolume grows the persistent disk in place, which GCE allows while it
+// is attached; the filesystem on it is expanded by the Node it is mounted on.
+func (p *Provider) ResizeVolume(m *model.Volume, action *core.Action) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	return c.do(zonePath(m.Kube, "disks/"+m.ProviderID+"/resize"), map[string]string{"sizeGb": strconv.Itoa(m.Size)})
+}
+
+func (p *Provider) DeleteVolume(m *model.Volume) error {
+	if m.ProviderID == "" {
+		return nil
+	}
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	if err := util.WaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, diskAvailable(c, m)); err != nil {
+		return err
+	}
+	if err := c.delete(zonePath(m.Kube, "disks/"+m.ProviderID)); isErrAndNotGCENotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// KubeVolume is the persistent disk, which Kubernetes attaches to the Node of
+// the pod.
+func (p *Provider) KubeVolume(m *model.Volume) (*guber.Volume, error) {
+	return &guber.Volume{
+		GCEPersistentDisk: &guber.GCEPersistentDisk{
+			PDName: m.ProviderID,
+			FSType: "ext4",
+		},
+	}, nil
+}
+
+// CreateEntrypoint reserves a static IP, and creates a target pool of the
+// Kube's minions for the forwarding rules of its ports.
+func (p *Provider) CreateEntrypoint(m *model.Entrypoint, action *core.Action) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	name := resourceName(m.ProviderID)
+
+	if err := c.insert(regionPath(m.Kube, "addresses"), &Address{Name: name}); isErrAndNotGCEAlreadyExists(err) {
+		return err
+	}
+
+	var instances []string
+	for _, node := range m.Kube.Nodes {
+		if node.ProviderID != "" {
+			instances = append(instances, c.url(zonePath(m.Kube, "instances/"+node.ProviderID)))
+		}
+	}
+	if err := c.insert(regionPath(m.Kube, "targetPools"), &TargetPool{Name: name, Instances: instances}); isErrAndNotGCEAlreadyExists(err) {
+		return err
+	}
+
+	address := new(Address)
+	if err := c.get(regionPath(m.Kube, "addresses/"+name), address); err != nil {
+		return err
+	}
+
+	// Save Address
+	m.Address = address.Address
+	return p.Core.DB.Save(m)
+}
+
+// AddPortToEntrypoint creates a forwarding rule for the port. GCE network load
+// balancers don't translate ports, so the port must be the node port.
+func (p *Provider) AddPortToEntrypoint(m *model.Entrypoint, lbPort int64, nodePort int64) error {
+	if lbPort != nodePort {
+		return &core.FatalError{Err: fmt.Errorf("GCE Entrypoints can't forward port %d to node port %d; leave the Port's external_number unset", lbPort, nodePort)}
+	}
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	name := resourceName(m.ProviderID)
+	err = c.insert(regionPath(m.Kube, "forwardingRules"), &ForwardingRule{
+		Name:       forwardingRuleName(m, lbPort),
+		IPAddress:  m.Address,
+		IPProtocol: "TCP",
+		PortRange:  fmt.Sprintf("%d-%d", lbPort, lbPort),
+		Target:     c.url(regionPath(m.Kube, "targetPools/"+name)),
+	})
+	if isErrAndNotGCEAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) RemovePortFromEntrypoint(m *model.Entrypoint, lbPort int64) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	if err := c.delete(regionPath(m.Kube, "forwardingRules/"+forwardingRuleName(m, lbPort))); isErrAndNotGCENotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) DeleteEntrypoint(m *model.Entrypoint) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	name := resourceName(m.ProviderID)
+
+	// Forwarding rules reference the pool and the address, so they go first.
+	rules := new(struct {
+		Items []*ForwardingRule `json:"items"`
+	})
+	if err := c.get(regionPath(m.Kube, "forwardingRules"), rules); err != nil {
+		return err
+	}
+	for _, rule := range rules.Items {
+		if strings.HasPrefix(rule.Name, name+"-") {
+			if err := c.delete(regionPath(m.Kube, "forwardingRules/"+rule.Name)); isErrAndNotGCENotFound(err) {
+				return err
+			}
+		}
+	}
+	if err := c.delete(regionPath(m.Kube, "targetPools/"+name)); isErrAndNotGCENotFound(err) {
+		return err
+	}
+	if err := c.delete(regionPath(m.Kube, "addresses/"+name)); isErrAndNotGCENotFound(err) {
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+func (p *Provider) client() (*client, error) {
+	account, err := parseServiceAccount(p.Credentials["service_account_json"])
+	if err != nil {
+		return nil, &core.FatalError{Err: err}
+	}
+	return &client{account: account}, nil
+}
+
+func (p *Provider) entrypoints(m *model.Kube) (entrypoints []*model.Entrypoint, err error) {
+	err = p.Core.DB.Where("kube_id = ?", m.ID).Find(&entrypoints)
+	return
+}
+
+// instance is the Instance to insert for the master or a minion of the Kube.
+func (p *Provider) instance(m *model.Kube, name string, size string, role string, tags []string) (*Instance, error) {
+	userdata, err := renderUserdata(role, m)
+	if err != nil {
+		return nil, err
+	}
+	return &Instance{
+		Name:        name,
+		MachineType: zonePath(m, "machineTypes/"+size),
+		Tags:        &Tags{Items: tags},
+		Disks: []*AttachedDisk{
+			{
+				Boot:       true,
+				AutoDelete: true,
+				InitializeParams: &AttachedDiskInitParams{
+					SourceImage: kubeConfig(m).Image,
+				},
+			},
+		},
+		NetworkInterfaces: []*NetworkInterface{
+			{
+				Network:       "global/networks/" + kubeConfig(m).Network,
+				AccessConfigs: []*AccessConfig{{Name: "External NAT", Type: "ONE_TO_ONE_NAT"}},
+			},
+		},
+		Metadata: &Metadata{
+			Items: []*MetadataItem{{Key: "startup-script", Value: userdata}},
+		},
+		ServiceAccounts: []*InstanceServiceAccount{
+			{
+				Email: "default",
+				Scopes: []string{
+					"https://www.googleapis.com/auth/compute",
+					"https://www.googleapis.com/auth/devstorage.read_only",
+				},
+			},
+		},
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// kubeConfig returns the GCE config of a Kube.
+func kubeConfig(m *model.Kube) *model.GCEKubeConfig {
+	return m.ProviderConfig.(*model.GCEKubeConfig)
+}
+
+// renderUserdata renders the generic userdata of the role ("master" or
+// "minion") for an instance of the Kube.
+func renderUserdata(role string, m *model.Kube) (string, error) {
+	return core.RenderUserdata(UserdataDir, "generic_"+role+"_userdata.txt", &core.Userdata{
+		Kube:            m,
+		Zone:            kubeConfig(m).Zone,
+		HostnameCommand: `curl --silent -H "Metadata-Flavor: Google" http://metadata.google.internal/computeMetadata/v1/instance/name`,
+		Cloud:           "gce",
+	})
+}
+
+// diskAvailable is a WaitFor func, true when the disk is ready and attached to
+// no instance (or doesn't exist).
+func diskAvailable(c *client, m *model.Volume) func() (bool, error) {
+	return func() (bool, error) {
+		disk := new(Disk)
+		if err := c.get(zonePath(m.Kube, "disks/"+m.ProviderID), disk); err != nil {
+			if isErrAndNotGCENotFound(err) {
+				return false, err
+			}
+			return true, nil
+		}
+		return disk.Status == "READY" && len(disk.Users) == 0, nil
+	}
+}
+
+func instanceReferences(c *client, m *model.Kube, names ...string) interface{} {
+	var refs []map[string]string
+	for _, name := range names {
+		refs = append(refs, map[string]string{"instance": c.url(zonePath(m, "instances/"+name))})
+	}
+	return map[string]interface{}{"instances": refs}
+}
+
+func zonePath(m *model.Kube, path string) string {
+	return "zones/" + kubeConfig(m).Zone + "/" + path
+}
+
+func regionPath(m *model.Kube, path string) string {
+	zone := kubeConfig(m).Zone
+	return "regions/" + zone[:strings.LastIndex(zone, "-")] + "/" + path
+}
+
+func firewallName(m *model.Kube) string {
+	return m.Name + "-allow-external"
+}
+
+func forwardingRuleName(m *model.Entrypoint, lbPort int64) string {
+	return resourceName(fmt.Sprintf("%s-%d", m.ProviderID, lbPort))
+}
+
+// diskType is the Volume's type if it is a GCE disk type (ex. "pd-ssd"), or
+// else "pd-standard".
+func diskType(m *model.Volume) string {
+	if strings.HasPrefix(m.Type, "pd-") {
+		return m.Type
+	}
+	return "pd-standard"
+}
+
+var invalidResourceNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// resourceName makes a valid GCE resource name, which must be at most 63
+// lowercase letters, numbers and hyphens, starting with a letter.
+func resourceName(name string) string {
+	name = invalidResourceNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 63 {
+		name = name[len(name)-63:]
+	}
+	return strings.TrimRight(strings.TrimLeft(name, "-0123456789"), "-")
+}
@@ -0,0 +1,541 @@
+package openstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+)
+
+// Service types of the catalog, in order of preference.
+var (
+	computeServices      = []string{"compute"}
+	volumeServices       = []string{"volumev3", "volumev2", "block-storage", "volume"}
+	networkServices      = []string{"network"}
+	loadBalancerServices = []string{"load-balancer", "network"} // Octavia, or else Neutron LBaaS v2
+)
+
+// APIError is an error response from an OpenStack API.
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("OpenStack API error %d: %s", err.Code, err.Message)
+}
+
+type Server struct {
+	ID        string                      `json:"id"`
+	Name      string                      `json:"name"`
+	Status    string                      `json:"status"`
+	Created   time.Time                   `json:"created"`
+	Addresses map[string][]*ServerAddress `json:"addresses"`
+	Metadata  map[string]string           `json:"metadata,omitempty"`
+}
+
+// IP returns the first "fixed" or "floating" IPv4 of the server, or "".
+func (s *Server) IP(typ string) string {
+	for _, addresses := range s.Addresses {
+		for _, address := range addresses {
+			if address.Version == 4 && address.Type == typ {
+				return address.Addr
+			}
+		}
+	}
+	return ""
+}
+
+type ServerAddress struct {
+	Addr    string `json:"addr"`
+	Version int    `json:"version"`
+	Type    string `json:"OS-EXT-IPS:type"`
+}
+
+type ServerCreateRequest struct {
+	Name           string              `json:"name"`
+	FlavorRef      string              `json:"flavorRef"`
+	ImageRef       string              `json:"imageRef"`
+	KeyName        string              `json:"key_name,omitempty"`
+	UserData       string              `json:"user_data,omitempty"`
+	Networks       []map[string]string `json:"networks"`
+	SecurityGroups []map[string]string `json:"security_groups,omitempty"`
+	Metadata       map[string]string   `json:"metadata,omitempty"`
+}
+
+type Flavor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Volume struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Size        int                 `json:"size"`
+	Status      string              `json:"status,omitempty"`
+	VolumeType  string              `json:"volume_type,omitempty"`
+	Attachments []map[string]string `json:"attachments,omitempty"`
+}
+
+type SecurityGroup struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SecurityGroupRule struct {
+	SecurityGroupID string `json:"security_group_id"`
+	Direction       string `json:"direction"`
+	Ethertype       string `json:"ethertype"`
+	Protocol        string `json:"protocol,omitempty"`
+	PortRangeMin    int    `json:"port_range_min,omitempty"`
+	PortRangeMax    int    `json:"port_range_max,omitempty"`
+	RemoteIPPrefix  string `json:"remote_ip_prefix,omitempty"`
+	RemoteGroupID   string `json:"remote_group_id,omitempty"`
+}
+
+type LoadBalancer struct {
+	ID                 string `json:"id,omitempty"`
+	Name               string `json:"name"`
+	VipSubnetID        string `json:"vip_subnet_id"`
+	VipAddress         string `json:"vip_address,omitempty"`
+	ProvisioningStatus string `json:"provisioning_status,omitempty"`
+}
+
+// Ref is a reference to another resource, as listed by LBaaS resources.
+type Ref struct {
+	ID string `json:"id"`
+}
+
+type Listener struct {
+	ID             string `json:"id,omitempty"`
+	Name           string `json:"name"`
+	LoadBalancerID string `json:"loadbalancer_id,omitempty"` // only when creating
+	LoadBalancers  []*Ref `json:"loadbalancers,omitempty"`
+	Protocol       string `json:"protocol"`
+	ProtocolPort   int64  `json:"protocol_port"`
+}
+
+type Pool struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ListenerID  string `json:"listener_id,omitempty"` // only when creating
+	Listeners   []*Ref `json:"listeners,omitempty"`
+	Protocol    string `json:"protocol"`
+	LBAlgorithm string `json:"lb_algorithm"`
+}
+
+type Member struct {
+	ID           string `json:"id,omitempty"`
+	Address      string `json:"address"`
+	ProtocolPort int64  `json:"protocol_port"`
+	SubnetID     string `json:"subnet_id"`
+}
+
+//------------------------------------------------------------------------------
+
+type catalogEntry struct {
+	Type      string `json:"type"`
+	Endpoints []*struct {
+		Interface string `json:"interface"`
+		Region    string `json:"region"`
+		RegionID  string `json:"region_id"`
+		URL       string `json:"url"`
+	} `json:"endpoints"`
+}
+
+// client is a minimal client of the Nova, Cinder, Neutron and Octavia APIs,
+// authenticated with Keystone v3 for a single project and region.
+type client struct {
+	token   string
+	region  string
+	catalog []*catalogEntry
+}
+
+// authenticate gets a token from Keystone for the credentials. When tenant is
+// "" the token is unscoped, which is only good for checking the credentials.
+func authenticate(credentials map[string]string, tenant string, region string) (*client, error) {
+	domain := map[string]string{"name": credentials["domain_name"]}
+	if domain["name"] == "" {
+		domain["name"] = "Default"
+	}
+	auth := map[string]interface{}{
+		"identity": map[string]interface{}{
+			"methods": []string{"password"},
+			"password": map[string]interface{}{
+				"user": map[string]interface{}{
+					"name":     credentials["username"],
+					"password": credentials["password"],
+					"domain":   domain,
+				},
+			},
+		},
+	}
+	if tenant != "" {
+		auth["scope"] = map[string]interface{}{
+			"project": map[string]interface{}{"name": tenant, "domain": domain},
+		}
+	}
+
+	out := new(struct {
+		Token struct {
+			Catalog []*catalogEntry `json:"catalog"`
+		} `json:"token"`
+	})
+	endpoint := strings.TrimSuffix(credentials["auth_url"], "/") + "/auth/tokens"
+	resp, err := do("", "POST", endpoint, map[string]interface{}{"auth": auth}, out)
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		token:   resp.Header.Get("X-Subject-Token"),
+		region:  region,
+		catalog: out.Token.Catalog,
+	}, nil
+}
+
+// endpoint is the public URL of the first service of the types in the
+// client's region.
+func (c *client) endpoint(types []string) (string, error) {
+	for _, typ := range types {
+		for _, entry := range c.catalog {
+			if entry.Type != typ {
+				continue
+			}
+			for _, endpoint := range entry.Endpoints {
+				if endpoint.Interface == "public" && (endpoint.Region == c.region || endpoint.RegionID == c.region) {
+					return strings.TrimSuffix(endpoint.URL, "/"), nil
+				}
+			}
+		}
+	}
+	return "", &core.FatalError{Err: fmt.Errorf("No %s endpoint in region %s", strings.Join(types, " or "), c.region)}
+}
+
+func (c *client) get(service []string, path string, out interface{}) error {
+	return c.request(service, "GET", path, nil, out)
+}
+
+func (c *client) post(service []string, path string, in interface{}, out interface{}) error {
+	return c.request(service, "POST", path, in, out)
+}
+
+func (c *client) delete(service []string, path string) error {
+	return c.request(service, "DELETE", path, nil, nil)
+}
+
+func (c *client) request(service []string, method string, path string, in interface{}, out interface{}) error {
+	endpoint, err := c.endpoint(service)
+	if err != nil {
+		return err
+	}
+	_, err = do(c.token, method, endpoint+"/"+path, in, out)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+func (c *client) flavorID(name string) (string, error) {
+	out := new(struct {
+		Flavors []*Flavor `json:"flavors"`
+	})
+	if err := c.get(computeServices, "flavors", out); err != nil {
+		return "", err
+	}
+	for _, flavor := range out.Flavors {
+		if flavor.Name == name {
+			return flavor.ID, nil
+		}
+	}
+	return "", &core.FatalError{Err: fmt.Errorf("No flavor named %s", name)}
+}
+
+func (c *client) createServer(req *ServerCreateRequest) (*Server, error) {
+	out := new(struct {
+		Server *Server `json:"server"`
+	})
+	if err := c.post(computeServices, "servers", map[string]interface{}{"server": req}, out); err != nil {
+		return nil, err
+	}
+	return out.Server, nil
+}
+
+func (c *client) server(id string) (*Server, error) {
+	out := new(struct {
+		Server *Server `json:"server"`
+	})
+	if err := c.get(computeServices, "servers/"+id, out); err != nil {
+		return nil, err
+	}
+	return out.Server, nil
+}
+
+func (c *client) deleteServer(id string) error {
+	return c.delete(computeServices, "servers/"+id)
+}
+
+func (c *client) createVolume(volume *Volume) (*Volume, error) {
+	out := new(struct {
+		Volume *Volume `json:"volume"`
+	})
+	if err := c.post(volumeServices, "volumes", map[string]interface{}{"volume": volume}, out); err != nil {
+		return nil, err
+	}
+	return out.Volume, nil
+}
+
+// volumeByName returns the volume of the name, or nil.
+func (c *client) volumeByName(name string) (*Volume, error) {
+	out := new(struct {
+		Volumes []*Volume `json:"volumes"`
+	})
+	if err := c.get(volumeServices, "volumes/detail?name="+url.QueryEscape(name), out); err != nil {
+		return nil, err
+	}
+	for _, volume := range out.Volumes {
+		if volume.Name == name {
+			return volume, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) volume(id string) (*Volume, error) {
+	out := new(struct {
+		Volume *Volume `json:"volume"`
+	})
+	if err := c.get(volumeServices, "volumes/"+id, out); err != nil {
+		return nil, err
+	}
+	return out.Volume, nil
+}
+
+func (c *client) extendVolume(id string, size int) error {
+	req := map[string]interface{}{
+		"os-extend": map[string]int{"new_size": size},
+	}
+	return c.post(volumeServices, "volumes/"+id+"/action", req, nil)
+}
+
+func (c *client) deleteVolume(id string) error {
+	return c.delete(volumeServices, "volumes/"+id)
+}
+
+// securityGroupByName returns the security group of the name, or nil.
+func (c *client) securityGroupByName(name string) (*SecurityGroup, error) {
+	out := new(struct {
+		SecurityGroups []*SecurityGroup `json:"security_groups"`
+	})
+	if err := c.get(networkServices, "v2.0/security-groups?name="+url.QueryEscape(name), out); err != nil {
+		return nil, err
+	}
+	for _, group := range out.SecurityGroups {
+		if group.Name == name {
+			return group, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) createSecurityGroup(group *SecurityGroup) (*SecurityGroup, error) {
+	out := new(struct {
+		SecurityGroup *SecurityGroup `json:"security_group"`
+	})
+	if err := c.post(networkServices, "v2.0/security-groups", map[string]interface{}{"security_group": group}, out); err != nil {
+		return nil, err
+	}
+	return out.SecurityGroup, nil
+}
+
+func (c *client) createSecurityGroupRule(rule *SecurityGroupRule) error {
+	return c.post(networkServices, "v2.0/security-group-rules", map[string]interface{}{"security_group_rule": rule}, nil)
+}
+
+func (c *client) deleteSecurityGroup(id string) error {
+	return c.delete(networkServices, "v2.0/security-groups/"+id)
+}
+
+// loadBalancerByName returns the load balancer of the name, or nil.
+func (c *client) loadBalancerByName(name string) (*LoadBalancer, error) {
+	out := new(struct {
+		LoadBalancers []*LoadBalancer `json:"loadbalancers"`
+	})
+	if err := c.get(loadBalancerServices, "v2.0/lbaas/loadbalancers?name="+url.QueryEscape(name), out); err != nil {
+		return nil, err
+	}
+	for _, lb := range out.LoadBalancers {
+		if lb.Name == name {
+			return lb, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) loadBalancer(id string) (*LoadBalancer, error) {
+	out := new(struct {
+		LoadBalancer *LoadBalancer `json:"loadbalancer"`
+	})
+	if err := c.get(loadBalancerServices, "v2.0/lbaas/loadbalancers/"+id, out); err != nil {
+		return nil, err
+	}
+	return out.LoadBalancer, nil
+}
+
+func (c *client) createLoadBalancer(lb *LoadBalancer) (*LoadBalancer, error) {
+	out := new(struct {
+		LoadBalancer *LoadBalancer `json:"loadbalancer"`
+	})
+	if err := c.post(loadBalancerServices, "v2.0/lbaas/loadbalancers", map[string]interface{}{"loadbalancer": lb}, out); err != nil {
+		return nil, err
+	}
+	return out.LoadBalancer, nil
+}
+
+func (c *client) deleteLoadBalancer(id string) error {
+	return c.delete(loadBalancerServices, "v2.0/lbaas/loadbalancers/"+id)
+}
+
+func (c *client) listeners() ([]*Listener, error) {
+	out := new(struct {
+		Listeners []*Listener `json:"listeners"`
+	})
+	err := c.get(loadBalancerServices, "v2.0/lbaas/listeners", out)
+	return out.Listeners, err
+}
+
+func (c *client) createListener(listener *Listener) (*Listener, error) {
+	out := new(struct {
+		Listener *Listener `json:"listener"`
+	})
+	if err := c.post(loadBalancerServices, "v2.0/lbaas/listeners", map[string]interface{}{"listener": listener}, out); err != nil {
+		return nil, err
+	}
+	return out.Listener, nil
+}
+
+func (c *client) deleteListener(id string) error {
+	return c.delete(loadBalancerServices, "v2.0/lbaas/listeners/"+id)
+}
+
+func (c *client) pools() ([]*Pool, error) {
+	out := new(struct {
+		Pools []*Pool `json:"pools"`
+	})
+	err := c.get(loadBalancerServices, "v2.0/lbaas/pools", out)
+	return out.Pools, err
+}
+
+func (c *client) createPool(pool *Pool) (*Pool, error) {
+	out := new(struct {
+		Pool *Pool `json:"pool"`
+	})
+	if err := c.post(loadBalancerServices, "v2.0/lbaas/pools", map[string]interface{}{"pool": pool}, out); err != nil {
+		return nil, err
+	}
+	return out.Pool, nil
+}
+
+func (c *client) deletePool(id string) error {
+	return c.delete(loadBalancerServices, "v2.0/lbaas/pools/"+id)
+}
+
+func (c *client) members(poolID string) ([]*Member, error) {
+	out := new(struct {
+		Members []*Member `json:"members"`
+	})
+	err := c.get(loadBalancerServices, "v2.0/lbaas/pools/"+poolID+"/members", out)
+	return out.Members, err
+}
+
+func (c *client) createMember(poolID string, member *Member) error {
+	return c.post(loadBalancerServices, "v2.0/lbaas/pools/"+poolID+"/members", map[string]interface{}{"member": member}, nil)
+}
+
+func (c *client) deleteMember(poolID string, id string) error {
+	return c.delete(loadBalancerServices, "v2.0/lbaas/pools/"+poolID+"/members/"+id)
+}
+
+//------------------------------------------------------------------------------
+
+func do(token string, method string, endpoint string, in interface{}, out interface{}) (*http.Response, error) {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("X-Auth-Token", token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &APIError{Code: resp.StatusCode, Message: errorMessage(respBody)}
+		// Bad credentials or requests won't be fixed by retrying.
+		switch resp.StatusCode {
+		case 400, 401, 403:
+			return nil, &core.FatalError{Err: apiErr}
+		}
+		return nil, apiErr
+	}
+	if out == nil || len(respBody) == 0 {
+		return resp, nil
+	}
+	return resp, json.Unmarshal(respBody, out)
+}
+
+// errorMessage finds the message in the error body of any of the APIs, which
+// each wrap it differently (ex. {"itemNotFound": {"message": "..."}}).
+func errorMessage(body []byte) string {
+	wrapped := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(body, &wrapped); err == nil {
+		for key, value := range wrapped {
+			if key == "faultstring" {
+				var message string
+				json.Unmarshal(value, &message)
+				return message
+			}
+			inner := new(struct {
+				Message string `json:"message"`
+			})
+			if err := json.Unmarshal(value, inner); err == nil && inner.Message != "" {
+				return inner.Message
+			}
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// is it NOT Not Found
+func isErrAndNotOpenStackNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return err != nil && !(ok && apiErr.Code == 404)
+}
+
+// is it NOT Conflict (ex. a duplicate security group rule)
+func isErrAndNotOpenStackConflict(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return err != nil && !(ok && apiErr.Code == 409)
+}
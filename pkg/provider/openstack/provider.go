@@ -0,0 +1,762 @@
+package openstack
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/util"
+)
+
+// UserdataDir is the directory of the servers' userdata templates.
+var UserdataDir = "config"
+
+func init() {
+	core.RegisterProvider(&core.ProviderDefinition{
+		Name: "openstack",
+		Credentials: []*model.ProviderCredential{
+			{Name: "auth_url", Description: "Keystone v3 URL (ex. https://keystone.example.com:5000/v3)", Required: true},
+			{Name: "username", Description: "OpenStack user", Required: true},
+			{Name: "password", Description: "Password of the user", Required: true, Secret: true},
+			{Name: "domain_name", Description: "Domain of the user and its projects (Default if blank)"},
+		},
+		NewKubeConfig: func() interface{} { return new(model.OpenStackKubeConfig) },
+		New: func(c *core.Core, credentials map[string]string) core.Provider {
+			return &Provider{Core: c, Credentials: credentials}
+		},
+	})
+}
+
+type Provider struct {
+	Core        *core.Core
+	Credentials map[string]string
+}
+
+// ValidateAccount checks the credentials with an unscoped token, since the
+// project (tenant) is chosen per Kube.
+func (p *Provider) ValidateAccount(m *model.CloudAccount) error {
+	_, err := authenticate(p.Credentials, "", "")
+	return err
+}
+
+func (p *Provider) CreateKube(m *model.Kube, action *core.Action) error {
+	c, err := p.client(m)
+	if err != nil {
+		return err
+	}
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m, Action: action}
+
+	provisioner.AddStep("creating security group", func() error {
+		// The group is found by name, so that retrying doesn't create another.
+		group, err := c.securityGroupByName(m.Name)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			group, err = c.createSecurityGroup(&SecurityGroup{
+				Name:        m.Name,
+				Description: "Supergiant Kube " + m.Name,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		kubeConfig(m).SecurityGroupID = group.ID
+
+		rules := []*SecurityGroupRule{
+			// The Kube's servers can reach each other on any port.
+			{RemoteGroupID: group.ID},
+		}
+		for _, ports := range [][2]int{{22, 22}, {443, 443}, {10250, 10250}, {30000, 32767}} {
+			rules = append(rules, &SecurityGroupRule{Protocol: "tcp", PortRangeMin: ports[0], PortRangeMax: ports[1], RemoteIPPrefix: "0.0.0.0/0"})
+		}
+		for _, rule := range rules {
+			rule.SecurityGroupID = group.ID
+			rule.Direction = "ingress"
+			rule.Ethertype = "IPv4"
+			if err := c.createSecurityGroupRule(rule); isErrAndNotOpenStackConflict(err) {
+				return err
+			}
+		}
+		return nil
+	})
+
+	provisioner.AddStep("creating Kubernetes master server", func() error {
+		if kubeConfig(m).MasterID != "" {
+			return nil
+		}
+		server, err := p.createServer(c, m, m.Name+"-master", m.MasterNodeSize, "master")
+		if err != nil {
+			return err
+		}
+		kubeConfig(m).MasterID = server.ID
+		return nil
+	})
+
+	provisioner.AddStep("waiting for Kubernetes master to launch", func() error {
+		return action.CancellableWaitFor("Kubernetes master launch", 5*time.Minute, 3*time.Second, func() (bool, error) {
+			server, err := c.server(kubeConfig(m).MasterID)
+			if err != nil {
+				return false, err
+			}
+			if server.Status == "ERROR" {
+				return false, &core.FatalError{Err: errors.New("Kubernetes master server failed to build")}
+			}
+			if server.Status != "ACTIVE" || server.IP("fixed") == "" {
+				return false, nil
+			}
+
+			// Save IPs when ready
+			m.MasterPublicIP = publicIP(server)
+			kubeConfig(m).MasterPrivateIP = server.IP("fixed")
+			return true, p.Core.DB.Save(m)
+		})
+	})
+
+	provisioner.AddStep("creating Kubernetes minion", func() error {
+		node := &model.Node{
+			KubeID: m.ID,
+			Size:   m.NodeSizes[0],
+		}
+		return p.Core.Nodes.Create(node)
+	})
+
+	provisioner.AddStep("waiting for Kubernetes", func() error {
+		return action.CancellableWaitFor("Kubernetes API and first minion", 20*time.Minute, time.Second, func() (bool, error) {
+			nodes, err := p.Core.K8S(m).Nodes().List()
+			if err != nil {
+				return false, nil
+			}
+			return len(nodes.Items) > 0, nil
+		})
+	})
+
+	if err := provisioner.Run(); err != nil {
+		return err
+	}
+
+	return p.Core.DB.Model(m).Update("ready", true).Error
+}
+
+func (p *Provider) DeleteKube(m *model.Kube) error {
+	c, err := p.client(m)
+	if err != nil {
+		return err
+	}
+	provisioner := &core.Provisioner{Core: p.Core, Kube: m}
+
+	provisioner.AddStep("deleting master", func() error {
+		if kubeConfig(m).MasterID == "" {
+			return nil
+		}
+		if err := c.deleteServer(kubeConfig(m).MasterID); isErrAndNotOpenStackNotFound(err) {
+			return err
+		}
+		// The security group can't be deleted while the server uses it.
+		err := util.WaitFor("Kubernetes master deletion", 5*time.Minute, 3*time.Second, func() (bool, error) {
+			if _, err := c.server(kubeConfig(m).MasterID); err != nil {
+				if isErrAndNotOpenStackNotFound(err) {
+					return false, err
+				}
+				return true, nil
+			}
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+		kubeConfig(m).MasterID = ""
+		return nil
+	})
+
+	provisioner.AddStep("deleting security group", func() error {
+		if kubeConfig(m).SecurityGroupID == "" {
+			return nil
+		}
+		if err := c.deleteSecurityGroup(kubeConfig(m).SecurityGroupID); isErrAndNotOpenStackNotFound(err) {
+			return err
+		}
+		kubeConfig(m).SecurityGroupID = ""
+		return nil
+	})
+
+	return provisioner.Run()
+}
+
+func (p *Provider) CreateNode(m *model.Node, action *core.Action) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	name := m.Kube.Name + "-minion-" + strings.ToLower(util.RandomString(5))
+	server, err := p.createServer(c, m.Kube, name, m.Size, "minion")
+	if err != nil {
+		return err
+	}
+
+	m.ProviderID = server.ID
+	m.Name = name
+	if err := p.Core.DB.Save(m); err != nil {
+		return err
+	}
+
+	err = action.CancellableWaitFor("Node "+name+" to launch", 5*time.Minute, 3*time.Second, func() (bool, error) {
+		server, err = c.server(m.ProviderID)
+		if err != nil {
+			return false, err
+		}
+		if server.Status == "ERROR" {
+			return false, &core.FatalError{Err: fmt.Errorf("Server %s failed to build", name)}
+		}
+		return server.Status == "ACTIVE" && server.IP("fixed") != "", nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.ProviderCreationTimestamp = server.Created
+	m.ExternalIP = publicIP(server)
+	if err := p.Core.DB.Save(m); err != nil {
+		return err
+	}
+
+	// Pools list their members, so the Node has to be added to the pools of
+	// the Kube's Entrypoints.
+	return p.eachEntrypointPool(c, m.Kube, func(lb *LoadBalancer, pool *Pool) error {
+		return addMember(c, lb, pool, m.Kube, server.IP("fixed"))
+	})
+}
+
+func (p *Provider) DeleteNode(m *model.Node) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	server, err := c.server(m.ProviderID)
+	if err != nil {
+		if isErrAndNotOpenStackNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	err = p.eachEntrypointPool(c, m.Kube, func(lb *LoadBalancer, pool *Pool) error {
+		members, err := c.members(pool.ID)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if member.Address == server.IP("fixed") {
+				if err := c.deleteMember(pool.ID, member.ID); isErrAndNotOpenStackNotFound(err) {
+					return err
+				}
+				if err := waitForLoadBalancer(c, lb); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.deleteServer(m.ProviderID); isErrAndNotOpenStackNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) CreateVolume(m *model.Volume, action *core.Action) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%d", m.Kube.Name, m.Name, *m.ID)
+
+	// The volume is found by name, so that retrying doesn't create another.
+	volume, err := c.volumeByName(name)
+	if err != nil {
+		return err
+	}
+	if volume == nil {
+		volume, err = c.createVolume(&Volume{
+			Name:       name,
+			Size:       m.Size,
+			VolumeType: kubeConfig(m.Kube).VolumeType,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	m.ProviderID = volume.ID
+	return p.Core.DB.Save(m)
+}
+
+func (p *Provider) WaitForVolumeAvailable(m *model.Volume, action *core.Action) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	return action.CancellableWaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, volumeAvailable(c, m))
+}
+
+// ResizeVolume extends the Cinder volume, which must be detached to extend
+// with most Cinder backends; the filesystem on it is expanded by the Node it
+// is next mounted on.
+func (p *Provider) ResizeVolume(m *model.Volume, action *core.Action) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	if err := action.CancellableWaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, volumeAvailable(c, m)); err != nil {
+		return err
+	}
+	if err := c.extendVolume(m.ProviderID, m.Size); err != nil {
+		return err
+	}
+	return action.CancellableWaitFor("Volume "+m.Name+" to extend", 10*time.Minute, 3*time.Second, func() (bool, error) {
+		volume, err := c.volume(m.ProviderID)
+		if err != nil {
+			return false, err
+		}
+		if volume.Status == "error_extending" {
+			return false, fmt.Errorf("Extending Volume %s failed", m.Name)
+		}
+		return volume.Status == "available" && volume.Size == m.Size, nil
+	})
+}
+
+func (p *Provider) DeleteVolume(m *model.Volume) error {
+	if m.ProviderID == "" {
+		return nil
+	}
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	if err := util.WaitFor("Volume "+m.Name+" to be available", 5*time.Minute, 3*time.Second, volumeAvailable(c, m)); err != nil {
+		return err
+	}
+	if err := c.deleteVolume(m.ProviderID); isErrAndNotOpenStackNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// KubeVolume is the Cinder volume, which Kubernetes attaches to the Node of
+// the pod.
+func (p *Provider) KubeVolume(m *model.Volume) (*guber.Volume, error) {
+	return &guber.Volume{
+		Cinder: &guber.Cinder{
+			VolumeID: m.ProviderID,
+			FSType:   "ext4",
+		},
+	}, nil
+}
+
+func (p *Provider) CreateEntrypoint(m *model.Entrypoint, action *core.Action) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+
+	// The load balancer is found by name, which is the Entrypoint's ProviderID,
+	// so that retrying doesn't create another.
+	lb, err := c.loadBalancerByName(m.ProviderID)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		lb, err = c.createLoadBalancer(&LoadBalancer{
+			Name:        m.ProviderID,
+			VipSubnetID: kubeConfig(m.Kube).SubnetID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := waitForLoadBalancer(c, lb); err != nil {
+		return err
+	}
+
+	// Save Address
+	m.Address = lb.VipAddress
+	return p.Core.DB.Save(m)
+}
+
+// AddPortToEntrypoint creates a listener for the port, with a pool of the
+// Kube's Nodes on the node port.
+func (p *Provider) AddPortToEntrypoint(m *model.Entrypoint, lbPort int64, nodePort int64) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	lb, err := c.loadBalancerByName(m.ProviderID)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		return fmt.Errorf("Load balancer %s not found", m.ProviderID)
+	}
+	name := portName(m, lbPort)
+
+	listener, err := findListener(c, name)
+	if err != nil {
+		return err
+	}
+	if listener == nil {
+		listener, err = c.createListener(&Listener{
+			Name:           name,
+			LoadBalancerID: lb.ID,
+			Protocol:       "TCP",
+			ProtocolPort:   lbPort,
+		})
+		if err != nil {
+			return err
+		}
+		if err := waitForLoadBalancer(c, lb); err != nil {
+			return err
+		}
+	}
+
+	pool, err := findPool(c, name)
+	if err != nil {
+		return err
+	}
+	if pool == nil {
+		pool, err = c.createPool(&Pool{
+			Name:        name,
+			Description: strconv.FormatInt(nodePort, 10), // read by addMember
+			ListenerID:  listener.ID,
+			Protocol:    "TCP",
+			LBAlgorithm: "ROUND_ROBIN",
+		})
+		if err != nil {
+			return err
+		}
+		if err := waitForLoadBalancer(c, lb); err != nil {
+			return err
+		}
+	}
+
+	var nodes []*model.Node
+	if err := p.Core.DB.Where("kube_id = ?", m.KubeID).Find(&nodes); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.ProviderID == "" {
+			continue
+		}
+		server, err := c.server(node.ProviderID)
+		if err != nil {
+			if isErrAndNotOpenStackNotFound(err) {
+				return err
+			}
+			continue
+		}
+		if err := addMember(c, lb, pool, m.Kube, server.IP("fixed")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) RemovePortFromEntrypoint(m *model.Entrypoint, lbPort int64) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	lb, err := c.loadBalancerByName(m.ProviderID)
+	if err != nil || lb == nil {
+		return err
+	}
+	return removePort(c, lb, portName(m, lbPort))
+}
+
+func (p *Provider) DeleteEntrypoint(m *model.Entrypoint) error {
+	c, err := p.client(m.Kube)
+	if err != nil {
+		return err
+	}
+	lb, err := c.loadBalancerByName(m.ProviderID)
+	if err != nil || lb == nil {
+		return err
+	}
+
+	// Neutron LBaaS doesn't delete a load balancer's listeners and pools with
+	// it, so they go first.
+	listeners, err := loadBalancerListeners(c, lb)
+	if err != nil {
+		return err
+	}
+	for _, listener := range listeners {
+		if err := removePort(c, lb, listener.Name); err != nil {
+			return err
+		}
+	}
+	if err := c.deleteLoadBalancer(lb.ID); isErrAndNotOpenStackNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+// client authenticates for the project and region of the Kube.
+func (p *Provider) client(m *model.Kube) (*client, error) {
+	return authenticate(p.Credentials, kubeConfig(m).TenantName, kubeConfig(m).Region)
+}
+
+func (p *Provider) createServer(c *client, m *model.Kube, name string, size string, role string) (*Server, error) {
+	flavorID, err := c.flavorID(size)
+	if err != nil {
+		return nil, err
+	}
+	userdata, err := p.renderUserdata(role, m)
+	if err != nil {
+		return nil, err
+	}
+	return c.createServer(&ServerCreateRequest{
+		Name:           name,
+		FlavorRef:      flavorID,
+		ImageRef:       kubeConfig(m).ImageID,
+		KeyName:        kubeConfig(m).KeyName,
+		UserData:       base64.StdEncoding.EncodeToString([]byte(userdata)),
+		Networks:       []map[string]string{{"uuid": kubeConfig(m).NetworkID}},
+		SecurityGroups: []map[string]string{{"name": m.Name}},
+		Metadata:       map[string]string{"supergiant-kube": m.Name},
+	})
+}
+
+// eachEntrypointPool calls fn with the load balancer and each pool of each of
+// the Kube's Entrypoints.
+func (p *Provider) eachEntrypointPool(c *client, m *model.Kube, fn func(*LoadBalancer, *Pool) error) error {
+	var entrypoints []*model.Entrypoint
+	if err := p.Core.DB.Where("kube_id = ?", m.ID).Find(&entrypoints); err != nil {
+		return err
+	}
+	for _, entrypoint := range entrypoints {
+		lb, err := c.loadBalancerByName(entrypoint.ProviderID)
+		if err != nil {
+			return err
+		}
+		if lb == nil {
+			continue
+		}
+		pools, err := loadBalancerPools(c, lb)
+		if err != nil {
+			return err
+		}
+		for _, pool := range pools {
+			if err := fn(lb, pool); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// kubeConfig returns the OpenStack config of a Kube.
+func kubeConfig(m *model.Kube) *model.OpenStackKubeConfig {
+	return m.ProviderConfig.(*model.OpenStackKubeConfig)
+}
+
+// renderUserdata renders the generic userdata of the role ("master" or
+// "minion") for a server of the Kube.
+func (p *Provider) renderUserdata(role string, m *model.Kube) (string, error) {
+	return core.RenderUserdata(UserdataDir, "generic_"+role+"_userdata.txt", &core.Userdata{
+		Kube:            m,
+		Zone:            kubeConfig(m).Region,
+		HostnameCommand: `curl --silent http://169.254.169.254/openstack/latest/meta_data.json | python -c 'import json, sys; print json.load(sys.stdin)["name"]'`,
+		Cloud:           "openstack",
+		CloudConfig:     p.cloudConfig(m),
+	})
+}
+
+// cloudConfig is the config of Kubernetes' OpenStack cloud provider, which
+// authenticates as the CloudAccount's user, in the project and region of the
+// Kube.
+func (p *Provider) cloudConfig(m *model.Kube) string {
+	settings := [][2]string{
+		{"auth-url", p.Credentials["auth_url"]},
+		{"username", p.Credentials["username"]},
+		{"password", p.Credentials["password"]},
+		{"domain-name", p.Credentials["domain_name"]},
+		{"tenant-name", kubeConfig(m).TenantName},
+		{"region", kubeConfig(m).Region},
+	}
+	lines := []string{"[Global]"}
+	for _, setting := range settings {
+		if setting[1] == "" {
+			continue
+		}
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(setting[1])
+		lines = append(lines, fmt.Sprintf(`%s = "%s"`, setting[0], value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// publicIP is the floating IP of the server if it has one, or else its fixed
+// IP, which in most private clouds is routable.
+func publicIP(server *Server) string {
+	if ip := server.IP("floating"); ip != "" {
+		return ip
+	}
+	return server.IP("fixed")
+}
+
+// volumeAvailable is a WaitFor func, true when the volume is attached to no
+// server (or doesn't exist).
+func volumeAvailable(c *client, m *model.Volume) func() (bool, error) {
+	return func() (bool, error) {
+		volume, err := c.volume(m.ProviderID)
+		if err != nil {
+			if isErrAndNotOpenStackNotFound(err) {
+				return false, err
+			}
+			return true, nil
+		}
+		if volume.Status == "error" {
+			return false, &core.FatalError{Err: fmt.Errorf("Volume %s is in error state", m.Name)}
+		}
+		return volume.Status == "available", nil
+	}
+}
+
+// waitForLoadBalancer waits for the load balancer to be ACTIVE, which it must
+// be before it can be changed again.
+func waitForLoadBalancer(c *client, lb *LoadBalancer) error {
+	return util.WaitFor("load balancer "+lb.Name+" to be active", 10*time.Minute, 2*time.Second, func() (bool, error) {
+		current, err := c.loadBalancer(lb.ID)
+		if err != nil {
+			return false, err
+		}
+		if current.ProvisioningStatus == "ERROR" {
+			return false, fmt.Errorf("Load balancer %s is in ERROR state", lb.Name)
+		}
+		*lb = *current
+		return lb.ProvisioningStatus == "ACTIVE", nil
+	})
+}
+
+// addMember adds the IP to the pool on the node port in its description.
+func addMember(c *client, lb *LoadBalancer, pool *Pool, m *model.Kube, ip string) error {
+	nodePort, err := strconv.ParseInt(pool.Description, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Pool %s has no node port in its description", pool.Name)
+	}
+	err = c.createMember(pool.ID, &Member{
+		Address:      ip,
+		ProtocolPort: nodePort,
+		SubnetID:     kubeConfig(m).SubnetID,
+	})
+	if isErrAndNotOpenStackConflict(err) {
+		return err
+	}
+	return waitForLoadBalancer(c, lb)
+}
+
+// removePort deletes the pool and listener of the name.
+func removePort(c *client, lb *LoadBalancer, name string) error {
+	pool, err := findPool(c, name)
+	if err != nil {
+		return err
+	}
+	if pool != nil {
+		if err := c.deletePool(pool.ID); isErrAndNotOpenStackNotFound(err) {
+			return err
+		}
+		if err := waitForLoadBalancer(c, lb); err != nil {
+			return err
+		}
+	}
+	listener, err := findListener(c, name)
+	if err != nil {
+		return err
+	}
+	if listener != nil {
+		if err := c.deleteListener(listener.ID); isErrAndNotOpenStackNotFound(err) {
+			return err
+		}
+		return waitForLoadBalancer(c, lb)
+	}
+	return nil
+}
+
+func loadBalancerListeners(c *client, lb *LoadBalancer) (lbListeners []*Listener, err error) {
+	listeners, err := c.listeners()
+	if err != nil {
+		return nil, err
+	}
+	for _, listener := range listeners {
+		for _, ref := range listener.LoadBalancers {
+			if ref.ID == lb.ID {
+				lbListeners = append(lbListeners, listener)
+			}
+		}
+	}
+	return lbListeners, nil
+}
+
+func loadBalancerPools(c *client, lb *LoadBalancer) (lbPools []*Pool, err error) {
+	listeners, err := loadBalancerListeners(c, lb)
+	if err != nil {
+		return nil, err
+	}
+	pools, err := c.pools()
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		for _, ref := range pool.Listeners {
+			for _, listener := range listeners {
+				if ref.ID == listener.ID {
+					lbPools = append(lbPools, pool)
+				}
+			}
+		}
+	}
+	return lbPools, nil
+}
+
+func findListener(c *client, name string) (*Listener, error) {
+	listeners, err := c.listeners()
+	if err != nil {
+		return nil, err
+	}
+	for _, listener := range listeners {
+		if listener.Name == name {
+			return listener, nil
+		}
+	}
+	return nil, nil
+}
+
+func findPool(c *client, name string) (*Pool, error) {
+	pools, err := c.pools()
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		if pool.Name == name {
+			return pool, nil
+		}
+	}
+	return nil, nil
+}
+
+// portName is the name of the listener and pool of the Entrypoint's port.
+func portName(m *model.Entrypoint, lbPort int64) string {
+	return fmt.Sprintf("%s-%d", m.ProviderID, lbPort)
+}
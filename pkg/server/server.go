@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"net/http"
@@ -35,12 +36,12 @@ func New(c *core.Core) (server *Server, err error) {
 		c.Log.Info(c.UIURL())
 	}
 
-	server.primaryHandler = router
+	server.primaryServer = &http.Server{Handler: router}
 
 	if c.SSLEnabled() {
 		server.primaryListener, err = newTLSListener(c.HTTPSPort, c.SSLCertFile, c.SSLKeyFile)
 		if err == nil {
-			server.secondaryHandler = &SecureInfoHandler{c}
+			server.secondaryServer = &http.Server{Handler: &SecureInfoHandler{c}}
 			server.secondaryListener, err = newListener(c.HTTPPort)
 		}
 	} else {
@@ -55,8 +56,8 @@ func New(c *core.Core) (server *Server, err error) {
 type Server struct {
 	Core *core.Core
 
-	primaryHandler   http.Handler
-	secondaryHandler http.Handler
+	primaryServer   *http.Server
+	secondaryServer *http.Server
 
 	primaryListener   net.Listener
 	secondaryListener net.Listener
@@ -65,9 +66,9 @@ type Server struct {
 func (s *Server) Start() error {
 	if s.secondaryListener != nil {
 		// NOTE we just kinda lose the error here, probably should do something
-		go http.Serve(s.secondaryListener, s.secondaryHandler)
+		go s.secondaryServer.Serve(s.secondaryListener)
 	}
-	return http.Serve(s.primaryListener, s.primaryHandler)
+	return s.primaryServer.Serve(s.primaryListener)
 }
 
 func (s *Server) Stop() error {
@@ -79,6 +80,21 @@ func (s *Server) Stop() error {
 	return s.primaryListener.Close()
 }
 
+// Shutdown stops accepting requests, waits for those in progress, and then
+// shuts down Core, all within the deadline of ctx. Start returns
+// http.ErrServerClosed as soon as Shutdown is called.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.secondaryServer != nil {
+		if err := s.secondaryServer.Shutdown(ctx); err != nil {
+			s.Core.Log.Error("Error shutting down HTTP server: ", err)
+		}
+	}
+	if err := s.primaryServer.Shutdown(ctx); err != nil {
+		s.Core.Log.Error("Error shutting down server: ", err)
+	}
+	return s.Core.Shutdown(ctx)
+}
+
 //------------------------------------------------------------------------------
 
 func newListener(port string) (ln net.Listener, err error) {
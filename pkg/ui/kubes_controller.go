@@ -27,12 +27,16 @@ func NewKube(sg *client.Client, w http.ResponseWriter, r *http.Request) error {
 				"m4.2xlarge",
 				"m4.4xlarge",
 			},
-			"aws_config": map[string]interface{}{
-				"region":                 "us-east-1",
-				"availability_zone":      "us-east-1b",
-				"vpc_ip_range":           "172.20.0.0/16",
-				"public_subnet_ip_range": "172.20.0.0/24",
-				"master_private_ip":      "172.20.0.9",
+			"provider_config": map[string]interface{}{
+				"region": "us-east-1",
+				"availability_zones": []map[string]interface{}{
+					{"name": "us-east-1b", "public_subnet_ip_range": "172.20.0.0/24"},
+					{"name": "us-east-1c", "public_subnet_ip_range": "172.20.1.0/24"},
+				},
+				"vpc_ip_range":      "172.20.0.0/16",
+				"master_private_ip": "172.20.0.9",
+				"private_nodes":     false,
+				"bastion_node_size": "",
 			},
 		},
 	})
@@ -64,9 +68,9 @@ func ListKubes(sg *client.Client, w http.ResponseWriter, r *http.Request) error
 			"field": "name",
 		},
 		{
-			"title": "Availability Zone",
+			"title": "Master Availability Zone",
 			"type":  "field_value",
-			"field": "aws_config.availability_zone",
+			"field": "provider_config.availability_zone",
 		},
 		{
 			"title": "Master Size",
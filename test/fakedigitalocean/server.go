@@ -0,0 +1,386 @@
+// Package fakedigitalocean is a fake DigitalOcean API, for testing the
+// DigitalOcean provider without a DigitalOcean account.
+//
+// It keeps droplets, block storage volumes and load balancers in memory.
+// Droplets are active with public and private IPs as soon as they are created,
+// load balancers have an IP, and volume actions complete immediately.
+package fakedigitalocean
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/supergiant/supergiant/pkg/provider/digitalocean"
+)
+
+type Server struct {
+	*httptest.Server
+
+	// Token is the API token requests must have.
+	Token string
+
+	mutex         sync.Mutex
+	droplets      map[int64]*digitalocean.Droplet
+	volumes       map[string]*digitalocean.Volume
+	loadBalancers map[string]*digitalocean.LoadBalancer
+	actions       map[int64]*digitalocean.Action
+
+	lastID int64
+}
+
+// NewServer starts a Server which accepts the given token. Close it when done.
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:         token,
+		droplets:      make(map[int64]*digitalocean.Droplet),
+		volumes:       make(map[string]*digitalocean.Volume),
+		loadBalancers: make(map[string]*digitalocean.LoadBalancer),
+		actions:       make(map[int64]*digitalocean.Action),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/account", s.getAccount).Methods("GET")
+	router.HandleFunc("/droplets", s.createDroplet).Methods("POST")
+	router.HandleFunc("/droplets/{id}", s.getDroplet).Methods("GET")
+	router.HandleFunc("/droplets/{id}", s.deleteDroplet).Methods("DELETE")
+	router.HandleFunc("/volumes", s.createVolume).Methods("POST")
+	router.HandleFunc("/volumes/{id}", s.getVolume).Methods("GET")
+	router.HandleFunc("/volumes/{id}", s.deleteVolume).Methods("DELETE")
+	router.HandleFunc("/volumes/{id}/actions", s.createVolumeAction).Methods("POST")
+	router.HandleFunc("/actions/{id}", s.getAction).Methods("GET")
+	router.HandleFunc("/load_balancers", s.listLoadBalancers).Methods("GET")
+	router.HandleFunc("/load_balancers", s.createLoadBalancer).Methods("POST")
+	router.HandleFunc("/load_balancers/{id}", s.deleteLoadBalancer).Methods("DELETE")
+	router.HandleFunc("/load_balancers/{id}/forwarding_rules", s.addForwardingRules).Methods("POST")
+	router.HandleFunc("/load_balancers/{id}/forwarding_rules", s.removeForwardingRules).Methods("DELETE")
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.Token {
+			writeError(w, 401, "unauthorized", "Unable to authenticate you.")
+			return
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		router.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// Use points the DigitalOcean provider at the Server.
+func (s *Server) Use() {
+	digitalocean.APIURL = s.URL
+}
+
+//------------------------------------------------------------------------------
+
+// Droplet returns the droplet of the ID, or nil.
+func (s *Server) Droplet(id int64) *digitalocean.Droplet {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.droplets[id]
+}
+
+// Droplets returns all droplets.
+func (s *Server) Droplets() (droplets []*digitalocean.Droplet) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, droplet := range s.droplets {
+		droplets = append(droplets, droplet)
+	}
+	return droplets
+}
+
+// Volume returns the volume of the ID, or nil.
+func (s *Server) Volume(id string) *digitalocean.Volume {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.volumes[id]
+}
+
+// LoadBalancer returns the load balancer of the name, or nil.
+func (s *Server) LoadBalancer(name string) *digitalocean.LoadBalancer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, lb := range s.loadBalancers {
+		if lb.Name == name {
+			return lb
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Handlers                                                                   //
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]interface{}{
+		"account": map[string]interface{}{"status": "active"},
+	})
+}
+
+func (s *Server) createDroplet(w http.ResponseWriter, r *http.Request) {
+	req := new(digitalocean.DropletCreateRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Name == "" || req.Region == "" || req.Size == "" || req.Image == "" {
+		writeError(w, 422, "unprocessable_entity", "name, region, size and image are required")
+		return
+	}
+	s.lastID++
+	droplet := &digitalocean.Droplet{
+		ID:        s.lastID,
+		Name:      req.Name,
+		Status:    "active",
+		SizeSlug:  req.Size,
+		CreatedAt: time.Now().UTC(),
+		Tags:      req.Tags,
+	}
+	droplet.Networks.V4 = []*digitalocean.DropletNetwork{
+		{Type: "public", IPAddress: fmt.Sprintf("198.51.100.%d", s.lastID%256)},
+	}
+	if req.PrivateNetworking {
+		droplet.Networks.V4 = append(droplet.Networks.V4, &digitalocean.DropletNetwork{
+			Type: "private", IPAddress: fmt.Sprintf("10.132.0.%d", s.lastID%256),
+		})
+	}
+	s.droplets[droplet.ID] = droplet
+	writeJSON(w, 202, map[string]interface{}{"droplet": droplet})
+}
+
+func (s *Server) getDroplet(w http.ResponseWriter, r *http.Request) {
+	droplet := s.droplets[intVar(r, "id")]
+	if droplet == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, 200, map[string]interface{}{"droplet": droplet})
+}
+
+func (s *Server) deleteDroplet(w http.ResponseWriter, r *http.Request) {
+	id := intVar(r, "id")
+	if s.droplets[id] == nil {
+		writeNotFound(w)
+		return
+	}
+	delete(s.droplets, id)
+	for _, volume := range s.volumes {
+		if len(volume.DropletIDs) > 0 && volume.DropletIDs[0] == id {
+			volume.DropletIDs = []int64{}
+		}
+	}
+	w.WriteHeader(204)
+}
+
+func (s *Server) createVolume(w http.ResponseWriter, r *http.Request) {
+	req := new(digitalocean.VolumeCreateRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	for _, volume := range s.volumes {
+		if volume.Name == req.Name {
+			writeError(w, 409, "conflict", "a volume with that name already exists")
+			return
+		}
+	}
+	s.lastID++
+	volume := &digitalocean.Volume{
+		ID:            fmt.Sprintf("fake-volume-%d", s.lastID),
+		Name:          req.Name,
+		SizeGigabytes: req.SizeGigabytes,
+		DropletIDs:    []int64{},
+	}
+	s.volumes[volume.ID] = volume
+	writeJSON(w, 201, map[string]interface{}{"volume": volume})
+}
+
+func (s *Server) getVolume(w http.ResponseWriter, r *http.Request) {
+	volume := s.volumes[mux.Vars(r)["id"]]
+	if volume == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, 200, map[string]interface{}{"volume": volume})
+}
+
+func (s *Server) deleteVolume(w http.ResponseWriter, r *http.Request) {
+	volume := s.volumes[mux.Vars(r)["id"]]
+	if volume == nil {
+		writeNotFound(w)
+		return
+	}
+	if len(volume.DropletIDs) > 0 {
+		writeError(w, 409, "conflict", "volume is attached to a droplet")
+		return
+	}
+	delete(s.volumes, volume.ID)
+	w.WriteHeader(204)
+}
+
+func (s *Server) createVolumeAction(w http.ResponseWriter, r *http.Request) {
+	volume := s.volumes[mux.Vars(r)["id"]]
+	if volume == nil {
+		writeNotFound(w)
+		return
+	}
+	req := new(digitalocean.VolumeActionRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	switch req.Type {
+	case "resize":
+		if req.SizeGigabytes <= volume.SizeGigabytes {
+			writeError(w, 422, "unprocessable_entity", "new size must be greater than the current size")
+			return
+		}
+		volume.SizeGigabytes = req.SizeGigabytes
+	case "attach":
+		if s.droplets[req.DropletID] == nil {
+			writeNotFound(w)
+			return
+		}
+		if len(volume.DropletIDs) > 0 {
+			writeError(w, 422, "unprocessable_entity", "volume is already attached to a droplet")
+			return
+		}
+		volume.DropletIDs = []int64{req.DropletID}
+	case "detach":
+		if len(volume.DropletIDs) == 0 || volume.DropletIDs[0] != req.DropletID {
+			writeError(w, 422, "unprocessable_entity", "volume is not attached to the droplet")
+			return
+		}
+		volume.DropletIDs = []int64{}
+	default:
+		writeError(w, 422, "unprocessable_entity", "unsupported action "+req.Type)
+		return
+	}
+
+	s.lastID++
+	action := &digitalocean.Action{ID: s.lastID, Type: req.Type, Status: "completed"}
+	s.actions[action.ID] = action
+	writeJSON(w, 202, map[string]interface{}{"action": action})
+}
+
+func (s *Server) getAction(w http.ResponseWriter, r *http.Request) {
+	action := s.actions[intVar(r, "id")]
+	if action == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, 200, map[string]interface{}{"action": action})
+}
+
+func (s *Server) listLoadBalancers(w http.ResponseWriter, r *http.Request) {
+	lbs := []*digitalocean.LoadBalancer{}
+	for _, lb := range s.loadBalancers {
+		lbs = append(lbs, lb)
+	}
+	writeJSON(w, 200, map[string]interface{}{"load_balancers": lbs})
+}
+
+func (s *Server) createLoadBalancer(w http.ResponseWriter, r *http.Request) {
+	lb := new(digitalocean.LoadBalancer)
+	if !readJSON(w, r, lb) {
+		return
+	}
+	if len(lb.ForwardingRules) == 0 {
+		writeError(w, 422, "unprocessable_entity", "at least one forwarding rule is required")
+		return
+	}
+	s.lastID++
+	lb.ID = fmt.Sprintf("fake-lb-%d", s.lastID)
+	lb.IP = fmt.Sprintf("203.0.113.%d", s.lastID%256)
+	lb.Status = "active"
+	s.loadBalancers[lb.ID] = lb
+	writeJSON(w, 202, map[string]interface{}{"load_balancer": lb})
+}
+
+func (s *Server) deleteLoadBalancer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if s.loadBalancers[id] == nil {
+		writeNotFound(w)
+		return
+	}
+	delete(s.loadBalancers, id)
+	w.WriteHeader(204)
+}
+
+func (s *Server) addForwardingRules(w http.ResponseWriter, r *http.Request) {
+	lb := s.loadBalancers[mux.Vars(r)["id"]]
+	if lb == nil {
+		writeNotFound(w)
+		return
+	}
+	req := new(struct {
+		ForwardingRules []*digitalocean.ForwardingRule `json:"forwarding_rules"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	lb.ForwardingRules = append(lb.ForwardingRules, req.ForwardingRules...)
+	w.WriteHeader(204)
+}
+
+func (s *Server) removeForwardingRules(w http.ResponseWriter, r *http.Request) {
+	lb := s.loadBalancers[mux.Vars(r)["id"]]
+	if lb == nil {
+		writeNotFound(w)
+		return
+	}
+	req := new(struct {
+		ForwardingRules []*digitalocean.ForwardingRule `json:"forwarding_rules"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	var rules []*digitalocean.ForwardingRule
+	for _, rule := range lb.ForwardingRules {
+		removed := false
+		for _, remove := range req.ForwardingRules {
+			if *rule == *remove {
+				removed = true
+			}
+		}
+		if !removed {
+			rules = append(rules, rule)
+		}
+	}
+	lb.ForwardingRules = rules
+	w.WriteHeader(204)
+}
+
+//------------------------------------------------------------------------------
+
+func readJSON(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeError(w, 400, "bad_request", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, id string, message string) {
+	writeJSON(w, status, map[string]string{"id": id, "message": message})
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeError(w, 404, "not_found", "The resource you were accessing could not be found.")
+}
+
+func intVar(r *http.Request, name string) int64 {
+	id, _ := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	return id
+}
@@ -0,0 +1,575 @@
+// Package fakegce is a fake Compute Engine API and OAuth2 token endpoint, for
+// testing the GCE provider without a Google Cloud project.
+//
+// It keeps instances, disks, firewalls, addresses, target pools and forwarding
+// rules of a single project in memory. Instances are RUNNING with internal and
+// external IPs as soon as they are inserted, and every operation is DONE when
+// it is returned.
+package fakegce
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/supergiant/supergiant/pkg/provider/gce"
+)
+
+const (
+	clientEmail = "supergiant@fake.iam.gserviceaccount.com"
+	accessToken = "fake-access-token"
+)
+
+type Server struct {
+	*httptest.Server
+
+	// ProjectID is the only project of the Server.
+	ProjectID string
+
+	// Key is the private key of the project's service account.
+	Key *rsa.PrivateKey
+
+	mutex           sync.Mutex
+	instances       map[string]*gce.Instance
+	disks           map[string]*gce.Disk
+	firewalls       map[string]*gce.Firewall
+	addresses       map[string]*gce.Address
+	targetPools     map[string]*gce.TargetPool
+	forwardingRules map[string]*gce.ForwardingRule
+
+	lastID int
+}
+
+// NewServer starts a Server for the project. Close it when done.
+func NewServer(projectID string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		ProjectID:       projectID,
+		Key:             key,
+		instances:       make(map[string]*gce.Instance),
+		disks:           make(map[string]*gce.Disk),
+		firewalls:       make(map[string]*gce.Firewall),
+		addresses:       make(map[string]*gce.Address),
+		targetPools:     make(map[string]*gce.TargetPool),
+		forwardingRules: make(map[string]*gce.ForwardingRule),
+	}
+
+	router := mux.NewRouter()
+	project := router.PathPrefix("/compute/v1/projects/" + projectID).Subrouter()
+	project.HandleFunc("", s.getProject).Methods("GET")
+	project.HandleFunc("/global/firewalls", s.insertFirewall).Methods("POST")
+	project.HandleFunc("/global/firewalls/{name}", s.deleteFirewall).Methods("DELETE")
+	project.HandleFunc("/zones/{zone}/instances", s.insertInstance).Methods("POST")
+	project.HandleFunc("/zones/{zone}/instances/{name}", s.getInstance).Methods("GET")
+	project.HandleFunc("/zones/{zone}/instances/{name}", s.deleteInstance).Methods("DELETE")
+	project.HandleFunc("/zones/{zone}/disks", s.insertDisk).Methods("POST")
+	project.HandleFunc("/zones/{zone}/disks/{name}", s.getDisk).Methods("GET")
+	project.HandleFunc("/zones/{zone}/disks/{name}", s.deleteDisk).Methods("DELETE")
+	project.HandleFunc("/zones/{zone}/disks/{name}/resize", s.resizeDisk).Methods("POST")
+	project.HandleFunc("/regions/{region}/addresses", s.insertAddress).Methods("POST")
+	project.HandleFunc("/regions/{region}/addresses/{name}", s.getAddress).Methods("GET")
+	project.HandleFunc("/regions/{region}/addresses/{name}", s.deleteAddress).Methods("DELETE")
+	project.HandleFunc("/regions/{region}/targetPools", s.insertTargetPool).Methods("POST")
+	project.HandleFunc("/regions/{region}/targetPools/{name}", s.deleteTargetPool).Methods("DELETE")
+	project.HandleFunc("/regions/{region}/targetPools/{name}/addInstance", s.addInstance).Methods("POST")
+	project.HandleFunc("/regions/{region}/targetPools/{name}/removeInstance", s.removeInstance).Methods("POST")
+	project.HandleFunc("/regions/{region}/forwardingRules", s.listForwardingRules).Methods("GET")
+	project.HandleFunc("/regions/{region}/forwardingRules", s.insertForwardingRule).Methods("POST")
+	project.HandleFunc("/regions/{region}/forwardingRules/{name}", s.deleteForwardingRule).Methods("DELETE")
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if r.URL.Path == "/token" {
+			s.token(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			writeError(w, 401, "Invalid Credentials")
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// Use points the GCE provider at the Server.
+func (s *Server) Use() {
+	gce.ComputeURL = s.URL + "/compute/v1"
+}
+
+// ServiceAccountJSON returns the JSON key of a service account of the project
+// with the private key, which authenticates if it is the Server's Key.
+func (s *Server) ServiceAccountJSON(key *rsa.PrivateKey) string {
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	out, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   s.ProjectID,
+		"private_key":  string(pemKey),
+		"client_email": clientEmail,
+		"token_uri":    s.URL + "/token",
+	})
+	return string(out)
+}
+
+//------------------------------------------------------------------------------
+
+// Instance returns the instance of the name, or nil.
+func (s *Server) Instance(name string) *gce.Instance {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.instances[name]
+}
+
+// Disk returns the disk of the name, or nil.
+func (s *Server) Disk(name string) *gce.Disk {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.disks[name]
+}
+
+// Firewall returns the firewall of the name, or nil.
+func (s *Server) Firewall(name string) *gce.Firewall {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.firewalls[name]
+}
+
+// Address returns the address of the name, or nil.
+func (s *Server) Address(name string) *gce.Address {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addresses[name]
+}
+
+// TargetPool returns the target pool of the name, or nil.
+func (s *Server) TargetPool(name string) *gce.TargetPool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.targetPools[name]
+}
+
+// ForwardingRule returns the forwarding rule of the name, or nil.
+func (s *Server) ForwardingRule(name string) *gce.ForwardingRule {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.forwardingRules[name]
+}
+
+// AttachDisk attaches a disk to an instance, as Kubernetes would when mounting
+// it.
+func (s *Server) AttachDisk(diskName string, instanceName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disks[diskName].Users = []string{instanceName}
+}
+
+// DetachDisk detaches a disk from its instance.
+func (s *Server) DetachDisk(diskName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disks[diskName].Users = nil
+}
+
+// AttachVolume and DetachVolume make the Server a fakekube.VolumeAttacher, so
+// that the disks of Pods' GCE persistent disk volumes are attached to the
+// instances of their Nodes.
+func (s *Server) AttachVolume(volumeID string, serverID string) error {
+	s.AttachDisk(volumeID, serverID)
+	return nil
+}
+
+func (s *Server) DetachVolume(volumeID string) error {
+	s.DetachDisk(volumeID)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Handlers                                                                   //
+////////////////////////////////////////////////////////////////////////////////
+
+// token exchanges a JWT assertion signed by the Server's Key for an access
+// token.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		writeJSON(w, 400, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	if err := s.verifyJWT(r.FormValue("assertion")); err != nil {
+		writeJSON(w, 400, map[string]string{"error": "invalid_grant", "error_description": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]string{"name": s.ProjectID})
+}
+
+func (s *Server) insertFirewall(w http.ResponseWriter, r *http.Request) {
+	firewall := new(gce.Firewall)
+	if !readJSON(w, r, firewall) || !s.checkNew(w, firewall.Name, s.firewalls[firewall.Name] != nil) {
+		return
+	}
+	s.firewalls[firewall.Name] = firewall
+	s.writeOperation(w, r)
+}
+
+func (s *Server) deleteFirewall(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.firewalls[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	delete(s.firewalls, name)
+	s.writeOperation(w, r)
+}
+
+func (s *Server) insertInstance(w http.ResponseWriter, r *http.Request) {
+	instance := new(gce.Instance)
+	if !readJSON(w, r, instance) || !s.checkNew(w, instance.Name, s.instances[instance.Name] != nil) {
+		return
+	}
+	if instance.MachineType == "" || len(instance.Disks) == 0 || len(instance.NetworkInterfaces) == 0 {
+		writeError(w, 400, "machineType, disks and networkInterfaces are required")
+		return
+	}
+	s.lastID++
+	now := time.Now().UTC()
+	instance.SelfLink = s.URL + r.URL.Path + "/" + instance.Name
+	instance.Status = "RUNNING"
+	instance.CreationTimestamp = &now
+	for _, iface := range instance.NetworkInterfaces {
+		iface.NetworkIP = fmt.Sprintf("10.128.0.%d", s.lastID%256)
+		for _, config := range iface.AccessConfigs {
+			config.NatIP = fmt.Sprintf("198.51.100.%d", s.lastID%256)
+		}
+	}
+	s.instances[instance.Name] = instance
+	s.writeOperation(w, r)
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.instances[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	writeJSON(w, 200, s.instances[name])
+}
+
+func (s *Server) deleteInstance(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.instances[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	delete(s.instances, name)
+	for _, disk := range s.disks {
+		if len(disk.Users) > 0 && disk.Users[0] == name {
+			disk.Users = nil
+		}
+	}
+	s.writeOperation(w, r)
+}
+
+func (s *Server) insertDisk(w http.ResponseWriter, r *http.Request) {
+	disk := new(gce.Disk)
+	if !readJSON(w, r, disk) || !s.checkNew(w, disk.Name, s.disks[disk.Name] != nil) {
+		return
+	}
+	disk.Status = "READY"
+	s.disks[disk.Name] = disk
+	s.writeOperation(w, r)
+}
+
+func (s *Server) getDisk(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.disks[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	writeJSON(w, 200, s.disks[name])
+}
+
+func (s *Server) deleteDisk(w http.ResponseWriter, r *http.Request) {
+	disk := s.disks[mux.Vars(r)["name"]]
+	if disk == nil {
+		writeNotFound(w, mux.Vars(r)["name"])
+		return
+	}
+	if len(disk.Users) > 0 {
+		writeError(w, 400, "The disk resource '"+disk.Name+"' is already being used by '"+disk.Users[0]+"'")
+		return
+	}
+	delete(s.disks, disk.Name)
+	s.writeOperation(w, r)
+}
+
+func (s *Server) resizeDisk(w http.ResponseWriter, r *http.Request) {
+	disk := s.disks[mux.Vars(r)["name"]]
+	if disk == nil {
+		writeNotFound(w, mux.Vars(r)["name"])
+		return
+	}
+	req := new(struct {
+		SizeGb int64 `json:"sizeGb,string"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.SizeGb <= disk.SizeGb {
+		writeError(w, 400, "Requested disk size cannot be smaller than the current size")
+		return
+	}
+	disk.SizeGb = req.SizeGb
+	s.writeOperation(w, r)
+}
+
+func (s *Server) insertAddress(w http.ResponseWriter, r *http.Request) {
+	address := new(gce.Address)
+	if !readJSON(w, r, address) || !s.checkNew(w, address.Name, s.addresses[address.Name] != nil) {
+		return
+	}
+	s.lastID++
+	address.Address = fmt.Sprintf("203.0.113.%d", s.lastID%256)
+	s.addresses[address.Name] = address
+	s.writeOperation(w, r)
+}
+
+func (s *Server) getAddress(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.addresses[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	writeJSON(w, 200, s.addresses[name])
+}
+
+func (s *Server) deleteAddress(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.addresses[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	for _, rule := range s.forwardingRules {
+		if rule.IPAddress == s.addresses[name].Address {
+			writeError(w, 400, "The address '"+name+"' is being used by '"+rule.Name+"'")
+			return
+		}
+	}
+	delete(s.addresses, name)
+	s.writeOperation(w, r)
+}
+
+func (s *Server) insertTargetPool(w http.ResponseWriter, r *http.Request) {
+	pool := new(gce.TargetPool)
+	if !readJSON(w, r, pool) || !s.checkNew(w, pool.Name, s.targetPools[pool.Name] != nil) {
+		return
+	}
+	pool.SelfLink = s.URL + r.URL.Path + "/" + pool.Name
+	s.targetPools[pool.Name] = pool
+	s.writeOperation(w, r)
+}
+
+func (s *Server) deleteTargetPool(w http.ResponseWriter, r *http.Request) {
+	pool := s.targetPools[mux.Vars(r)["name"]]
+	if pool == nil {
+		writeNotFound(w, mux.Vars(r)["name"])
+		return
+	}
+	for _, rule := range s.forwardingRules {
+		if rule.Target == pool.SelfLink {
+			writeError(w, 400, "The target pool '"+pool.Name+"' is being used by '"+rule.Name+"'")
+			return
+		}
+	}
+	delete(s.targetPools, pool.Name)
+	s.writeOperation(w, r)
+}
+
+func (s *Server) addInstance(w http.ResponseWriter, r *http.Request) {
+	pool := s.targetPools[mux.Vars(r)["name"]]
+	if pool == nil {
+		writeNotFound(w, mux.Vars(r)["name"])
+		return
+	}
+	req := new(instanceReferences)
+	if !readJSON(w, r, req) {
+		return
+	}
+	for _, ref := range req.Instances {
+		pool.Instances = append(pool.Instances, ref.Instance)
+	}
+	s.writeOperation(w, r)
+}
+
+func (s *Server) removeInstance(w http.ResponseWriter, r *http.Request) {
+	pool := s.targetPools[mux.Vars(r)["name"]]
+	if pool == nil {
+		writeNotFound(w, mux.Vars(r)["name"])
+		return
+	}
+	req := new(instanceReferences)
+	if !readJSON(w, r, req) {
+		return
+	}
+	var instances []string
+	for _, instance := range pool.Instances {
+		removed := false
+		for _, ref := range req.Instances {
+			if instance == ref.Instance {
+				removed = true
+			}
+		}
+		if !removed {
+			instances = append(instances, instance)
+		}
+	}
+	pool.Instances = instances
+	s.writeOperation(w, r)
+}
+
+func (s *Server) listForwardingRules(w http.ResponseWriter, r *http.Request) {
+	var rules []*gce.ForwardingRule
+	for _, rule := range s.forwardingRules {
+		rules = append(rules, rule)
+	}
+	writeJSON(w, 200, map[string]interface{}{"items": rules})
+}
+
+func (s *Server) insertForwardingRule(w http.ResponseWriter, r *http.Request) {
+	rule := new(gce.ForwardingRule)
+	if !readJSON(w, r, rule) || !s.checkNew(w, rule.Name, s.forwardingRules[rule.Name] != nil) {
+		return
+	}
+	found := false
+	for _, pool := range s.targetPools {
+		found = found || pool.SelfLink == rule.Target
+	}
+	if !found {
+		writeError(w, 400, "The target '"+rule.Target+"' was not found")
+		return
+	}
+	s.forwardingRules[rule.Name] = rule
+	s.writeOperation(w, r)
+}
+
+func (s *Server) deleteForwardingRule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if s.forwardingRules[name] == nil {
+		writeNotFound(w, name)
+		return
+	}
+	delete(s.forwardingRules, name)
+	s.writeOperation(w, r)
+}
+
+//------------------------------------------------------------------------------
+
+type instanceReferences struct {
+	Instances []*struct {
+		Instance string `json:"instance"`
+	} `json:"instances"`
+}
+
+// checkNew writes a 409 if a resource of the name exists, returning false.
+func (s *Server) checkNew(w http.ResponseWriter, name string, exists bool) bool {
+	if exists {
+		writeError(w, 409, "The resource '"+name+"' already exists")
+		return false
+	}
+	return true
+}
+
+// writeOperation writes a DONE Operation, in the zone or region of the
+// request if it has one.
+func (s *Server) writeOperation(w http.ResponseWriter, r *http.Request) {
+	s.lastID++
+	op := &gce.Operation{
+		Name:   fmt.Sprintf("operation-%d", s.lastID),
+		Status: "DONE",
+	}
+	vars := mux.Vars(r)
+	if zone := vars["zone"]; zone != "" {
+		op.Zone = s.URL + "/compute/v1/projects/" + s.ProjectID + "/zones/" + zone
+	} else if region := vars["region"]; region != "" {
+		op.Region = s.URL + "/compute/v1/projects/" + s.ProjectID + "/regions/" + region
+	}
+	writeJSON(w, 200, op)
+}
+
+func (s *Server) verifyJWT(assertion string) error {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed assertion")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&s.Key.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+		return fmt.Errorf("Invalid JWT Signature.")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	claims := new(struct {
+		Iss string `json:"iss"`
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+	})
+	if err := json.Unmarshal(claimsJSON, claims); err != nil {
+		return err
+	}
+	if claims.Iss != clientEmail || claims.Aud != s.URL+"/token" || claims.Exp < time.Now().Unix() {
+		return fmt.Errorf("Invalid JWT claims.")
+	}
+	return nil
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeError(w, 400, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"code": status, "message": message},
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, name string) {
+	writeError(w, 404, "The resource '"+name+"' was not found")
+}
@@ -0,0 +1,714 @@
+// Package fakekube is a fake Kubernetes API server, for testing the parts of
+// Supergiant that talk to Kubernetes without running a cluster.
+//
+// It keeps Namespaces, Nodes, Services, ReplicationControllers, Pods, Secrets
+// and Events in memory, and emulates just enough of Kubernetes for the guber
+// client: ReplicationControllers create Pods, Pods are scheduled onto Nodes
+// matching their node selector (and become ready) or left Pending with a
+// scheduling Event, NodePort
+// Services are assigned node ports, and Heapster stats and Pod logs are served
+// as set by the test.
+package fakekube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+)
+
+// VolumeAttacher attaches the disks of Pods' Volumes (of the kinds Kubernetes
+// attaches, such as AWS EBS volumes) to the Nodes they are scheduled on, by the
+// Node's external ID. *fake.Cloud is one.
+type VolumeAttacher interface {
+	AttachVolume(volumeID string, serverID string) error
+	DetachVolume(volumeID string) error
+}
+
+type Server struct {
+	*httptest.Server
+
+	// Volumes, when set, has the Volumes of Pods attached when they are
+	// scheduled, and detached when they are deleted.
+	Volumes VolumeAttacher
+
+	// BearerToken, when set, must authenticate every request, as the token of
+	// a service account would.
+	BearerToken string
+
+	mutex     sync.Mutex
+	resources map[string]*resource
+	stats     map[string]*guber.HeapsterStats
+	logs      map[string]string
+
+	lastID       int
+	lastNodePort int
+	lastNode     int
+}
+
+// Event is a guber.Event with the object it is about, which the capacity
+// service selects Events by.
+type Event struct {
+	*guber.Event
+
+	InvolvedObject *ObjectReference `json:"involvedObject"`
+}
+
+type ObjectReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// NewServer starts a Server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		resources: map[string]*resource{
+			"namespaces":             newResource(false, func() interface{} { return new(guber.Namespace) }),
+			"nodes":                  newResource(false, func() interface{} { return new(guber.Node) }),
+			"services":               newResource(true, func() interface{} { return new(guber.Service) }),
+			"replicationcontrollers": newResource(true, func() interface{} { return new(guber.ReplicationController) }),
+			"pods":                   newResource(true, func() interface{} { return new(guber.Pod) }),
+			"secrets":                newResource(true, func() interface{} { return new(guber.Secret) }),
+			"events":                 newResource(true, func() interface{} { return new(Event) }),
+		},
+		stats:        make(map[string]*guber.HeapsterStats),
+		logs:         make(map[string]string),
+		lastNodePort: 30000,
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Host is the address of the Server, as it would be in Kube.MasterPublicIP.
+func (s *Server) Host() string {
+	return s.Listener.Addr().String()
+}
+
+// Client returns a guber.Client for the Server, with the credentials of kube.
+func (s *Server) Client(kube *model.Kube) guber.Client {
+	return guber.NewClient(s.Host(), kube.Username, kube.Password, s.Server.Client())
+}
+
+// Use points Core.K8S at the Server, for every Kube.
+func (s *Server) Use(c *core.Core) {
+	c.K8SProvider = s.Client
+}
+
+//------------------------------------------------------------------------------
+
+// AddNode registers a ready Node for m (by its Name, ProviderID, ExternalIP and
+// AvailabilityZone), as the kubelet of a new server would, and schedules any
+// Pending Pods onto it.
+func (s *Server) AddNode(m *model.Node) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	labels := map[string]string{
+		"kubernetes.io/hostname": m.Name,
+	}
+	if m.AvailabilityZone != "" {
+		labels["failure-domain.beta.kubernetes.io/zone"] = m.AvailabilityZone
+	}
+	node := &guber.Node{
+		Metadata: &guber.Metadata{
+			Name:              m.Name,
+			Labels:            labels,
+			CreationTimestamp: timestamp(),
+		},
+		Spec: &guber.NodeSpec{
+			ExternalID: m.ProviderID,
+		},
+		Status: &guber.NodeStatus{
+			Conditions: []*guber.NodeStatusCondition{
+				{Type: "OutOfDisk", Status: "False"},
+				{Type: "Ready", Status: "True"},
+			},
+			Addresses: []*guber.NodeAddress{
+				{Type: "ExternalIP", Address: m.ExternalIP},
+			},
+		},
+	}
+	s.resources["nodes"].put("", m.Name, node)
+
+	for _, obj := range s.resources["pods"].list("", nil, nil) {
+		if pod := obj.(*guber.Pod); pod.Spec.NodeName == "" {
+			s.schedule(pod)
+		}
+	}
+}
+
+// RemoveNode deregisters a Node, as when its server is terminated.
+func (s *Server) RemoveNode(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resources["nodes"].delete("", name)
+}
+
+// SetPodPhase sets the phase of a Pod; it is ready only when Running.
+func (s *Server) SetPodPhase(namespace string, name string, phase string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	obj := s.resources["pods"].get(namespace, name)
+	if obj == nil {
+		return fmt.Errorf("Pod %s/%s not found", namespace, name)
+	}
+	setPodPhase(obj.(*guber.Pod), phase)
+	return nil
+}
+
+// SetPodLog sets the log of every container of a Pod.
+func (s *Server) SetPodLog(namespace string, name string, log string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.logs[namespace+"/"+name] = log
+}
+
+// SetNodeStats sets the Heapster stats of a Node. Until they are set, they are
+// not found.
+func (s *Server) SetNodeStats(name string, stats *guber.HeapsterStats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stats["nodes/"+name] = stats
+}
+
+// SetPodStats sets the Heapster stats of a Pod.
+func (s *Server) SetPodStats(namespace string, name string, stats *guber.HeapsterStats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stats["namespaces/"+namespace+"/pods/"+name] = stats
+}
+
+// AddEvent records an Event about an object, such as a Pod.
+func (s *Server) AddEvent(namespace string, involvedObjectName string, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.addEvent(namespace, involvedObjectName, message)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Private methods                                                            //
+////////////////////////////////////////////////////////////////////////////////
+
+type resource struct {
+	namespaced bool
+	new        func() interface{}
+	objects    map[string]interface{} // by namespace/name
+}
+
+func newResource(namespaced bool, new func() interface{}) *resource {
+	return &resource{namespaced, new, make(map[string]interface{})}
+}
+
+func (r *resource) get(namespace string, name string) interface{} {
+	return r.objects[namespace+"/"+name]
+}
+
+func (r *resource) put(namespace string, name string, obj interface{}) {
+	r.objects[namespace+"/"+name] = obj
+}
+
+func (r *resource) delete(namespace string, name string) {
+	delete(r.objects, namespace+"/"+name)
+}
+
+// list returns the objects in namespace (or every namespace, when empty) which
+// match the selectors, ordered by namespace and name.
+func (r *resource) list(namespace string, labels map[string]string, fields map[string]string) (objs []interface{}) {
+	var keys []string
+	for key := range r.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		obj := r.objects[key]
+		meta := metadata(obj)
+		if namespace != "" && meta.Namespace != namespace {
+			continue
+		}
+		if !matches(meta.Labels, labels) || !matches(fieldValues(obj), fields) {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.BearerToken != "" && r.Header.Get("Authorization") != "Bearer "+s.BearerToken {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1"), "/")
+	segments := strings.Split(path, "/")
+
+	if segments[0] == "proxy" {
+		s.serveHeapsterStats(w, r, path)
+		return
+	}
+
+	// [namespaces/{namespace}/]{resource}[/{name}[/log]]
+	var namespace string
+	if segments[0] == "namespaces" && len(segments) > 2 {
+		namespace = segments[1]
+		segments = segments[2:]
+	}
+	res, ok := s.resources[segments[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "the server could not find the requested resource")
+		return
+	}
+	var name string
+	if len(segments) > 1 {
+		name = segments[1]
+	}
+
+	switch {
+	case r.Method == "GET" && len(segments) == 3 && segments[0] == "pods" && segments[2] == "log":
+		s.serveLog(w, namespace, name)
+	case r.Method == "GET" && name == "":
+		s.serveList(w, r, res, namespace)
+	case r.Method == "GET":
+		s.serveGet(w, res, namespace, name)
+	case r.Method == "POST" && name == "":
+		s.serveCreate(w, r, segments[0], res, namespace)
+	case r.Method == "PATCH" || r.Method == "PUT":
+		s.serveUpdate(w, r, res, namespace, name)
+	case r.Method == "DELETE" && name != "":
+		s.serveDelete(w, res, namespace, name)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, res *resource, namespace string) {
+	labels := parseSelector(r.URL.Query().Get("labelSelector"))
+	fields := parseSelector(r.URL.Query().Get("fieldSelector"))
+	items := res.list(namespace, labels, fields)
+	if items == nil {
+		items = []interface{}{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func (s *Server) serveGet(w http.ResponseWriter, res *resource, namespace string, name string) {
+	obj := res.get(namespace, name)
+	if obj == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%q not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func (s *Server) serveCreate(w http.ResponseWriter, r *http.Request, kind string, res *resource, namespace string) {
+	obj := res.new()
+	if err := readJSON(r, obj); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	meta := metadata(obj)
+	if meta == nil || meta.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "metadata.name is required")
+		return
+	}
+	if res.namespaced && s.resources["namespaces"].get("", namespace) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("namespaces %q not found", namespace))
+		return
+	}
+	if res.get(namespace, meta.Name) != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s %q already exists", kind, meta.Name))
+		return
+	}
+
+	meta.Namespace = namespace
+	meta.CreationTimestamp = timestamp()
+	res.put(namespace, meta.Name, obj)
+
+	switch obj := obj.(type) {
+	case *guber.Service:
+		s.assignServicePorts(obj)
+	case *guber.ReplicationController:
+		s.reconcile(obj)
+	case *guber.Pod:
+		s.schedule(obj)
+	}
+	writeJSON(w, http.StatusCreated, obj)
+}
+
+func (s *Server) serveUpdate(w http.ResponseWriter, r *http.Request, res *resource, namespace string, name string) {
+	existing := res.get(namespace, name)
+	if existing == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%q not found", name))
+		return
+	}
+
+	// NOTE guber always sends the whole object, so the patch replaces it.
+	obj := res.new()
+	if err := readJSON(r, obj); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	meta := metadata(obj)
+	if meta == nil {
+		writeError(w, http.StatusUnprocessableEntity, "metadata is required")
+		return
+	}
+	meta.Name = name
+	meta.Namespace = namespace
+	meta.CreationTimestamp = metadata(existing).CreationTimestamp
+	res.put(namespace, name, obj)
+
+	switch obj := obj.(type) {
+	case *guber.Service:
+		s.assignServicePorts(obj)
+	case *guber.ReplicationController:
+		s.reconcile(obj)
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func (s *Server) serveDelete(w http.ResponseWriter, res *resource, namespace string, name string) {
+	obj := res.get(namespace, name)
+	if obj == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%q not found", name))
+		return
+	}
+	res.delete(namespace, name)
+
+	switch obj := obj.(type) {
+	case *guber.Namespace:
+		for _, res := range s.resources {
+			if !res.namespaced {
+				continue
+			}
+			for _, obj := range res.list(name, nil, nil) {
+				if pod, ok := obj.(*guber.Pod); ok {
+					s.detachVolumes(pod)
+				}
+				res.delete(name, metadata(obj).Name)
+			}
+		}
+	case *guber.Pod:
+		s.detachVolumes(obj)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "Status", "status": "Success"})
+}
+
+func (s *Server) serveLog(w http.ResponseWriter, namespace string, name string) {
+	if s.resources["pods"].get(namespace, name) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("pods %q not found", name))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(s.logs[namespace+"/"+name]))
+}
+
+// serveHeapsterStats serves
+// proxy/namespaces/kube-system/services/heapster/api/v1/model/{nodes/{name},namespaces/{namespace}/pods/{name}}/stats
+func (s *Server) serveHeapsterStats(w http.ResponseWriter, r *http.Request, path string) {
+	const prefix = "proxy/namespaces/kube-system/services/heapster/api/v1/model/"
+	if r.Method != "GET" || !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, "/stats") {
+		writeError(w, http.StatusNotFound, "the server could not find the requested resource")
+		return
+	}
+	key := strings.TrimSuffix(strings.TrimPrefix(path, prefix), "/stats")
+	stats, ok := s.stats[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no metrics for "+key)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+//------------------------------------------------------------------------------
+
+// reconcile creates or deletes Pods of a ReplicationController until it has
+// as many as its replicas.
+func (s *Server) reconcile(rc *guber.ReplicationController) {
+	pods := s.resources["pods"]
+	existing := pods.list(rc.Metadata.Namespace, rc.Spec.Selector, nil)
+
+	for i := len(existing); i < rc.Spec.Replicas; i++ {
+		pod := new(guber.Pod)
+		copyJSON(rc.Spec.Template, pod)
+		if pod.Metadata == nil {
+			pod.Metadata = new(guber.Metadata)
+		}
+		s.lastID++
+		pod.Metadata.Name = fmt.Sprintf("%s-%05x", rc.Metadata.Name, s.lastID)
+		pod.Metadata.Namespace = rc.Metadata.Namespace
+		pod.Metadata.CreationTimestamp = timestamp()
+		pods.put(pod.Metadata.Namespace, pod.Metadata.Name, pod)
+		s.schedule(pod)
+	}
+	for i := rc.Spec.Replicas; i < len(existing); i++ {
+		pod := existing[i].(*guber.Pod)
+		s.detachVolumes(pod)
+		pods.delete(pod.Metadata.Namespace, pod.Metadata.Name)
+	}
+
+	rc.Status = &guber.ReplicationControllerStatus{Replicas: rc.Spec.Replicas}
+}
+
+// schedule places a Pod onto the next ready Node, attaching its Volumes, and
+// starts it. Without any Nodes, the Pod is left Pending with an Event like the
+// one the Kubernetes scheduler records.
+func (s *Server) schedule(pod *guber.Pod) {
+	if pod.Spec == nil {
+		pod.Spec = new(guber.PodSpec)
+	}
+
+	all := s.resources["nodes"].list("", nil, nil)
+	if len(all) == 0 {
+		setPodPhase(pod, "Pending")
+		s.addEvent(pod.Metadata.Namespace, pod.Metadata.Name, "FailedScheduling: no nodes available to schedule pods")
+		return
+	}
+	var nodes []*guber.Node
+	for _, obj := range all {
+		if node := obj.(*guber.Node); matches(node.Metadata.Labels, pod.Spec.NodeSelector) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		setPodPhase(pod, "Pending")
+		s.addEvent(pod.Metadata.Namespace, pod.Metadata.Name, "FailedScheduling: pod failed to fit in any node, MatchNodeSelector")
+		return
+	}
+	s.lastNode++
+	node := nodes[s.lastNode%len(nodes)]
+	pod.Spec.NodeName = node.Metadata.Name
+
+	if s.Volumes != nil {
+		for _, volume := range pod.Spec.Volumes {
+			id := diskID(volume)
+			if id == "" {
+				continue
+			}
+			if err := s.Volumes.AttachVolume(id, node.Spec.ExternalID); err != nil {
+				setPodPhase(pod, "Pending")
+				s.addEvent(pod.Metadata.Namespace, pod.Metadata.Name, "FailedMount: "+err.Error())
+				return
+			}
+		}
+	}
+	setPodPhase(pod, "Running")
+}
+
+func (s *Server) detachVolumes(pod *guber.Pod) {
+	if s.Volumes == nil || pod.Spec == nil || pod.Spec.NodeName == "" {
+		return
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if id := diskID(volume); id != "" {
+			s.Volumes.DetachVolume(id)
+		}
+	}
+}
+
+// diskID returns the ID of the disk of a Volume, for the kinds Kubernetes
+// attaches to the Node of the Pod. Other kinds (such as host paths, whose
+// disks the provider attaches) have none.
+func diskID(volume *guber.Volume) string {
+	switch {
+	case volume.AwsElasticBlockStore != nil:
+		return volume.AwsElasticBlockStore.VolumeID
+	case volume.GCEPersistentDisk != nil:
+		return volume.GCEPersistentDisk.PDName
+	case volume.Cinder != nil:
+		return volume.Cinder.VolumeID
+	}
+	return ""
+}
+
+// assignServicePorts gives a Service a cluster IP and, for NodePort Services,
+// a node port for each port that doesn't have one.
+func (s *Server) assignServicePorts(svc *guber.Service) {
+	if svc.Spec == nil {
+		svc.Spec = new(guber.ServiceSpec)
+	}
+	if svc.Spec.ClusterIP == "" {
+		s.lastID++
+		svc.Spec.ClusterIP = fmt.Sprintf("10.3.%d.%d", s.lastID/256%256, s.lastID%256)
+	}
+	if svc.Spec.Type != "NodePort" {
+		return
+	}
+	for _, port := range svc.Spec.Ports {
+		if port.NodePort == 0 {
+			s.lastNodePort++
+			port.NodePort = s.lastNodePort
+		}
+	}
+}
+
+func (s *Server) addEvent(namespace string, involvedObjectName string, message string) {
+	s.lastID++
+	event := &Event{
+		Event: &guber.Event{
+			Metadata: &guber.Metadata{
+				Name:              fmt.Sprintf("%s.%05x", involvedObjectName, s.lastID),
+				Namespace:         namespace,
+				CreationTimestamp: timestamp(),
+			},
+			Message: message,
+			Count:   1,
+		},
+		InvolvedObject: &ObjectReference{
+			Namespace: namespace,
+			Name:      involvedObjectName,
+		},
+	}
+	s.resources["events"].put(namespace, event.Metadata.Name, event)
+}
+
+//------------------------------------------------------------------------------
+
+func metadata(obj interface{}) *guber.Metadata {
+	switch obj := obj.(type) {
+	case *guber.Namespace:
+		return obj.Metadata
+	case *guber.Node:
+		return obj.Metadata
+	case *guber.Service:
+		return obj.Metadata
+	case *guber.ReplicationController:
+		return obj.Metadata
+	case *guber.Pod:
+		return obj.Metadata
+	case *guber.Secret:
+		return obj.Metadata
+	case *Event:
+		if obj.Event == nil {
+			return nil
+		}
+		return obj.Metadata
+	}
+	panic(fmt.Sprintf("fakekube: unknown object %T", obj))
+}
+
+// fieldValues are the fields of obj that may be used in a field selector.
+func fieldValues(obj interface{}) map[string]string {
+	meta := metadata(obj)
+	values := map[string]string{
+		"metadata.name":      meta.Name,
+		"metadata.namespace": meta.Namespace,
+	}
+	switch obj := obj.(type) {
+	case *guber.Pod:
+		values["spec.nodeName"] = obj.Spec.NodeName
+		values["status.phase"] = obj.Status.Phase
+	case *Event:
+		values["involvedObject.name"] = obj.InvolvedObject.Name
+		values["involvedObject.namespace"] = obj.InvolvedObject.Namespace
+	}
+	return values
+}
+
+func setPodPhase(pod *guber.Pod, phase string) {
+	ready := "False"
+	if phase == "Running" {
+		ready = "True"
+	}
+	// NOTE guber's Pod.IsReady expects a Ready condition whenever there are
+	// conditions.
+	pod.Status = &guber.PodStatus{
+		Phase: phase,
+		Conditions: []*guber.PodStatusCondition{
+			{Type: "Ready", Status: ready},
+		},
+	}
+	if pod.Spec == nil {
+		return
+	}
+	for _, container := range pod.Spec.Containers {
+		status := &guber.ContainerStatus{
+			Name:  container.Name,
+			Image: container.Image,
+			Ready: ready == "True",
+			State: new(guber.ContainerState),
+		}
+		if phase == "Running" {
+			status.State.Running = &guber.ContainerStateRunning{StartedAt: timestamp()}
+		}
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, status)
+	}
+}
+
+// parseSelector parses label or field selectors of the form "a=b,c=d".
+func parseSelector(str string) map[string]string {
+	if str == "" {
+		return nil
+	}
+	selector := make(map[string]string)
+	for _, requirement := range strings.Split(str, ",") {
+		parts := strings.SplitN(requirement, "=", 2)
+		if len(parts) == 2 {
+			selector[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return selector
+}
+
+func matches(values map[string]string, selector map[string]string) bool {
+	for key, value := range selector {
+		if values[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func copyJSON(from interface{}, to interface{}) {
+	data, err := json.Marshal(from)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		panic(err)
+	}
+}
+
+func readJSON(r *http.Request, obj interface{}) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, obj)
+}
+
+func writeJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(obj)
+}
+
+// writeError responds with a Kubernetes Status.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"kind":    "Status",
+		"status":  "Failure",
+		"message": message,
+		"code":    status,
+	})
+}
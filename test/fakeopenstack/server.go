@@ -0,0 +1,801 @@
+// Package fakeopenstack is a fake OpenStack cloud, for testing the OpenStack
+// provider without one.
+//
+// It serves Keystone v3 password authentication, and the parts of Nova,
+// Cinder, Neutron and LBaaS v2 the provider uses, for a single user, project
+// and region, keeping everything in memory. Servers are ACTIVE with a fixed IP
+// as soon as they are created, volumes are available (and extended)
+// immediately, and load balancers are ACTIVE from their first GET.
+package fakeopenstack
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/supergiant/supergiant/pkg/provider/openstack"
+)
+
+const Region = "RegionOne"
+
+type Server struct {
+	*httptest.Server
+
+	// Username, Password and ProjectName are of the only user and project.
+	Username    string
+	Password    string
+	ProjectName string
+
+	mutex          sync.Mutex
+	servers        map[string]*openstack.Server
+	userData       map[string]string
+	volumes        map[string]*openstack.Volume
+	securityGroups map[string]*openstack.SecurityGroup
+	rules          []*openstack.SecurityGroupRule
+	loadBalancers  map[string]*openstack.LoadBalancer
+	listeners      map[string]*openstack.Listener
+	pools          map[string]*openstack.Pool
+	members        map[string][]*openstack.Member
+
+	lastID int
+}
+
+// NewServer starts a Server for the user and project. Close it when done.
+func NewServer(username string, password string, projectName string) *Server {
+	s := &Server{
+		Username:       username,
+		Password:       password,
+		ProjectName:    projectName,
+		servers:        make(map[string]*openstack.Server),
+		userData:       make(map[string]string),
+		volumes:        make(map[string]*openstack.Volume),
+		securityGroups: make(map[string]*openstack.SecurityGroup),
+		loadBalancers:  make(map[string]*openstack.LoadBalancer),
+		listeners:      make(map[string]*openstack.Listener),
+		pools:          make(map[string]*openstack.Pool),
+		members:        make(map[string][]*openstack.Member),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/identity/v3/auth/tokens", s.createToken).Methods("POST")
+
+	compute := router.PathPrefix("/compute/v2.1").Subrouter()
+	compute.HandleFunc("/flavors", s.listFlavors).Methods("GET")
+	compute.HandleFunc("/servers", s.createServer).Methods("POST")
+	compute.HandleFunc("/servers/{id}", s.getServer).Methods("GET")
+	compute.HandleFunc("/servers/{id}", s.deleteServer).Methods("DELETE")
+
+	volume := router.PathPrefix("/volume/v3/project-id").Subrouter()
+	volume.HandleFunc("/volumes", s.createVolume).Methods("POST")
+	volume.HandleFunc("/volumes/detail", s.listVolumes).Methods("GET")
+	volume.HandleFunc("/volumes/{id}", s.getVolume).Methods("GET")
+	volume.HandleFunc("/volumes/{id}", s.deleteVolume).Methods("DELETE")
+	volume.HandleFunc("/volumes/{id}/action", s.volumeAction).Methods("POST")
+
+	network := router.PathPrefix("/network/v2.0").Subrouter()
+	network.HandleFunc("/security-groups", s.listSecurityGroups).Methods("GET")
+	network.HandleFunc("/security-groups", s.createSecurityGroup).Methods("POST")
+	network.HandleFunc("/security-groups/{id}", s.deleteSecurityGroup).Methods("DELETE")
+	network.HandleFunc("/security-group-rules", s.createSecurityGroupRule).Methods("POST")
+	network.HandleFunc("/lbaas/loadbalancers", s.listLoadBalancers).Methods("GET")
+	network.HandleFunc("/lbaas/loadbalancers", s.createLoadBalancer).Methods("POST")
+	network.HandleFunc("/lbaas/loadbalancers/{id}", s.getLoadBalancer).Methods("GET")
+	network.HandleFunc("/lbaas/loadbalancers/{id}", s.deleteLoadBalancer).Methods("DELETE")
+	network.HandleFunc("/lbaas/listeners", s.listListeners).Methods("GET")
+	network.HandleFunc("/lbaas/listeners", s.createListener).Methods("POST")
+	network.HandleFunc("/lbaas/listeners/{id}", s.deleteListener).Methods("DELETE")
+	network.HandleFunc("/lbaas/pools", s.listPools).Methods("GET")
+	network.HandleFunc("/lbaas/pools", s.createPool).Methods("POST")
+	network.HandleFunc("/lbaas/pools/{id}", s.deletePool).Methods("DELETE")
+	network.HandleFunc("/lbaas/pools/{id}/members", s.listMembers).Methods("GET")
+	network.HandleFunc("/lbaas/pools/{id}/members", s.createMember).Methods("POST")
+	network.HandleFunc("/lbaas/pools/{id}/members/{member_id}", s.deleteMember).Methods("DELETE")
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if !strings.HasPrefix(r.URL.Path, "/identity/") && r.Header.Get("X-Auth-Token") != s.token() {
+			writeError(w, 401, "Unauthorized", "The request you have made requires authentication.")
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// Credentials are the CloudAccount credentials of the Server's user.
+func (s *Server) Credentials() map[string]string {
+	return map[string]string{
+		"auth_url": s.URL + "/identity/v3",
+		"username": s.Username,
+		"password": s.Password,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ServerByName returns the server of the name, or nil.
+func (s *Server) ServerByName(name string) *openstack.Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, server := range s.servers {
+		if server.Name == name {
+			return server
+		}
+	}
+	return nil
+}
+
+// UserData returns the decoded user data the server of the ID was created
+// with.
+func (s *Server) UserData(id string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.userData[id]
+}
+
+// Volume returns the volume of the ID, or nil.
+func (s *Server) Volume(id string) *openstack.Volume {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.volumes[id]
+}
+
+// SecurityGroup returns the security group of the name, or nil.
+func (s *Server) SecurityGroup(name string) *openstack.SecurityGroup {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, group := range s.securityGroups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// SecurityGroupRules returns the rules of the security group of the ID.
+func (s *Server) SecurityGroupRules(id string) (rules []*openstack.SecurityGroupRule) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, rule := range s.rules {
+		if rule.SecurityGroupID == id {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// LoadBalancer returns the load balancer of the name, or nil.
+func (s *Server) LoadBalancer(name string) *openstack.LoadBalancer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, lb := range s.loadBalancers {
+		if lb.Name == name {
+			return lb
+		}
+	}
+	return nil
+}
+
+// Listener returns the listener of the name, or nil.
+func (s *Server) Listener(name string) *openstack.Listener {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, listener := range s.listeners {
+		if listener.Name == name {
+			return listener
+		}
+	}
+	return nil
+}
+
+// Members returns the members of the pool of the name.
+func (s *Server) Members(poolName string) []*openstack.Member {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, pool := range s.pools {
+		if pool.Name == poolName {
+			return s.members[pool.ID]
+		}
+	}
+	return nil
+}
+
+// AddSecurityGroup adds a security group, as provisioning a Kube of the name
+// would.
+func (s *Server) AddSecurityGroup(name string) *openstack.SecurityGroup {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	group := &openstack.SecurityGroup{ID: s.newID("sg"), Name: name}
+	s.securityGroups[group.ID] = group
+	return group
+}
+
+// AttachVolume attaches a volume to a server, as Kubernetes' OpenStack cloud
+// provider would when mounting it. With DetachVolume, it makes the Server a
+// fakekube.VolumeAttacher.
+func (s *Server) AttachVolume(volumeID string, serverID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.volumes[volumeID] == nil {
+		return fmt.Errorf("Volume %s could not be found", volumeID)
+	}
+	s.volumes[volumeID].Status = "in-use"
+	s.volumes[volumeID].Attachments = []map[string]string{{"server_id": serverID}}
+	return nil
+}
+
+// DetachVolume detaches a volume from its server.
+func (s *Server) DetachVolume(volumeID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.volumes[volumeID] == nil {
+		return fmt.Errorf("Volume %s could not be found", volumeID)
+	}
+	s.volumes[volumeID].Status = "available"
+	s.volumes[volumeID].Attachments = nil
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Handlers                                                                   //
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Auth struct {
+			Identity struct {
+				Password struct {
+					User struct {
+						Name     string `json:"name"`
+						Password string `json:"password"`
+					} `json:"user"`
+				} `json:"password"`
+			} `json:"identity"`
+			Scope *struct {
+				Project struct {
+					Name string `json:"name"`
+				} `json:"project"`
+			} `json:"scope"`
+		} `json:"auth"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	user := req.Auth.Identity.Password.User
+	if user.Name != s.Username || user.Password != s.Password {
+		writeError(w, 401, "Unauthorized", "The request you have made requires authentication.")
+		return
+	}
+
+	token := map[string]interface{}{"expires_at": time.Now().Add(time.Hour).UTC()}
+	if req.Auth.Scope != nil {
+		if req.Auth.Scope.Project.Name != s.ProjectName {
+			writeError(w, 401, "Unauthorized", "The request you have made requires authentication.")
+			return
+		}
+		token["catalog"] = []map[string]interface{}{
+			s.catalogEntry("compute", "/compute/v2.1"),
+			s.catalogEntry("volumev3", "/volume/v3/project-id"),
+			s.catalogEntry("network", "/network"),
+		}
+	}
+	w.Header().Set("X-Subject-Token", s.token())
+	writeJSON(w, 201, map[string]interface{}{"token": token})
+}
+
+func (s *Server) listFlavors(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]interface{}{
+		"flavors": []*openstack.Flavor{
+			{ID: "2", Name: "m1.small"},
+			{ID: "3", Name: "m1.medium"},
+			{ID: "4", Name: "m1.large"},
+		},
+	})
+}
+
+func (s *Server) createServer(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Server *openstack.ServerCreateRequest `json:"server"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Server.Name == "" || req.Server.FlavorRef == "" || req.Server.ImageRef == "" || len(req.Server.Networks) == 0 {
+		writeError(w, 400, "badRequest", "name, flavorRef, imageRef and networks are required")
+		return
+	}
+	for _, sg := range req.Server.SecurityGroups {
+		if s.findSecurityGroup(sg["name"]) == nil {
+			writeError(w, 400, "badRequest", "Unable to find security_group with name '"+sg["name"]+"'")
+			return
+		}
+	}
+	id := s.newID("server")
+	s.servers[id] = &openstack.Server{
+		ID:      id,
+		Name:    req.Server.Name,
+		Status:  "ACTIVE",
+		Created: time.Now().UTC().Truncate(time.Second),
+		Addresses: map[string][]*openstack.ServerAddress{
+			"private": {{Addr: fmt.Sprintf("10.0.0.%d", s.lastID%256), Version: 4, Type: "fixed"}},
+		},
+		Metadata: req.Server.Metadata,
+	}
+	userData, _ := base64.StdEncoding.DecodeString(req.Server.UserData)
+	s.userData[id] = string(userData)
+	writeJSON(w, 202, map[string]interface{}{"server": map[string]string{"id": id}})
+}
+
+func (s *Server) getServer(w http.ResponseWriter, r *http.Request) {
+	server := s.servers[mux.Vars(r)["id"]]
+	if server == nil {
+		writeNotFound(w, "itemNotFound", "Instance could not be found.")
+		return
+	}
+	writeJSON(w, 200, map[string]interface{}{"server": server})
+}
+
+func (s *Server) deleteServer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if s.servers[id] == nil {
+		writeNotFound(w, "itemNotFound", "Instance could not be found.")
+		return
+	}
+	delete(s.servers, id)
+	for _, volume := range s.volumes {
+		if len(volume.Attachments) > 0 && volume.Attachments[0]["server_id"] == id {
+			volume.Status = "available"
+			volume.Attachments = nil
+		}
+	}
+	w.WriteHeader(204)
+}
+
+func (s *Server) createVolume(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Volume *openstack.Volume `json:"volume"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Volume.Size < 1 {
+		writeError(w, 400, "badRequest", "size must be a positive integer")
+		return
+	}
+	volume := req.Volume
+	volume.ID = s.newID("volume")
+	volume.Status = "available"
+	s.volumes[volume.ID] = volume
+	writeJSON(w, 202, map[string]interface{}{"volume": volume})
+}
+
+func (s *Server) listVolumes(w http.ResponseWriter, r *http.Request) {
+	volumes := []*openstack.Volume{}
+	for _, volume := range s.volumes {
+		if name := r.URL.Query().Get("name"); name == "" || volume.Name == name {
+			volumes = append(volumes, volume)
+		}
+	}
+	writeJSON(w, 200, map[string]interface{}{"volumes": volumes})
+}
+
+func (s *Server) getVolume(w http.ResponseWriter, r *http.Request) {
+	volume := s.volumes[mux.Vars(r)["id"]]
+	if volume == nil {
+		writeNotFound(w, "itemNotFound", "Volume could not be found.")
+		return
+	}
+	writeJSON(w, 200, map[string]interface{}{"volume": volume})
+}
+
+func (s *Server) deleteVolume(w http.ResponseWriter, r *http.Request) {
+	volume := s.volumes[mux.Vars(r)["id"]]
+	if volume == nil {
+		writeNotFound(w, "itemNotFound", "Volume could not be found.")
+		return
+	}
+	if volume.Status != "available" {
+		writeError(w, 400, "badRequest", "Volume status must be available or error")
+		return
+	}
+	delete(s.volumes, volume.ID)
+	w.WriteHeader(202)
+}
+
+func (s *Server) volumeAction(w http.ResponseWriter, r *http.Request) {
+	volume := s.volumes[mux.Vars(r)["id"]]
+	if volume == nil {
+		writeNotFound(w, "itemNotFound", "Volume could not be found.")
+		return
+	}
+	req := new(struct {
+		Extend *struct {
+			NewSize int `json:"new_size"`
+		} `json:"os-extend"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Extend == nil {
+		writeError(w, 400, "badRequest", "unsupported action")
+		return
+	}
+	if volume.Status != "available" {
+		writeError(w, 400, "badRequest", "Volume status must be available to extend, but current status is: "+volume.Status)
+		return
+	}
+	if req.Extend.NewSize <= volume.Size {
+		writeError(w, 400, "badRequest", "New size for extend must be greater than current size.")
+		return
+	}
+	volume.Size = req.Extend.NewSize
+	w.WriteHeader(202)
+}
+
+func (s *Server) listSecurityGroups(w http.ResponseWriter, r *http.Request) {
+	groups := []*openstack.SecurityGroup{}
+	for _, group := range s.securityGroups {
+		if name := r.URL.Query().Get("name"); name == "" || group.Name == name {
+			groups = append(groups, group)
+		}
+	}
+	writeJSON(w, 200, map[string]interface{}{"security_groups": groups})
+}
+
+func (s *Server) createSecurityGroup(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		SecurityGroup *openstack.SecurityGroup `json:"security_group"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	group := req.SecurityGroup
+	group.ID = s.newID("sg")
+	s.securityGroups[group.ID] = group
+	writeJSON(w, 201, map[string]interface{}{"security_group": group})
+}
+
+func (s *Server) deleteSecurityGroup(w http.ResponseWriter, r *http.Request) {
+	group := s.securityGroups[mux.Vars(r)["id"]]
+	if group == nil {
+		writeNotFound(w, "NotFound", "Security group could not be found.")
+		return
+	}
+	for _, server := range s.servers {
+		if server.Metadata["supergiant-kube"] == group.Name {
+			writeError(w, 409, "SecurityGroupInUse", "Security Group "+group.ID+" in use.")
+			return
+		}
+	}
+	delete(s.securityGroups, group.ID)
+	var rules []*openstack.SecurityGroupRule
+	for _, rule := range s.rules {
+		if rule.SecurityGroupID != group.ID {
+			rules = append(rules, rule)
+		}
+	}
+	s.rules = rules
+	w.WriteHeader(204)
+}
+
+func (s *Server) createSecurityGroupRule(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Rule *openstack.SecurityGroupRule `json:"security_group_rule"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if s.securityGroups[req.Rule.SecurityGroupID] == nil {
+		writeNotFound(w, "NotFound", "Security group could not be found.")
+		return
+	}
+	for _, rule := range s.rules {
+		if *rule == *req.Rule {
+			writeError(w, 409, "SecurityGroupRuleExists", "Security group rule already exists.")
+			return
+		}
+	}
+	s.rules = append(s.rules, req.Rule)
+	writeJSON(w, 201, map[string]interface{}{"security_group_rule": req.Rule})
+}
+
+func (s *Server) listLoadBalancers(w http.ResponseWriter, r *http.Request) {
+	lbs := []*openstack.LoadBalancer{}
+	for _, lb := range s.loadBalancers {
+		if name := r.URL.Query().Get("name"); name == "" || lb.Name == name {
+			lbs = append(lbs, lb)
+		}
+	}
+	writeJSON(w, 200, map[string]interface{}{"loadbalancers": lbs})
+}
+
+func (s *Server) createLoadBalancer(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		LoadBalancer *openstack.LoadBalancer `json:"loadbalancer"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.LoadBalancer.VipSubnetID == "" {
+		writeError(w, 400, "BadRequest", "vip_subnet_id is required")
+		return
+	}
+	lb := req.LoadBalancer
+	lb.ID = s.newID("lb")
+	lb.VipAddress = fmt.Sprintf("10.0.0.%d", s.lastID%256)
+	lb.ProvisioningStatus = "PENDING_CREATE"
+	s.loadBalancers[lb.ID] = lb
+	writeJSON(w, 201, map[string]interface{}{"loadbalancer": lb})
+}
+
+func (s *Server) getLoadBalancer(w http.ResponseWriter, r *http.Request) {
+	lb := s.loadBalancers[mux.Vars(r)["id"]]
+	if lb == nil {
+		writeNotFound(w, "NotFound", "Load balancer could not be found.")
+		return
+	}
+	lb.ProvisioningStatus = "ACTIVE"
+	writeJSON(w, 200, map[string]interface{}{"loadbalancer": lb})
+}
+
+func (s *Server) deleteLoadBalancer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if s.loadBalancers[id] == nil {
+		writeNotFound(w, "NotFound", "Load balancer could not be found.")
+		return
+	}
+	for _, listener := range s.listeners {
+		if listener.LoadBalancers[0].ID == id {
+			writeError(w, 409, "StateInvalid", "Load balancer "+id+" has listeners.")
+			return
+		}
+	}
+	delete(s.loadBalancers, id)
+	w.WriteHeader(204)
+}
+
+func (s *Server) listListeners(w http.ResponseWriter, r *http.Request) {
+	listeners := []*openstack.Listener{}
+	for _, listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+	writeJSON(w, 200, map[string]interface{}{"listeners": listeners})
+}
+
+func (s *Server) createListener(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Listener *openstack.Listener `json:"listener"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	listener := req.Listener
+	lb := s.loadBalancers[listener.LoadBalancerID]
+	if lb == nil {
+		writeNotFound(w, "NotFound", "Load balancer could not be found.")
+		return
+	}
+	if !s.updating(w, lb) {
+		return
+	}
+	for _, other := range s.listeners {
+		if other.LoadBalancers[0].ID == lb.ID && other.ProtocolPort == listener.ProtocolPort {
+			writeError(w, 409, "Conflict", "Listener protocol port is already in use.")
+			return
+		}
+	}
+	listener.ID = s.newID("listener")
+	listener.LoadBalancers = []*openstack.Ref{{ID: listener.LoadBalancerID}}
+	listener.LoadBalancerID = ""
+	s.listeners[listener.ID] = listener
+	writeJSON(w, 201, map[string]interface{}{"listener": listener})
+}
+
+func (s *Server) deleteListener(w http.ResponseWriter, r *http.Request) {
+	listener := s.listeners[mux.Vars(r)["id"]]
+	if listener == nil {
+		writeNotFound(w, "NotFound", "Listener could not be found.")
+		return
+	}
+	for _, pool := range s.pools {
+		if pool.Listeners[0].ID == listener.ID {
+			writeError(w, 409, "StateInvalid", "Listener "+listener.ID+" has a pool.")
+			return
+		}
+	}
+	if !s.updating(w, s.loadBalancers[listener.LoadBalancers[0].ID]) {
+		return
+	}
+	delete(s.listeners, listener.ID)
+	w.WriteHeader(204)
+}
+
+func (s *Server) listPools(w http.ResponseWriter, r *http.Request) {
+	pools := []*openstack.Pool{}
+	for _, pool := range s.pools {
+		pools = append(pools, pool)
+	}
+	writeJSON(w, 200, map[string]interface{}{"pools": pools})
+}
+
+func (s *Server) createPool(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Pool *openstack.Pool `json:"pool"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	pool := req.Pool
+	listener := s.listeners[pool.ListenerID]
+	if listener == nil {
+		writeNotFound(w, "NotFound", "Listener could not be found.")
+		return
+	}
+	if !s.updating(w, s.loadBalancers[listener.LoadBalancers[0].ID]) {
+		return
+	}
+	pool.ID = s.newID("pool")
+	pool.Listeners = []*openstack.Ref{{ID: pool.ListenerID}}
+	pool.ListenerID = ""
+	s.pools[pool.ID] = pool
+	writeJSON(w, 201, map[string]interface{}{"pool": pool})
+}
+
+func (s *Server) deletePool(w http.ResponseWriter, r *http.Request) {
+	pool := s.pools[mux.Vars(r)["id"]]
+	if pool == nil {
+		writeNotFound(w, "NotFound", "Pool could not be found.")
+		return
+	}
+	if !s.updating(w, s.poolLoadBalancer(pool)) {
+		return
+	}
+	delete(s.pools, pool.ID)
+	delete(s.members, pool.ID)
+	w.WriteHeader(204)
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	pool := s.pools[mux.Vars(r)["id"]]
+	if pool == nil {
+		writeNotFound(w, "NotFound", "Pool could not be found.")
+		return
+	}
+	members := s.members[pool.ID]
+	if members == nil {
+		members = []*openstack.Member{}
+	}
+	writeJSON(w, 200, map[string]interface{}{"members": members})
+}
+
+func (s *Server) createMember(w http.ResponseWriter, r *http.Request) {
+	pool := s.pools[mux.Vars(r)["id"]]
+	if pool == nil {
+		writeNotFound(w, "NotFound", "Pool could not be found.")
+		return
+	}
+	req := new(struct {
+		Member *openstack.Member `json:"member"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	for _, member := range s.members[pool.ID] {
+		if member.Address == req.Member.Address && member.ProtocolPort == req.Member.ProtocolPort {
+			writeError(w, 409, "MemberExists", "Member already exists in pool.")
+			return
+		}
+	}
+	if !s.updating(w, s.poolLoadBalancer(pool)) {
+		return
+	}
+	member := req.Member
+	member.ID = s.newID("member")
+	s.members[pool.ID] = append(s.members[pool.ID], member)
+	writeJSON(w, 201, map[string]interface{}{"member": member})
+}
+
+func (s *Server) deleteMember(w http.ResponseWriter, r *http.Request) {
+	pool := s.pools[mux.Vars(r)["id"]]
+	if pool == nil {
+		writeNotFound(w, "NotFound", "Pool could not be found.")
+		return
+	}
+	var members []*openstack.Member
+	found := false
+	for _, member := range s.members[pool.ID] {
+		if member.ID == mux.Vars(r)["member_id"] {
+			found = true
+		} else {
+			members = append(members, member)
+		}
+	}
+	if !found {
+		writeNotFound(w, "NotFound", "Member could not be found.")
+		return
+	}
+	if !s.updating(w, s.poolLoadBalancer(pool)) {
+		return
+	}
+	s.members[pool.ID] = members
+	w.WriteHeader(204)
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) token() string {
+	return "fake-token-" + s.Username
+}
+
+func (s *Server) catalogEntry(typ string, path string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": typ,
+		"endpoints": []map[string]string{
+			{"interface": "internal", "region": Region, "region_id": Region, "url": "http://internal.invalid" + path},
+			{"interface": "public", "region": Region, "region_id": Region, "url": s.URL + path},
+		},
+	}
+}
+
+func (s *Server) newID(prefix string) string {
+	s.lastID++
+	return fmt.Sprintf("%s-%d", prefix, s.lastID)
+}
+
+func (s *Server) findSecurityGroup(name string) *openstack.SecurityGroup {
+	for _, group := range s.securityGroups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+func (s *Server) poolLoadBalancer(pool *openstack.Pool) *openstack.LoadBalancer {
+	listener := s.listeners[pool.Listeners[0].ID]
+	return s.loadBalancers[listener.LoadBalancers[0].ID]
+}
+
+// updating moves the load balancer to PENDING_UPDATE (until its next GET), or
+// writes a 409 if it isn't ACTIVE, as LBaaS does.
+func (s *Server) updating(w http.ResponseWriter, lb *openstack.LoadBalancer) bool {
+	if lb.ProvisioningStatus != "ACTIVE" {
+		writeError(w, 409, "StateInvalid", "Invalid state "+lb.ProvisioningStatus+" of loadbalancer resource "+lb.ID)
+		return false
+	}
+	lb.ProvisioningStatus = "PENDING_UPDATE"
+	return true
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeError(w, 400, "badRequest", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error wrapped in its type, as Nova and Cinder do (ex.
+// {"itemNotFound": {"code": 404, "message": "..."}}).
+func writeError(w http.ResponseWriter, status int, typ string, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		typ: map[string]interface{}{"code": status, "message": message},
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, typ string, message string) {
+	writeError(w, 404, typ, message)
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestActionsList(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user := createUser(srv.Core)
+
+	Convey("Given a user and no running Actions", t, func() {
+
+		Convey("When the user Lists Actions", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			var actions []*model.Action
+			err := sg.Actions.List(&actions)
+
+			Convey("They should see none", func() {
+				So(err, ShouldBeNil)
+				So(len(actions), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the user Lists failed Actions", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			var actions []*model.Action
+			err := sg.Actions.ListWithFilters(&actions, map[string]string{"state": model.ActionStateFailed})
+
+			Convey("They should see none", func() {
+				So(err, ShouldBeNil)
+				So(len(actions), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestActionsRetryAndCancel(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user := createUser(srv.Core)
+
+	action := &model.Action{ResourceUUID: "not-a-real-resource"}
+
+	Convey("Given a user", t, func() {
+
+		Convey("When the user Gets an Action for a resource with no Action", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			err := sg.Actions.Get(action.ResourceUUID, action)
+
+			Convey("They should receive a 404 Not Found error", func() {
+				So(err.(*model.Error).Status, ShouldEqual, 404)
+			})
+		})
+
+		Convey("When the user Retries an Action that does not exist", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			err := sg.Actions.Retry(action)
+
+			Convey("They should receive a 404 Not Found error", func() {
+				So(err.(*model.Error).Status, ShouldEqual, 404)
+			})
+		})
+
+		Convey("When the user Cancels an Action that does not exist", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			err := sg.Actions.Cancel(action)
+
+			Convey("They should receive a 404 Not Found error", func() {
+				So(err.(*model.Error).Status, ShouldEqual, 404)
+			})
+		})
+	})
+}
+
+func TestActionsResume(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	kube := createKube(srv.Core)
+	cloudAccount := kube.CloudAccount
+
+	Convey("Given a leader whose Node provisioning is failing", t, func() {
+		So(srv.Core.DB.Model(cloudAccount).Update("credentials_json", []byte(`{"fail_operations":"CreateNode"}`)).Error, ShouldBeNil)
+
+		srv.Core.InitializeBackground()
+		So(srv.Core.Leader.IsLeader(), ShouldBeTrue)
+
+		node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+		So(srv.Core.Nodes.Create(node), ShouldBeNil)
+
+		// The first attempt fails, and the Action waits to retry.
+		record := new(model.Action)
+		waitFor(func() bool {
+			r, err := srv.Core.Actions.Record(node.UUID)
+			if err == nil && r.Retries > 0 {
+				record = r
+				return true
+			}
+			return false
+		})
+
+		Convey("When the server restarts, and the failure clears", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			So(srv.Core.Shutdown(ctx), ShouldBeNil)
+
+			persisted := new(model.Action)
+			So(srv.Core.DB.Where("resource_uuid = ?", node.UUID).First(persisted), ShouldBeNil)
+
+			So(srv.Core.DB.Model(cloudAccount).Update("credentials_json", []byte(`{}`)).Error, ShouldBeNil)
+
+			restarted := restartCore(srv.Core)
+			restarted.InitializeBackground()
+			defer restarted.Shutdown(ctx)
+
+			provisioned := waitFor(func() bool {
+				So(restarted.DB.First(node, *node.ID), ShouldBeNil)
+				return node.ProviderID != ""
+			})
+
+			Convey("The new leader should resume the persisted Action, and finish it", func() {
+				So(record.Error, ShouldContainSubstring, "Simulated failure of CreateNode")
+				So(persisted.Async, ShouldBeTrue)
+				So(persisted.Description, ShouldEqual, "provisioning")
+				So(persisted.Retries, ShouldEqual, 1)
+				So(persisted.RetryPolicy, ShouldNotBeNil)
+				So(persisted.RetryPolicy, ShouldResemble, record.RetryPolicy)
+
+				So(restarted.Leader.IsLeader(), ShouldBeTrue)
+				So(provisioned, ShouldBeTrue)
+
+				waitFor(func() bool { return restarted.Actions.Get(node.UUID) == nil })
+				var remaining []*model.Action
+				So(restarted.DB.Where("resource_uuid = ?", node.UUID).Find(&remaining), ShouldBeNil)
+				So(remaining, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestActionsQueuedByFollower(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	kube := createKube(srv.Core)
+	missingInstanceID := int64(999)
+	volume := &model.Volume{KubeID: kube.ID, InstanceID: &missingInstanceID, Name: "data", Type: "gp2", Size: 10, Retained: true}
+	volume.SetUUID()
+	if err := srv.Core.DB.DB.Create(volume).Error; err != nil {
+		panic(err)
+	}
+
+	Convey("Given a server that is not the leader", t, func() {
+		So(srv.Core.Leader.IsLeader(), ShouldBeFalse)
+
+		Convey("When it provisions Nodes Now(), one the leader is provisioning, and one whose provisioning failed", func() {
+			inFlight := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+			failed := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+			var records []*model.Action
+			for _, node := range []*model.Node{inFlight, failed} {
+				So(srv.Core.DB.Create(node), ShouldBeNil)
+				record := &model.Action{
+					ResourceUUID: node.UUID,
+					ResourceType: "Node",
+					ResourceID:   node.ID,
+					Async:        true,
+					ActionStatus: model.ActionStatus{Description: "provisioning", MaxRetries: 5},
+				}
+				records = append(records, record)
+			}
+			records[1].Retries = 5
+			records[1].Error = "Simulated failure of CreateNode"
+			for _, record := range records {
+				So(srv.Core.DB.Create(record), ShouldBeNil)
+			}
+
+			inFlightErr := srv.Core.Nodes.Provision(inFlight.ID, inFlight).Now()
+			failedErr := srv.Core.Nodes.Provision(failed.ID, failed).Now()
+
+			var inFlightRecords, failedRecords []*model.Action
+			So(srv.Core.DB.Where("resource_uuid = ?", inFlight.UUID).Find(&inFlightRecords), ShouldBeNil)
+			So(srv.Core.DB.Where("resource_uuid = ?", failed.UUID).Find(&failedRecords), ShouldBeNil)
+
+			Convey("It should keep the record of the Action in flight on the leader, and clear the failed one", func() {
+				So(inFlightErr, ShouldBeNil)
+				So(failedErr, ShouldBeNil)
+				So(inFlightRecords, ShouldHaveLength, 1)
+				So(*inFlightRecords[0].ID, ShouldEqual, *records[0].ID)
+				So(failedRecords, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When it deletes a retained Volume, and resizes it", func() {
+			deleteErr := srv.Core.Volumes.DeleteRetained(volume.ID, new(model.Volume))
+			queued, recordErr := srv.Core.Actions.Record(volume.UUID)
+			resizeErr := srv.Core.Volumes.Resize(volume.ID, new(model.Volume)).Async()
+
+			Convey("The deletion should be queued, and the resize refused, since the leader could not perform it", func() {
+				So(deleteErr, ShouldBeNil)
+				So(recordErr, ShouldBeNil)
+				So(queued.Queued, ShouldBeTrue)
+				So(queued.Description, ShouldEqual, "deleting")
+
+				So(resizeErr, ShouldNotBeNil)
+				So(resizeErr.Error(), ShouldContainSubstring, "cannot be queued")
+			})
+
+			Convey("When it becomes the leader, it should delete the Volume", func() {
+				srv.Core.InitializeBackground()
+				defer srv.Core.Shutdown(context.Background())
+
+				deleted := waitFor(func() bool {
+					var volumes []*model.Volume
+					So(srv.Core.DB.Where("id = ?", *volume.ID).Find(&volumes), ShouldBeNil)
+					return len(volumes) == 0
+				})
+				So(deleted, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestActionsPersistSteps(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	kube := createKube(srv.Core, kubeOptions{Credentials: map[string]string{"fail_operations": "CreateKube"}, Unready: true})
+
+	Convey("Given a leader whose Kube provisioning is failing", t, func() {
+		srv.Core.InitializeBackground()
+		defer srv.Core.Shutdown(context.Background())
+
+		So(srv.Core.Kubes.Provision(kube.ID, new(model.Kube)).Async(), ShouldBeNil)
+
+		Convey("The persisted record should have the steps and retry policy of the Action", func() {
+			persisted := new(model.Action)
+			failed := waitFor(func() bool {
+				return srv.Core.DB.Where("resource_uuid = ?", kube.UUID).First(persisted) == nil && persisted.Retries > 0
+			})
+			So(failed, ShouldBeTrue)
+
+			So(persisted.RetryPolicy, ShouldNotBeNil)
+			So(persisted.RetryPolicy.MaxDelay, ShouldBeGreaterThan, 0)
+			So(persisted.Steps, ShouldHaveLength, 2)
+			So(persisted.Steps[0].Name, ShouldEqual, "creating Kubernetes master")
+			So(persisted.Steps[0].State, ShouldEqual, model.ActionStepStateFailed)
+			So(persisted.Steps[0].Error, ShouldContainSubstring, "Simulated failure of CreateKube")
+			So(persisted.Steps[1].State, ShouldEqual, model.ActionStepStatePending)
+		})
+	})
+}
+
+func TestActionsRetryAndCancelOnLeader(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user := createUser(srv.Core)
+	kube := createKube(srv.Core)
+
+	failingKube := createKube(srv.Core, kubeOptions{Name: "failing", Credentials: map[string]string{"fail_operations": "CreateNode"}})
+
+	// The leader restores this Action from its record as failed, out of
+	// retries.
+	failedNode := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+	if err := srv.Core.DB.Create(failedNode); err != nil {
+		panic(err)
+	}
+	failedRecord := &model.Action{
+		ResourceUUID: failedNode.UUID,
+		ResourceType: "Node",
+		ResourceID:   failedNode.ID,
+		Async:        true,
+		ActionStatus: model.ActionStatus{Description: "provisioning", MaxRetries: 5, Retries: 5, Error: "Simulated failure of CreateNode"},
+	}
+	if err := srv.Core.DB.Create(failedRecord); err != nil {
+		panic(err)
+	}
+
+	srv.Core.InitializeBackground()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Core.Shutdown(ctx)
+	}()
+
+	Convey("Given a failed Action", t, func() {
+		sg := srv.Core.NewAPIClient("token", user.APIToken)
+
+		action := new(model.Action)
+		So(sg.Actions.Get(failedNode.UUID, action), ShouldBeNil)
+		So(action.State, ShouldEqual, model.ActionStateFailed)
+
+		Convey("When the user Retries it", func() {
+			err := sg.Actions.Retry(action)
+
+			provisioned := waitFor(func() bool {
+				So(srv.Core.DB.First(failedNode, *failedNode.ID), ShouldBeNil)
+				return failedNode.ProviderID != ""
+			})
+
+			Convey("It should be performed again, with its retries reset, and succeed", func() {
+				So(err, ShouldBeNil)
+				So(action.Retries, ShouldEqual, 0)
+				So(action.State, ShouldEqual, model.ActionStateRunning)
+				So(provisioned, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a running Action which is waiting to retry", t, func() {
+		sg := srv.Core.NewAPIClient("token", user.APIToken)
+
+		node := &model.Node{KubeID: failingKube.ID, Size: "fake.small"}
+		So(srv.Core.Nodes.Create(node), ShouldBeNil)
+		So(waitFor(func() bool {
+			a := srv.Core.Actions.Get(node.UUID)
+			return a != nil && a.Status.Retries > 0
+		}), ShouldBeTrue)
+
+		Convey("When the user Cancels it", func() {
+			action := &model.Action{ResourceUUID: node.UUID}
+			err := sg.Actions.Cancel(action)
+
+			var cancelled []*model.Action
+			listErr := sg.Actions.ListWithFilters(&cancelled, map[string]string{"state": model.ActionStateCancelled})
+
+			retryErr := sg.Actions.Retry(&model.Action{ResourceUUID: node.UUID})
+
+			Convey("It should stop, and be listed as cancelled", func() {
+				So(err, ShouldBeNil)
+				So(action.State, ShouldEqual, model.ActionStateCancelled)
+				So(srv.Core.Actions.Get(node.UUID), ShouldBeNil)
+
+				So(listErr, ShouldBeNil)
+				So(cancelled, ShouldHaveLength, 1)
+				So(cancelled[0].ResourceUUID, ShouldEqual, node.UUID)
+
+				So(retryErr, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAppsNamespace(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+
+	kube := createKube(srv.Core)
+
+	Convey("Given an App on a Kube", t, func() {
+		app := &model.App{KubeID: kube.ID, Name: "test"}
+		So(srv.Core.DB.Create(app), ShouldBeNil)
+
+		Convey("When the App is provisioned, and then deleted", func() {
+			provisionErr := srv.Core.Apps.Provision(app.ID, app).Now()
+			_, namespaceErr := kubernetes.Client(kube).Namespaces().Get("test")
+
+			deleteErr := srv.Core.Apps.Delete(app.ID, app).Now()
+			_, deletedNamespaceErr := kubernetes.Client(kube).Namespaces().Get("test")
+
+			Convey("Its Namespace should be created, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(namespaceErr, ShouldBeNil)
+				So(deleteErr, ShouldBeNil)
+				So(deletedNamespaceErr, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/fake"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAvailabilityZones(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+	kubernetes.Volumes = fake.DefaultCloud
+
+	kube := createKube(srv.Core, kubeOptions{Name: "zoned", Zones: []string{"fake-1a", "fake-1b"}})
+
+	Convey("Given a Kube spanning two availability zones", t, func() {
+
+		Convey("When Nodes are created without a zone", func() {
+			var zones []string
+			for i := 0; i < 3; i++ {
+				node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+				So(srv.Core.Nodes.Create(node), ShouldBeNil)
+				zones = append(zones, node.AvailabilityZone)
+			}
+			srv.Core.DB.Where("kube_id = ?", kube.ID).Delete(new(model.Node))
+
+			Convey("They should be spread across the zones", func() {
+				So(zones, ShouldResemble, []string{"fake-1a", "fake-1b", "fake-1a"})
+			})
+		})
+
+		Convey("When a Node is created in a zone the Kube doesn't have", func() {
+			err := srv.Core.Nodes.Create(&model.Node{KubeID: kube.ID, Size: "fake.small", AvailabilityZone: "fake-1c"})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "AvailabilityZone")
+			})
+		})
+
+		Convey("When two Instances with a Volume are started, with a Node in each zone", func() {
+			var nodes []*model.Node
+			for _, zone := range []string{"fake-1a", "fake-1b"} {
+				node := &model.Node{KubeID: kube.ID, Size: "fake.small", AvailabilityZone: zone}
+				So(srv.Core.DB.Create(node), ShouldBeNil)
+				So(srv.Core.Nodes.Provision(node.ID, node).Now(), ShouldBeNil)
+				kubernetes.AddNode(node)
+				nodes = append(nodes, node)
+			}
+
+			app := &model.App{KubeID: kube.ID, Name: "zoned"}
+			So(srv.Core.DB.Create(app), ShouldBeNil)
+			So(srv.Core.Apps.Provision(app.ID, app).Now(), ShouldBeNil)
+			component := &model.Component{AppID: app.ID, Name: "db"}
+			So(srv.Core.DB.Create(component), ShouldBeNil)
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			var instances []*model.Instance
+			var startErrs []error
+			for num := 0; num < 2; num++ {
+				instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: num, Name: fmt.Sprintf("db-%d", num)}
+				So(srv.Core.DB.Create(instance), ShouldBeNil)
+				So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+				startErrs = append(startErrs, srv.Core.Instances.Start(instance.ID, instance).Now())
+				instances = append(instances, instance)
+			}
+
+			Convey("Each should have its Volume in a different zone, and its pod on the Node in that zone", func() {
+				for i, instance := range instances {
+					So(startErrs[i], ShouldBeNil)
+					So(instance.Volumes, ShouldHaveLength, 1)
+					volume := instance.Volumes[0]
+					So(volume.AvailabilityZone, ShouldEqual, nodes[i].AvailabilityZone)
+
+					cloudVolume, err := fake.DefaultCloud.Volume(volume.ProviderID)
+					So(err, ShouldBeNil)
+					So(cloudVolume.Zone, ShouldEqual, nodes[i].AvailabilityZone)
+					So(cloudVolume.AttachedTo, ShouldEqual, nodes[i].ProviderID)
+				}
+			})
+		})
+	})
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/aws"
+	"github.com/supergiant/supergiant/test/fakeaws"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAWSKubeConfig(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	// NOTE the CloudAccount is not validated against AWS, and the Kubes are
+	// not provisioned, since the test server is not the leader.
+	cloudAccount := &model.CloudAccount{
+		Name:        "aws",
+		Provider:    "aws",
+		Credentials: map[string]string{"access_key": "key", "secret_key": "secret"},
+	}
+	if err := srv.Core.DB.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+
+	newKube := func(name string, config *model.AWSKubeConfig) *model.Kube {
+		config.Region = "us-east-1"
+		return &model.Kube{
+			CloudAccountID: cloudAccount.ID,
+			Name:           name,
+			MasterNodeSize: "m4.large",
+			NodeSizes:      []string{"m4.large"},
+			ProviderConfig: config,
+		}
+	}
+
+	Convey("Given an AWS CloudAccount", t, func() {
+
+		Convey("When a Kube is created in an existing VPC, with its Subnets and Security Groups", func() {
+			kube := newKube("existing", &model.AWSKubeConfig{
+				VPCID:               "vpc-1",
+				RouteTableID:        "rtb-1",
+				ELBSecurityGroupID:  "sg-1",
+				NodeSecurityGroupID: "sg-2",
+				PrivateNodes:        true,
+				AvailabilityZones: []*model.AWSAvailabilityZone{
+					{Name: "us-east-1b", PublicSubnetID: "subnet-1", PrivateSubnetID: "subnet-2"},
+				},
+			})
+			err := srv.Core.Kubes.Create(kube)
+
+			saved := new(model.Kube)
+			srv.Core.DB.First(saved, *kube.ID)
+
+			Convey("They should be marked existing, so that they are not deleted with it", func() {
+				So(err, ShouldBeNil)
+				So(saved.ProviderConfig.(*model.AWSKubeConfig).VPCID, ShouldEqual, "vpc-1")
+				So(saved.ProviderConfig.(*model.AWSKubeConfig).ExistingVPC, ShouldBeTrue)
+				So(saved.ProviderConfig.(*model.AWSKubeConfig).ExistingSecurityGroups, ShouldBeTrue)
+				So(saved.ProviderConfig.(*model.AWSKubeConfig).AvailabilityZones[0].PrivateSubnetID, ShouldEqual, "subnet-2")
+			})
+		})
+
+		Convey("When a Kube is created in an existing VPC without the Subnet of a zone", func() {
+			err := srv.Core.Kubes.Create(newKube("nosubnet", &model.AWSKubeConfig{
+				VPCID: "vpc-1",
+				AvailabilityZones: []*model.AWSAvailabilityZone{
+					{Name: "us-east-1b", PublicSubnetID: "subnet-1"},
+					{Name: "us-east-1c"},
+				},
+			}))
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "AvailabilityZones[1].PublicSubnetID")
+			})
+		})
+
+		Convey("When a Kube is created with an existing Security Group, but no VPC", func() {
+			err := srv.Core.Kubes.Create(newKube("novpc", &model.AWSKubeConfig{
+				ELBSecurityGroupID:  "sg-1",
+				NodeSecurityGroupID: "sg-2",
+				AvailabilityZones: []*model.AWSAvailabilityZone{
+					{Name: "us-east-1b"},
+				},
+			}))
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "NodeSecurityGroupID")
+			})
+		})
+
+		Convey("When a Kube is created with zones outside its region", func() {
+			err := srv.Core.Kubes.Create(newKube("badzone", &model.AWSKubeConfig{
+				AvailabilityZones: []*model.AWSAvailabilityZone{
+					{Name: "us-east-1b"},
+					{Name: "us-west-2a"},
+				},
+			}))
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "AvailabilityZones[1].Name")
+			})
+		})
+	})
+}
+
+func TestAWSProvider(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	cloud := fakeaws.NewServer()
+	defer cloud.Close()
+	aws.Endpoint = cloud.URL
+	defer func() { aws.Endpoint = "" }()
+	aws.UserdataDir = "../../../config"
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+
+	cloudAccount := &model.CloudAccount{
+		Name:        "aws",
+		Provider:    "aws",
+		Credentials: map[string]string{"access_key": "key", "secret_key": "secret"},
+	}
+	if err := srv.Core.DB.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+
+	Convey("Given an AWS CloudAccount", t, func() {
+
+		Convey("When a Kube with private Nodes and a bastion is provisioned, and then deleted", func() {
+			kube := createKube(srv.Core, kubeOptions{
+				Name:         "private",
+				CloudAccount: cloudAccount,
+				NodeSizes:    []string{"m4.large"},
+				Zones:        []string{"us-east-1b", "us-east-1c"},
+				AWSConfig:    &model.AWSKubeConfig{PrivateNodes: true, BastionNodeSize: "t2.micro"},
+				Unready:      true,
+			})
+
+			// The first minion registers with Kubernetes.
+			kubernetes.AddNode(&model.Node{Name: "ip-172-20-2-10.ec2.internal"})
+
+			provisionErr := srv.Core.Kubes.Provision(kube.ID, kube).Now()
+			config := *kube.ProviderConfig.(*model.AWSKubeConfig)
+			zones := make([]model.AWSAvailabilityZone, len(config.AvailabilityZones))
+			for i, zone := range config.AvailabilityZones {
+				zones[i] = *zone
+			}
+			var privateSubnets []*ec2.Subnet
+			var natGateways []*ec2.NatGateway
+			var privateRouteTables []*ec2.RouteTable
+			for _, zone := range zones {
+				privateSubnets = append(privateSubnets, cloud.Subnet(zone.PrivateSubnetID))
+				natGateways = append(natGateways, cloud.NatGateway(zone.NATGatewayID))
+				privateRouteTables = append(privateRouteTables, cloud.RouteTable(zone.PrivateRouteTableID))
+			}
+			tagged := cloud.TaggedWith("KubernetesCluster", "private")
+			bastion := cloud.Instance(config.BastionID)
+
+			deleteErr := srv.Core.Kubes.Delete(kube.ID, kube).Now()
+
+			Convey("Each zone should have a private Subnet routed through its NAT Gateway, with one Route Table of the cluster", func() {
+				So(provisionErr, ShouldBeNil)
+				So(kube.Ready, ShouldBeTrue)
+				for i, zone := range zones {
+					So(privateSubnets[i], ShouldNotBeNil)
+					So(*privateSubnets[i].CidrBlock, ShouldEqual, []string{"172.20.2.0/24", "172.20.3.0/24"}[i])
+					So(*privateSubnets[i].AvailabilityZone, ShouldEqual, zone.Name)
+					So(natGateways[i], ShouldNotBeNil)
+					So(*natGateways[i].SubnetId, ShouldEqual, zone.PublicSubnetID)
+					So(*natGateways[i].NatGatewayAddresses[0].AllocationId, ShouldEqual, zone.NATElasticIPAllocationID)
+					So(privateRouteTables[i], ShouldNotBeNil)
+					So(*privateRouteTables[i].Associations[0].SubnetId, ShouldEqual, zone.PrivateSubnetID)
+					So(*privateRouteTables[i].Routes[0].NatGatewayId, ShouldEqual, zone.NATGatewayID)
+					So(*privateRouteTables[i].Routes[1].InstanceId, ShouldEqual, config.MasterID)
+				}
+				So(tagged, ShouldContain, config.RouteTableID)
+				So(tagged, ShouldNotContain, zones[0].PrivateRouteTableID)
+				So(tagged, ShouldNotContain, zones[1].PrivateRouteTableID)
+			})
+
+			Convey("The bastion should be launched in the first zone's public Subnet, with a public IP", func() {
+				So(bastion, ShouldNotBeNil)
+				So(*bastion.SubnetId, ShouldEqual, zones[0].PublicSubnetID)
+				So(*bastion.InstanceType, ShouldEqual, "t2.micro")
+				So(config.BastionPublicIP, ShouldEqual, *bastion.PublicIpAddress)
+				So(cloud.Tags(config.BastionID)["Name"], ShouldEqual, "private-bastion")
+			})
+
+			Convey("Deleting it should terminate the bastion, and delete the NAT Gateways, their Elastic IPs and the VPC", func() {
+				So(deleteErr, ShouldBeNil)
+				So(*cloud.Instance(config.BastionID).State.Name, ShouldEqual, "terminated")
+				for _, zone := range zones {
+					So(*cloud.NatGateway(zone.NATGatewayID).State, ShouldEqual, "deleted")
+					So(cloud.Address(zone.NATElasticIPAllocationID), ShouldBeNil)
+					So(cloud.Subnet(zone.PrivateSubnetID), ShouldBeNil)
+				}
+				So(cloud.RouteTables(), ShouldBeEmpty)
+				So(cloud.Vpc(config.VPCID), ShouldBeNil)
+			})
+		})
+
+		Convey("When spot Nodes are created without spot capacity and with it, and then interrupted or terminated", func() {
+			kube := createKube(srv.Core, kubeOptions{
+				Name:         "spot",
+				CloudAccount: cloudAccount,
+				NodeSizes:    []string{"m4.large"},
+				SpotPolicy:   &model.SpotPolicy{NodeSizes: []string{"m4.large"}, OnDemandFallback: true},
+				Zones:        []string{"us-east-1b"},
+				Unready:      true,
+			})
+			kubernetes.AddNode(&model.Node{Name: "ip-172-20-0-10.ec2.internal"})
+			So(srv.Core.Kubes.Provision(kube.ID, kube).Now(), ShouldBeNil)
+
+			provisionNode := func() *model.Node {
+				node := &model.Node{KubeID: kube.ID, Size: "m4.large"}
+				So(srv.Core.DB.Create(node), ShouldBeNil)
+				So(srv.Core.Nodes.Provision(node.ID, node).Now(), ShouldBeNil)
+				return node
+			}
+
+			cloud.SetSpotCapacity(false)
+			onDemand := provisionNode()
+			retried := &model.Node{KubeID: kube.ID, Size: "m4.large"}
+			retried.UUID = onDemand.UUID
+			So(srv.Core.DB.Create(retried), ShouldBeNil)
+			retryErr := srv.Core.Nodes.Provision(retried.ID, retried).Now()
+			cloud.SetSpotCapacity(true)
+
+			interrupted := provisionNode()
+			terminated := provisionNode()
+			So(cloud.InterruptInstance(interrupted.ProviderID), ShouldBeNil)
+			So(cloud.TerminateInstance(terminated.ProviderID), ShouldBeNil)
+			replaceErr := srv.Core.Nodes.ReplaceInterrupted(kube)
+
+			var nodes []*model.Node
+			So(srv.Core.DB.Where("kube_id = ? AND id > ?", kube.ID, *onDemand.ID).Order("id").Find(&nodes), ShouldBeNil)
+
+			So(srv.Core.Kubes.Delete(kube.ID, kube).Now(), ShouldBeNil)
+
+			Convey("Without spot capacity, a Node should be created on-demand, with a token of its own that a retry finds", func() {
+				So(onDemand.Spot, ShouldBeFalse)
+				So(cloud.Instance(onDemand.ProviderID).InstanceLifecycle, ShouldBeNil)
+				So(retryErr, ShouldBeNil)
+				So(retried.ProviderID, ShouldEqual, onDemand.ProviderID)
+			})
+
+			Convey("Only the interrupted spot Node should be replaced", func() {
+				So(interrupted.Spot, ShouldBeTrue)
+				So(replaceErr, ShouldBeNil)
+				So(nodes, ShouldHaveLength, 3)
+				So(*nodes[0].ID, ShouldEqual, *retried.ID)
+				So(*nodes[1].ID, ShouldEqual, *terminated.ID)
+				So(*nodes[2].ID, ShouldBeGreaterThan, *terminated.ID)
+				So(nodes[2].Size, ShouldEqual, "m4.large")
+			})
+		})
+
+		Convey("When a Volume that can't be modified is resized, and its new EBS volume fails to be created the first time", func() {
+			kube := createKube(srv.Core, kubeOptions{
+				Name:         "volumes",
+				CloudAccount: cloudAccount,
+				NodeSizes:    []string{"m4.large"},
+				Zones:        []string{"us-east-1b"},
+			})
+			instance := &model.Instance{ComponentID: new(int64), ReleaseID: new(int64), Name: "data-0"}
+			So(srv.Core.DB.DB.Create(instance).Error, ShouldBeNil)
+			volume := &model.Volume{KubeID: kube.ID, InstanceID: instance.ID, Name: "data", Type: "gp2", Size: 10}
+			So(srv.Core.DB.DB.Create(volume).Error, ShouldBeNil)
+			So(srv.Core.Volumes.Provision(volume.ID, volume).Now(), ShouldBeNil)
+			oldID := volume.ProviderID
+
+			So(srv.Core.DB.Model(volume).Update("size", 20).Error, ShouldBeNil)
+			cloud.SetVolumesModifiable(false)
+			cloud.SetVolumeCapacity(false)
+			failedErr := srv.Core.Volumes.Resize(volume.ID, volume).Now()
+			snapshotID := volume.ResizeSnapshotID
+			oldAfterFailure := cloud.Volume(oldID)
+			snapshotsAfterFailure := cloud.Snapshots()
+
+			cloud.SetVolumeCapacity(true)
+			retryErr := srv.Core.Volumes.Resize(volume.ID, volume).Now()
+			cloud.SetVolumesModifiable(true)
+			replaced := cloud.Volume(volume.ProviderID)
+
+			So(srv.Core.Volumes.Delete(volume.ID, volume).Now(), ShouldBeNil)
+
+			Convey("The old EBS volume should be kept, and the retry should create the new one from the same snapshot before deleting it", func() {
+				So(failedErr, ShouldNotBeNil)
+				So(snapshotID, ShouldNotBeEmpty)
+				So(oldAfterFailure, ShouldNotBeNil)
+				So(snapshotsAfterFailure, ShouldResemble, []string{snapshotID})
+				So(retryErr, ShouldBeNil)
+				So(volume.ProviderID, ShouldNotEqual, oldID)
+				So(*replaced.SnapshotId, ShouldEqual, snapshotID)
+				So(*replaced.Size, ShouldEqual, 20)
+				So(cloud.Volume(oldID), ShouldBeNil)
+				So(cloud.Snapshots(), ShouldBeEmpty)
+				So(volume.ResizeSnapshotID, ShouldBeEmpty)
+				So(volume.GrowFilesystem, ShouldBeTrue)
+			})
+		})
+	})
+}
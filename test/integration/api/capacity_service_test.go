@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCapacityServiceScaleDown(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+
+	kube := createKube(srv.Core)
+	k8s := kubernetes.Client(kube)
+
+	// addNode adds a Node launched an hour ago, old enough to be removed when
+	// it has nothing to run.
+	addNode := func() *model.Node {
+		node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+		if err := srv.Core.DB.Create(node); err != nil {
+			panic(err)
+		}
+		if err := srv.Core.Nodes.Provision(node.ID, node).Now(); err != nil {
+			panic(err)
+		}
+		if err := srv.Core.DB.Model(node).Update("provider_creation_timestamp", time.Now().Add(-time.Hour)).Error; err != nil {
+			panic(err)
+		}
+		kubernetes.AddNode(node)
+		return node
+	}
+	idle := addNode()
+	busy := addNode()
+
+	// A pod reserving resources, on the busy Node.
+	if _, err := k8s.Namespaces().Create(&guber.Namespace{Metadata: &guber.Metadata{Name: "busy"}}); err != nil {
+		panic(err)
+	}
+	pod := &guber.Pod{
+		Metadata: &guber.Metadata{Name: "worker"},
+		Spec: &guber.PodSpec{
+			NodeSelector: map[string]string{"kubernetes.io/hostname": busy.Name},
+			Containers: []*guber.Container{
+				{
+					Name:  "worker",
+					Image: "busybox",
+					Resources: &guber.Resources{
+						Requests: &guber.ResourceValues{CPU: "500m", Memory: "256Mi"},
+					},
+				},
+			},
+		},
+	}
+	if _, err := k8s.Pods("busy").Create(pod); err != nil {
+		panic(err)
+	}
+
+	Convey("Given a Kube with an idle Node and a busy one", t, func() {
+
+		Convey("When the capacity service runs", func() {
+			srv.Core.NodeSizes = map[string][]*core.NodeSize{
+				"fake": {{Name: "fake.small", RAMGIB: 2, CPUCores: 1}},
+			}
+			srv.Core.ServiceIntervals = map[string]int{"capacity_service": 1}
+			srv.Core.InitializeBackground()
+			defer srv.Core.Shutdown(context.Background())
+
+			removed := waitFor(func() bool {
+				var nodes []*model.Node
+				So(srv.Core.DB.Where("id = ?", *idle.ID).Find(&nodes), ShouldBeNil)
+				return len(nodes) == 0
+			})
+
+			Convey("It should remove the idle Node, and keep the busy one", func() {
+				So(removed, ShouldBeTrue)
+
+				kept := new(model.Node)
+				So(srv.Core.DB.First(kept, *busy.ID), ShouldBeNil)
+				So(kept.Name, ShouldEqual, busy.Name)
+			})
+		})
+	})
+}
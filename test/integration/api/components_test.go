@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComponentsDeploy(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+
+	user := createUser(srv.Core)
+	kube := createKube(srv.Core)
+
+	node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+	if err := srv.Core.DB.Create(node); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.Nodes.Provision(node.ID, node).Now(); err != nil {
+		panic(err)
+	}
+	kubernetes.AddNode(node)
+
+	app := &model.App{KubeID: kube.ID, Name: "cache"}
+	if err := srv.Core.DB.Create(app); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.Apps.Provision(app.ID, app).Now(); err != nil {
+		panic(err)
+	}
+	component := &model.Component{AppID: app.ID, Name: "redis"}
+	if err := srv.Core.DB.Create(component); err != nil {
+		panic(err)
+	}
+	release := &model.Release{
+		ComponentID: component.ID,
+		Config: &model.ComponentConfig{
+			Containers: []*model.ContainerBlueprint{
+				{
+					Image: "redis",
+					Ports: []*model.Port{
+						{Protocol: "TCP", Number: 6379},
+						{Protocol: "TCP", Number: 8080, Public: true},
+					},
+				},
+			},
+		},
+	}
+	if err := srv.Core.DB.Create(release); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error; err != nil {
+		panic(err)
+	}
+
+	// Instances are started by the deploy through the API, as async Actions.
+	srv.Core.InitializeBackground()
+	defer srv.Core.Shutdown(context.Background())
+
+	deployed := new(model.Component)
+	deployErr := srv.Core.Components.Deploy(user, component.ID, deployed).Now()
+
+	k8s := kubernetes.Client(kube)
+
+	Convey("Given a deployed Component", t, func() {
+		So(deployErr, ShouldBeNil)
+
+		instance := new(model.Instance)
+		So(srv.Core.DB.Where("component_id = ?", component.ID).First(instance), ShouldBeNil)
+
+		Convey("Its Release should be current, and its Instance started", func() {
+			So(*deployed.CurrentReleaseID, ShouldEqual, *release.ID)
+			So(deployed.TargetReleaseID, ShouldBeNil)
+			So(instance.Started, ShouldBeTrue)
+
+			rc, err := k8s.ReplicationControllers(app.Name).Get(instance.Name)
+			So(err, ShouldBeNil)
+			So(rc.Spec.Replicas, ShouldEqual, 1)
+		})
+
+		Convey("Its Services should have its ports, with a node port for the public one", func() {
+			internal, err := k8s.Services(app.Name).Get(component.Name)
+			So(err, ShouldBeNil)
+			So(internal.Spec.Ports, ShouldHaveLength, 1)
+			So(internal.Spec.Ports[0].Port, ShouldEqual, 6379)
+
+			external, err := k8s.Services(app.Name).Get(component.Name + "-public")
+			So(err, ShouldBeNil)
+			So(external.Spec.Type, ShouldEqual, "NodePort")
+			So(external.Spec.Ports, ShouldHaveLength, 1)
+			So(external.Spec.Ports[0].Port, ShouldEqual, 8080)
+			nodePort := external.Spec.Ports[0].NodePort
+			So(nodePort, ShouldBeGreaterThan, 30000)
+
+			So(deployed.Addresses.External, ShouldHaveLength, 1)
+			So(deployed.Addresses.External[0].Address, ShouldEqual, fmt.Sprintf("%s:%d", node.ExternalIP, nodePort))
+			So(deployed.Addresses.Internal, ShouldHaveLength, 2)
+		})
+
+		Convey("When its Instance is stopped", func() {
+			stopErr := srv.Core.Instances.Stop(instance.ID, instance).Now()
+
+			Convey("Its ReplicationController and Pod should be deleted", func() {
+				So(stopErr, ShouldBeNil)
+
+				_, err := k8s.ReplicationControllers(app.Name).Get(instance.Name)
+				So(err, ShouldNotBeNil)
+				pods, err := k8s.Pods(app.Name).Query(&guber.QueryParams{LabelSelector: "instance=" + instance.Name})
+				So(err, ShouldBeNil)
+				So(pods.Items, ShouldBeEmpty)
+
+				stopped := new(model.Instance)
+				So(srv.Core.DB.First(stopped, *instance.ID), ShouldBeNil)
+				So(stopped.Started, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/digitalocean"
+	"github.com/supergiant/supergiant/test/fakedigitalocean"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDigitalOceanProvider(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	do := fakedigitalocean.NewServer("secret")
+	defer do.Close()
+	do.Use()
+	digitalocean.UserdataDir = "../../../config"
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+
+	kube := createDigitalOceanKube(srv.Core, "secret")
+
+	Convey("Given a DigitalOcean CloudAccount", t, func() {
+
+		Convey("When a Kube is provisioned, and then deleted", func() {
+			newKube := &model.Kube{
+				CloudAccountID: kube.CloudAccountID,
+				Name:           "new",
+				MasterNodeSize: "2gb",
+				NodeSizes:      []string{"1gb"},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.DOKubeConfig{Region: "nyc3"},
+			}
+			So(srv.Core.DB.Create(newKube), ShouldBeNil)
+
+			// The first minion registers with Kubernetes.
+			kubernetes.AddNode(&model.Node{Name: "new-minion-abcde"})
+
+			provisionErr := srv.Core.Kubes.Provision(newKube.ID, newKube).Now()
+			master := do.Droplet(newKube.ProviderConfig.(*model.DOKubeConfig).MasterID)
+			var nodes []*model.Node
+			srv.Core.DB.Where("kube_id = ?", newKube.ID).Find(&nodes)
+
+			deleteErr := srv.Core.Kubes.Delete(newKube.ID, newKube).Now()
+
+			Convey("The master droplet and first Node should be created, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(newKube.Ready, ShouldBeTrue)
+				So(master, ShouldNotBeNil)
+				So(master.Name, ShouldEqual, "new-master")
+				So(newKube.MasterPublicIP, ShouldEqual, master.IP("public"))
+				So(newKube.ProviderConfig.(*model.DOKubeConfig).MasterPrivateIP, ShouldEqual, master.IP("private"))
+				So(nodes, ShouldHaveLength, 1)
+				So(deleteErr, ShouldBeNil)
+				So(do.Droplet(master.ID), ShouldBeNil)
+			})
+		})
+
+		Convey("When a CloudAccount is created with a bad token", func() {
+			err := srv.Core.CloudAccounts.Create(&model.CloudAccount{
+				Name:        "bad",
+				Provider:    "digitalocean",
+				Credentials: map[string]string{"token": "wrong"},
+			})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "401")
+			})
+		})
+
+		Convey("When a Node is provisioned, and then deleted", func() {
+			node := &model.Node{KubeID: kube.ID, Size: "1gb"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+
+			provisionErr := srv.Core.Nodes.Provision(node.ID, node).Now()
+			dropletID, _ := strconv.ParseInt(node.ProviderID, 10, 64)
+			droplet := do.Droplet(dropletID)
+
+			deleteErr := srv.Core.Nodes.Delete(node.ID, node).Now()
+
+			Convey("A droplet should be created with the Kube's minion tag, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(droplet, ShouldNotBeNil)
+				So(droplet.Name, ShouldEqual, node.Name)
+				So(droplet.SizeSlug, ShouldEqual, "1gb")
+				So(droplet.Tags, ShouldHaveLength, 1)
+				So(droplet.Tags[0], ShouldEqual, "test-minion")
+				So(deleteErr, ShouldBeNil)
+				So(do.Droplet(dropletID), ShouldBeNil)
+			})
+		})
+
+		Convey("When a Volume is provisioned, resized, and then deleted", func() {
+			volume := &model.Volume{KubeID: kube.ID, InstanceID: new(int64), Name: "data", Size: 10}
+			So(srv.Core.DB.DB.Create(volume).Error, ShouldBeNil)
+
+			provisionErr := srv.Core.Volumes.Provision(volume.ID, volume).Now()
+			created := *do.Volume(volume.ProviderID)
+
+			So(srv.Core.DB.Model(volume).Update("size", 20).Error, ShouldBeNil)
+			resizeErr := srv.Core.Volumes.Resize(volume.ID, volume).Now()
+			resized := *do.Volume(volume.ProviderID)
+
+			deleteErr := srv.Core.Volumes.Delete(volume.ID, volume).Now()
+
+			Convey("Its block storage volume should be created, grown, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(created.Name, ShouldEqual, "test-data-"+strconv.FormatInt(*volume.ID, 10))
+				So(created.SizeGigabytes, ShouldEqual, 10)
+				So(resizeErr, ShouldBeNil)
+				So(resized.SizeGigabytes, ShouldEqual, 20)
+				So(deleteErr, ShouldBeNil)
+				So(do.Volume(volume.ProviderID), ShouldBeNil)
+			})
+		})
+
+		Convey("When an Instance with a Volume is started, and then stopped", func() {
+			node := &model.Node{KubeID: kube.ID, Size: "1gb"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+			So(srv.Core.Nodes.Provision(node.ID, node).Now(), ShouldBeNil)
+			So(srv.Core.DB.Model(node).Update("ready", true).Error, ShouldBeNil)
+			kubernetes.AddNode(node)
+			dropletID, _ := strconv.ParseInt(node.ProviderID, 10, 64)
+
+			app := &model.App{KubeID: kube.ID, Name: "attach"}
+			So(srv.Core.DB.Create(app), ShouldBeNil)
+			So(srv.Core.Apps.Provision(app.ID, app).Now(), ShouldBeNil)
+			component := &model.Component{AppID: app.ID, Name: "db"}
+			So(srv.Core.DB.Create(component), ShouldBeNil)
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Name: "db-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+			attached := *do.Volume(instance.Volumes[0].ProviderID)
+			rc, rcErr := srv.Core.K8S(kube).ReplicationControllers(app.Name).Get(instance.Name)
+
+			stopErr := srv.Core.Instances.Stop(instance.ID, instance).Now()
+			stopped := *do.Volume(instance.Volumes[0].ProviderID)
+
+			Convey("The volume should be attached to a Node's droplet, which the pod mounts it on, and then detached", func() {
+				So(startErr, ShouldBeNil)
+				So(attached.DropletIDs, ShouldResemble, []int64{dropletID})
+				So(rcErr, ShouldBeNil)
+				So(rc.Spec.Template.Spec.NodeSelector, ShouldResemble, map[string]string{"kubernetes.io/hostname": node.Name})
+				So(rc.Spec.Template.Spec.Volumes[0].HostPath.Path, ShouldEqual, "/mnt/supergiant/volumes/"+attached.Name)
+				So(stopErr, ShouldBeNil)
+				So(stopped.DropletIDs, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an Entrypoint is provisioned, given a port, and then deleted", func() {
+			entrypoint := &model.Entrypoint{KubeID: kube.ID, Name: "web"}
+			So(srv.Core.DB.Create(entrypoint), ShouldBeNil)
+
+			provisionErr := srv.Core.Entrypoints.Provision(entrypoint.ID, entrypoint).Now()
+			addErr := srv.Core.Entrypoints.SetPort(entrypoint.ID, entrypoint, 80, 30001)
+			lb := *do.LoadBalancer("sg-web")
+			removeErr := srv.Core.Entrypoints.RemovePort(entrypoint.ID, entrypoint, 80)
+			rulesAfterRemove := do.LoadBalancer("sg-web").ForwardingRules
+
+			deleteErr := srv.Core.Entrypoints.Delete(entrypoint.ID, entrypoint).Now()
+
+			Convey("A load balancer targeting the Kube's minions should forward the port until it is removed", func() {
+				So(provisionErr, ShouldBeNil)
+				So(entrypoint.Address, ShouldEqual, lb.IP)
+				So(lb.Tag, ShouldEqual, "test-minion")
+				So(addErr, ShouldBeNil)
+				So(lb.ForwardingRules, ShouldHaveLength, 2)
+				So(lb.ForwardingRules[1].EntryPort, ShouldEqual, 80)
+				So(lb.ForwardingRules[1].TargetPort, ShouldEqual, 30001)
+				So(removeErr, ShouldBeNil)
+				So(rulesAfterRemove, ShouldHaveLength, 1)
+				So(deleteErr, ShouldBeNil)
+				So(do.LoadBalancer("sg-web"), ShouldBeNil)
+			})
+		})
+	})
+}
+
+// createDigitalOceanKube creates a ready DigitalOcean Kube, without
+// provisioning it.
+func createDigitalOceanKube(c *core.Core, token string) *model.Kube {
+	cloudAccount := &model.CloudAccount{
+		Name:        "digitalocean",
+		Provider:    "digitalocean",
+		Credentials: map[string]string{"token": token},
+	}
+	if err := c.DB.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+	kube := &model.Kube{
+		CloudAccountID: cloudAccount.ID,
+		Name:           "test",
+		MasterNodeSize: "2gb",
+		NodeSizes:      []string{"1gb"},
+		Username:       "user",
+		Password:       "password",
+		ProviderConfig: &model.DOKubeConfig{
+			Region: "nyc3",
+		},
+		Ready: true,
+	}
+	if err := c.DB.Create(kube); err != nil {
+		panic(err)
+	}
+	return kube
+}
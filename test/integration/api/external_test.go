@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/pem"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExternalProvider(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	// NOTE the fake Kubernetes API is not Used; Core connects to it with the
+	// Kubes' external config.
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.BearerToken = "secret-token"
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: kubernetes.Certificate().Raw}))
+
+	cloudAccount := &model.CloudAccount{Name: "clusters", Provider: "external"}
+	if err := srv.Core.CloudAccounts.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+
+	Convey("Given an external CloudAccount", t, func() {
+
+		Convey("When a Kube of an existing cluster is imported, given an App, and then deleted", func() {
+			kube := &model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "existing",
+				NodeSizes:      []string{},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:                 kubernetes.Host(),
+					BearerToken:          "secret-token",
+					CertificateAuthority: ca,
+				},
+			}
+			So(srv.Core.DB.Create(kube), ShouldBeNil)
+
+			provisionErr := srv.Core.Kubes.Provision(kube.ID, kube).Now()
+
+			app := &model.App{KubeID: kube.ID, Name: "web"}
+			So(srv.Core.DB.Create(app), ShouldBeNil)
+			appErr := srv.Core.Apps.Provision(app.ID, app).Now()
+			namespace, namespaceErr := srv.Core.K8S(kube).Namespaces().Get("web")
+
+			nodeErr := srv.Core.Nodes.Create(&model.Node{KubeID: kube.ID, Size: "m4.large"})
+
+			deleteErr := srv.Core.Kubes.Delete(kube.ID, kube).Now()
+
+			Convey("It should be ready without provisioning, manage the App's Namespace, and refuse Nodes", func() {
+				So(provisionErr, ShouldBeNil)
+				So(kube.Ready, ShouldBeTrue)
+				So(appErr, ShouldBeNil)
+				So(namespaceErr, ShouldBeNil)
+				So(namespace.Metadata.Name, ShouldEqual, "web")
+				So(nodeErr, ShouldEqual, core.ErrorKubeExternal)
+				So(deleteErr, ShouldBeNil)
+			})
+		})
+
+		Convey("When a Kube is imported with a bearer token the cluster doesn't accept", func() {
+			kube := &model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "badtoken",
+				NodeSizes:      []string{},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:                 kubernetes.Host(),
+					BearerToken:          "wrong-token",
+					CertificateAuthority: ca,
+				},
+			}
+			So(srv.Core.DB.Create(kube), ShouldBeNil)
+
+			err := srv.Core.Kubes.Provision(kube.ID, kube).Now()
+
+			Convey("It should fail without retrying, and not be ready", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "401")
+				So(kube.Ready, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a Kube is imported without a certificate authority", func() {
+			kube := &model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "noca",
+				NodeSizes:      []string{},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:        kubernetes.Host(),
+					BearerToken: "secret-token",
+				},
+			}
+			So(srv.Core.DB.Create(kube), ShouldBeNil)
+
+			err := srv.Core.Kubes.Provision(kube.ID, kube).Now()
+
+			Convey("It should fail to verify the API server's certificate with the system's roots", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "certificate")
+				So(kube.Ready, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a Kube is imported with insecure_skip_verify, and then deleted", func() {
+			kube := &model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "insecure",
+				NodeSizes:      []string{},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:               kubernetes.Host(),
+					BearerToken:        "secret-token",
+					InsecureSkipVerify: true,
+				},
+			}
+			So(srv.Core.DB.Create(kube), ShouldBeNil)
+
+			provisionErr := srv.Core.Kubes.Provision(kube.ID, kube).Now()
+			deleteErr := srv.Core.Kubes.Delete(kube.ID, kube).Now()
+
+			Convey("It should be ready without verifying the API server's certificate", func() {
+				So(provisionErr, ShouldBeNil)
+				So(kube.Ready, ShouldBeTrue)
+				So(deleteErr, ShouldBeNil)
+			})
+		})
+
+		Convey("When Releases with ebs and empty_dir Volumes are created on an imported Kube", func() {
+			kube := &model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "volumes",
+				NodeSizes:      []string{},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:                 kubernetes.Host(),
+					BearerToken:          "secret-token",
+					CertificateAuthority: ca,
+				},
+			}
+			So(srv.Core.DB.Create(kube), ShouldBeNil)
+			app := &model.App{KubeID: kube.ID, Name: "volumes"}
+			So(srv.Core.DB.Create(app), ShouldBeNil)
+			component := &model.Component{AppID: app.ID, Name: "db"}
+			So(srv.Core.DB.Create(component), ShouldBeNil)
+
+			createRelease := func(volume *model.VolumeBlueprint) error {
+				return srv.Core.Releases.Create(&model.Release{
+					ComponentID: component.ID,
+					Config: &model.ComponentConfig{
+						Volumes:    []*model.VolumeBlueprint{volume},
+						Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+					},
+				})
+			}
+			ebsErr := createRelease(&model.VolumeBlueprint{Name: "data", Type: "gp2", Size: 10})
+			emptyDirErr := createRelease(&model.VolumeBlueprint{Name: "data", Kind: model.VolumeKindEmptyDir})
+
+			Convey("Only the Release with the empty_dir Volume should be created, as Supergiant can't create disks there", func() {
+				So(ebsErr, ShouldNotBeNil)
+				So(ebsErr.Error(), ShouldContainSubstring, "can't be ebs on external Kubes")
+				So(emptyDirErr, ShouldBeNil)
+			})
+		})
+
+		Convey("When a Kube is created with both a certificate authority and insecure_skip_verify", func() {
+			err := srv.Core.Kubes.Create(&model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "bothca",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:                 kubernetes.Host(),
+					CertificateAuthority: ca,
+					InsecureSkipVerify:   true,
+				},
+			})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "InsecureSkipVerify")
+			})
+		})
+
+		Convey("When a Kube is created with a certificate authority that isn't PEM", func() {
+			err := srv.Core.Kubes.Create(&model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "badca",
+				ProviderConfig: &model.ExternalKubeConfig{
+					Host:                 kubernetes.Host(),
+					CertificateAuthority: "not a certificate",
+				},
+			})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "CertificateAuthority")
+			})
+		})
+	})
+}
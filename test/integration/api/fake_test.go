@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFakeSimulatedFailures(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	kube := createKube(srv.Core)
+	cloudAccount := new(model.CloudAccount)
+	if err := srv.Core.DB.First(cloudAccount, *kube.CloudAccountID); err != nil {
+		panic(err)
+	}
+	setCredentials := func(credentials string) {
+		if err := srv.Core.DB.Model(cloudAccount).Update("credentials_json", []byte(credentials)).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	srv.Core.InitializeBackground()
+	defer srv.Core.Shutdown(context.Background())
+
+	// provision creates a Node, and returns the record of its Action once the
+	// first attempt is done.
+	provision := func() *model.Action {
+		node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+		So(srv.Core.Nodes.Create(node), ShouldBeNil)
+		record := new(model.Action)
+		attempted := waitFor(func() bool {
+			r, err := srv.Core.Actions.Record(node.UUID)
+			if err == nil && r.Retries > 0 {
+				record = r
+				return true
+			}
+			return false
+		})
+		So(attempted, ShouldBeTrue)
+		return record
+	}
+
+	Convey("Given a fake CloudAccount", t, func() {
+
+		Convey("When every operation fails, by failure_rate", func() {
+			setCredentials(`{"failure_rate":"1"}`)
+			record := provision()
+
+			Convey("The Action should wait to retry", func() {
+				So(record.Error, ShouldEqual, "Simulated failure of CreateNode")
+				So(record.State, ShouldEqual, model.ActionStateRunning)
+				So(record.Retries, ShouldEqual, 1)
+				So(record.NextRetryAt, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When its failure_rate is invalid", func() {
+			setCredentials(`{"failure_rate":"2"}`)
+			record := provision()
+
+			Convey("The Action should fail without retrying", func() {
+				So(record.Error, ShouldContainSubstring, "Invalid failure_rate")
+				So(record.State, ShouldEqual, model.ActionStateFailed)
+				So(record.Retries, ShouldEqual, record.MaxRetries)
+				So(record.NextRetryAt, ShouldBeNil)
+			})
+		})
+
+		Convey("When it has latency", func() {
+			setCredentials(`{"latency":"500ms"}`)
+			node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+
+			startedAt := time.Now()
+			err := srv.Core.Nodes.Provision(node.ID, node).Now()
+
+			Convey("Operations should take that long", func() {
+				So(err, ShouldBeNil)
+				So(time.Since(startedAt), ShouldBeGreaterThanOrEqualTo, 500*time.Millisecond)
+				So(node.ProviderID, ShouldNotBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strconv"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/gce"
+	"github.com/supergiant/supergiant/test/fakegce"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGCEProvider(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	google := fakegce.NewServer("test-project")
+	defer google.Close()
+	google.Use()
+	gce.UserdataDir = "../../../config"
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+	kubernetes.Volumes = google
+
+	kube := createGCEKube(srv.Core, google.ServiceAccountJSON(google.Key))
+
+	Convey("Given a GCE CloudAccount", t, func() {
+
+		Convey("When a Kube is provisioned, and then deleted", func() {
+			newKube := &model.Kube{
+				CloudAccountID: kube.CloudAccountID,
+				Name:           "new",
+				MasterNodeSize: "n1-standard-1",
+				NodeSizes:      []string{"n1-standard-1"},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: &model.GCEKubeConfig{Zone: "us-central1-a"},
+			}
+			So(srv.Core.DB.Create(newKube), ShouldBeNil)
+
+			// The first minion registers with Kubernetes.
+			kubernetes.AddNode(&model.Node{Name: "new-minion-abcde"})
+
+			provisionErr := srv.Core.Kubes.Provision(newKube.ID, newKube).Now()
+			master := google.Instance("new-master")
+			firewall := google.Firewall("new-allow-external")
+			var nodes []*model.Node
+			srv.Core.DB.Where("kube_id = ?", newKube.ID).Find(&nodes)
+
+			deleteErr := srv.Core.Kubes.Delete(newKube.ID, newKube).Now()
+
+			Convey("The master instance, firewall rule and first Node should be created, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(newKube.Ready, ShouldBeTrue)
+				So(master, ShouldNotBeNil)
+				So(master.MachineType, ShouldEqual, "zones/us-central1-a/machineTypes/n1-standard-1")
+				So(master.Metadata.Items[0].Key, ShouldEqual, "startup-script")
+				So(master.Metadata.Items[0].Value, ShouldContainSubstring, "us-central1-a")
+				So(newKube.MasterPublicIP, ShouldEqual, master.PublicIP())
+				So(newKube.ProviderConfig.(*model.GCEKubeConfig).MasterPrivateIP, ShouldEqual, master.PrivateIP())
+				So(firewall, ShouldNotBeNil)
+				So(firewall.TargetTags[0], ShouldEqual, "new")
+				So(nodes, ShouldHaveLength, 1)
+				So(deleteErr, ShouldBeNil)
+				So(google.Instance("new-master"), ShouldBeNil)
+				So(google.Firewall("new-allow-external"), ShouldBeNil)
+			})
+		})
+
+		Convey("When a CloudAccount is created with a key the project doesn't know", func() {
+			otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			So(err, ShouldBeNil)
+			err = srv.Core.CloudAccounts.Create(&model.CloudAccount{
+				Name:        "bad",
+				Provider:    "gce",
+				Credentials: map[string]string{"service_account_json": google.ServiceAccountJSON(otherKey)},
+			})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "invalid_grant")
+			})
+		})
+
+		Convey("When a CloudAccount is created with JSON that isn't a service account key", func() {
+			err := srv.Core.CloudAccounts.Create(&model.CloudAccount{
+				Name:        "bad",
+				Provider:    "gce",
+				Credentials: map[string]string{"service_account_json": `{"type": "authorized_user"}`},
+			})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "service_account_json")
+			})
+		})
+
+		Convey("When an Entrypoint is provisioned, a Node is added, a port is set, and then all are deleted", func() {
+			entrypoint := &model.Entrypoint{KubeID: kube.ID, Name: "web"}
+			So(srv.Core.DB.Create(entrypoint), ShouldBeNil)
+			provisionErr := srv.Core.Entrypoints.Provision(entrypoint.ID, entrypoint).Now()
+			address := *google.Address("sg-web")
+
+			node := &model.Node{KubeID: kube.ID, Size: "n1-standard-2"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+			nodeErr := srv.Core.Nodes.Provision(node.ID, node).Now()
+			instance := google.Instance(node.ProviderID)
+			pool := *google.TargetPool("sg-web")
+
+			translateErr := srv.Core.Entrypoints.SetPort(entrypoint.ID, entrypoint, 80, 30001)
+			addErr := srv.Core.Entrypoints.SetPort(entrypoint.ID, entrypoint, 30001, 30001)
+			rule := google.ForwardingRule("sg-web-30001")
+			removeErr := srv.Core.Entrypoints.RemovePort(entrypoint.ID, entrypoint, 30001)
+			ruleAfterRemove := google.ForwardingRule("sg-web-30001")
+
+			srv.Core.Entrypoints.SetPort(entrypoint.ID, entrypoint, 30002, 30002)
+			nodeDeleteErr := srv.Core.Nodes.Delete(node.ID, node).Now()
+			poolAfterNodeDelete := *google.TargetPool("sg-web")
+			deleteErr := srv.Core.Entrypoints.Delete(entrypoint.ID, entrypoint).Now()
+
+			Convey("The Node should be in the Entrypoint's target pool, and the port forwarded by a rule until it is removed", func() {
+				So(provisionErr, ShouldBeNil)
+				So(entrypoint.Address, ShouldEqual, address.Address)
+				So(nodeErr, ShouldBeNil)
+				So(instance, ShouldNotBeNil)
+				So(instance.Name, ShouldEqual, node.Name)
+				So(instance.Tags.Items, ShouldContain, "test")
+				So(pool.Instances, ShouldHaveLength, 1)
+				So(pool.Instances[0], ShouldEqual, instance.SelfLink)
+				So(translateErr, ShouldNotBeNil)
+				So(addErr, ShouldBeNil)
+				So(rule, ShouldNotBeNil)
+				So(rule.IPAddress, ShouldEqual, entrypoint.Address)
+				So(rule.PortRange, ShouldEqual, "30001-30001")
+				So(removeErr, ShouldBeNil)
+				So(ruleAfterRemove, ShouldBeNil)
+				So(nodeDeleteErr, ShouldBeNil)
+				So(poolAfterNodeDelete.Instances, ShouldBeEmpty)
+				So(google.Instance(node.ProviderID), ShouldBeNil)
+				So(deleteErr, ShouldBeNil)
+				So(google.ForwardingRule("sg-web-30002"), ShouldBeNil)
+				So(google.TargetPool("sg-web"), ShouldBeNil)
+				So(google.Address("sg-web"), ShouldBeNil)
+			})
+		})
+
+		Convey("When an Instance with a Volume is started, and then stopped", func() {
+			node := &model.Node{KubeID: kube.ID, Size: "n1-standard-1"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+			So(srv.Core.Nodes.Provision(node.ID, node).Now(), ShouldBeNil)
+			kubernetes.AddNode(node)
+			startupScript := google.Instance(node.ProviderID).Metadata.Items[0].Value
+
+			app := &model.App{KubeID: kube.ID, Name: "disks"}
+			So(srv.Core.DB.Create(app), ShouldBeNil)
+			So(srv.Core.Apps.Provision(app.ID, app).Now(), ShouldBeNil)
+			component := &model.Component{AppID: app.ID, Name: "db"}
+			So(srv.Core.DB.Create(component), ShouldBeNil)
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "pd-ssd", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Name: "db-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+			attached := *google.Disk(instance.Volumes[0].ProviderID)
+			rc, rcErr := srv.Core.K8S(kube).ReplicationControllers(app.Name).Get(instance.Name)
+
+			stopErr := srv.Core.Instances.Stop(instance.ID, instance).Now()
+			stopped := *google.Disk(instance.Volumes[0].ProviderID)
+
+			Convey("The pod should mount the persistent disk, which Kubernetes' GCE cloud provider attaches to its Node", func() {
+				So(startupScript, ShouldContainSubstring, "cloud: gce")
+				So(startErr, ShouldBeNil)
+				So(rcErr, ShouldBeNil)
+				So(rc.Spec.Template.Spec.Volumes[0].GCEPersistentDisk.PDName, ShouldEqual, attached.Name)
+				So(attached.Users, ShouldResemble, []string{node.ProviderID})
+				So(stopErr, ShouldBeNil)
+				So(stopped.Users, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a Volume is provisioned, resized while attached, and then deleted", func() {
+			volume := &model.Volume{KubeID: kube.ID, InstanceID: new(int64), Name: "data", Type: "pd-ssd", Size: 10}
+			So(srv.Core.DB.DB.Create(volume).Error, ShouldBeNil)
+
+			provisionErr := srv.Core.Volumes.Provision(volume.ID, volume).Now()
+			created := *google.Disk(volume.ProviderID)
+
+			google.AttachDisk(volume.ProviderID, "test-minion-abcde")
+			So(srv.Core.DB.Model(volume).Update("size", 20).Error, ShouldBeNil)
+			resizeErr := srv.Core.Volumes.Resize(volume.ID, volume).Now()
+			resized := *google.Disk(volume.ProviderID)
+			google.DetachDisk(volume.ProviderID)
+
+			deleteErr := srv.Core.Volumes.Delete(volume.ID, volume).Now()
+
+			Convey("Its persistent disk should be created, grown in place, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(created.Name, ShouldEqual, "test-data-"+strconv.FormatInt(*volume.ID, 10))
+				So(created.SizeGb, ShouldEqual, 10)
+				So(created.Type, ShouldEqual, "zones/us-central1-a/diskTypes/pd-ssd")
+				So(resizeErr, ShouldBeNil)
+				So(resized.SizeGb, ShouldEqual, 20)
+				So(resized.Users, ShouldHaveLength, 1)
+				So(deleteErr, ShouldBeNil)
+				So(google.Disk(volume.ProviderID), ShouldBeNil)
+			})
+		})
+	})
+}
+
+// createGCEKube creates a ready GCE Kube, without provisioning it.
+func createGCEKube(c *core.Core, serviceAccountJSON string) *model.Kube {
+	cloudAccount := &model.CloudAccount{
+		Name:        "gce",
+		Provider:    "gce",
+		Credentials: map[string]string{"service_account_json": serviceAccountJSON},
+	}
+	if err := c.DB.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+	kube := &model.Kube{
+		CloudAccountID: cloudAccount.ID,
+		Name:           "test",
+		MasterNodeSize: "n1-standard-1",
+		NodeSizes:      []string{"n1-standard-1"},
+		Username:       "user",
+		Password:       "password",
+		ProviderConfig: &model.GCEKubeConfig{
+			Zone: "us-central1-a",
+		},
+		Ready: true,
+	}
+	if err := c.DB.Create(kube); err != nil {
+		panic(err)
+	}
+	return kube
+}
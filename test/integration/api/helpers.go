@@ -2,10 +2,20 @@ package api
 
 import (
 	"os"
+	"strings"
+	"time"
 
 	"github.com/supergiant/supergiant/pkg/core"
 	"github.com/supergiant/supergiant/pkg/model"
 	"github.com/supergiant/supergiant/pkg/server"
+
+	// Providers register themselves with core
+	_ "github.com/supergiant/supergiant/pkg/provider/aws"
+	_ "github.com/supergiant/supergiant/pkg/provider/digitalocean"
+	_ "github.com/supergiant/supergiant/pkg/provider/external"
+	_ "github.com/supergiant/supergiant/pkg/provider/fake"
+	_ "github.com/supergiant/supergiant/pkg/provider/gce"
+	_ "github.com/supergiant/supergiant/pkg/provider/openstack"
 )
 
 func newTestServer() *server.Server {
@@ -28,6 +38,19 @@ func newTestServer() *server.Server {
 	return srv
 }
 
+// restartCore returns a new Core on the database of c, as if the server had
+// restarted. It isn't in the background until InitializeBackground.
+func restartCore(c *core.Core) *core.Core {
+	restarted := new(core.Core)
+	restarted.PublishHost = c.PublishHost
+	restarted.HTTPPort = c.HTTPPort
+	restarted.SQLiteFile = c.SQLiteFile
+	if err := restarted.InitializeForeground(); err != nil {
+		panic(err)
+	}
+	return restarted
+}
+
 func createUser(c *core.Core) *model.User {
 	user := &model.User{
 		Username: "user",
@@ -50,3 +73,112 @@ func createAdmin(c *core.Core) *model.User {
 func createUserAndAdmin(c *core.Core) (*model.User, *model.User) {
 	return createUser(c), createAdmin(c)
 }
+
+// kubeOptions vary the Kube that createKube creates. The zero value is a ready
+// Kube named "test", of fake.small Nodes, on a fake CloudAccount of its own.
+type kubeOptions struct {
+	// Name names the Kube, and the CloudAccount created for it.
+	Name string
+
+	// CloudAccount is used in place of a new fake CloudAccount. Credentials
+	// are those of the new one, e.g. fail_operations.
+	CloudAccount *model.CloudAccount
+	Credentials  map[string]string
+
+	// NodeSizes are the Kube's node sizes, the first of which is the size of
+	// the master.
+	NodeSizes  []string
+	SpotPolicy *model.SpotPolicy
+
+	// Zones are the availability zones of the Kube. An AWS Kube has them in
+	// its AWSConfig, which is in us-east-1 unless it has a Region; a fake one
+	// has them as the availability_zones of its CloudAccount.
+	Zones     []string
+	AWSConfig *model.AWSKubeConfig
+
+	// Unready leaves the Kube to be provisioned.
+	Unready bool
+}
+
+// createKube creates a Kube, without provisioning it, as varied by at most one
+// kubeOptions. Point Core.K8S at a fakekube.Server to use its Kubernetes API.
+func createKube(c *core.Core, opts ...kubeOptions) *model.Kube {
+	var o kubeOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Name == "" {
+		o.Name = "test"
+	}
+	if len(o.NodeSizes) == 0 {
+		o.NodeSizes = []string{"fake.small"}
+	}
+
+	cloudAccount := o.CloudAccount
+	if cloudAccount == nil {
+		credentials := map[string]string{}
+		for key, value := range o.Credentials {
+			credentials[key] = value
+		}
+		if len(o.Zones) > 0 {
+			credentials["availability_zones"] = strings.Join(o.Zones, ",")
+		}
+		cloudAccount = &model.CloudAccount{
+			Name:        o.Name,
+			Provider:    "fake",
+			Credentials: credentials,
+		}
+		if err := c.DB.Create(cloudAccount); err != nil {
+			panic(err)
+		}
+	}
+
+	kube := &model.Kube{
+		CloudAccountID: cloudAccount.ID,
+		Name:           o.Name,
+		MasterNodeSize: o.NodeSizes[0],
+		NodeSizes:      o.NodeSizes,
+		SpotPolicy:     o.SpotPolicy,
+		Username:       "user",
+		Password:       "password",
+		Ready:          !o.Unready,
+	}
+	if cloudAccount.Provider == "aws" {
+		config := new(model.AWSKubeConfig)
+		if o.AWSConfig != nil {
+			*config = *o.AWSConfig
+		}
+		if config.Region == "" {
+			config.Region = "us-east-1"
+		}
+		for _, zone := range o.Zones {
+			config.AvailabilityZones = append(config.AvailabilityZones, &model.AWSAvailabilityZone{Name: zone})
+		}
+		kube.ProviderConfig = config
+	}
+	if err := c.DB.Create(kube); err != nil {
+		panic(err)
+	}
+	kube.CloudAccount = cloudAccount
+	return kube
+}
+
+// performQueued performs the Action queued for m with Now(), in place of the
+// leader, and clears its queued record as the leader would.
+func performQueued(c *core.Core, m model.Model, a *core.Action) error {
+	if err := a.Now(); err != nil {
+		return err
+	}
+	return c.DB.DB.Where("resource_uuid = ? AND queued = ?", m.GetUUID(), true).Delete(new(model.Action)).Error
+}
+
+// waitFor polls fn until it returns true, for up to 10 seconds, and returns
+// whether it did.
+func waitFor(fn func() bool) bool {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if fn() {
+			return true
+		}
+	}
+	return false
+}
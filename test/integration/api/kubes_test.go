@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKubesResume(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user := createUser(srv.Core)
+
+	Convey("Given a user", t, func() {
+
+		Convey("When the user Resumes a Kube that does not exist", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			id := int64(1)
+			err := sg.Kubes.Resume(&id, new(model.Kube))
+
+			Convey("They should receive a 404 Not Found error", func() {
+				So(err.(*model.Error).Status, ShouldEqual, 404)
+			})
+		})
+	})
+}
+
+func TestKubesResumeAfterFailure(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	admin := createAdmin(srv.Core)
+	kube := createKube(srv.Core)
+	cloudAccount := new(model.CloudAccount)
+	if err := srv.Core.DB.First(cloudAccount, *kube.CloudAccountID); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.DB.Model(kube).Update("ready", false).Error; err != nil {
+		panic(err)
+	}
+
+	Convey("Given a Kube whose provisioning failed after creating the master", t, func() {
+		So(srv.Core.DB.Model(cloudAccount).Update("credentials_json", []byte(`{"fail_operations":"CreateNode"}`)).Error, ShouldBeNil)
+		err := srv.Core.Kubes.Provision(kube.ID, new(model.Kube)).Now()
+		So(err.Error(), ShouldContainSubstring, "Simulated failure of CreateNode")
+
+		failed := new(model.Kube)
+		So(srv.Core.DB.First(failed, *kube.ID), ShouldBeNil)
+		So(failed.MasterPublicIP, ShouldNotBeEmpty)
+		So(failed.CompletedSteps, ShouldResemble, []string{"creating Kubernetes master"})
+
+		Convey("When the admin Resumes it, with creating the master failing now", func() {
+			So(srv.Core.DB.Model(cloudAccount).Update("credentials_json", []byte(`{"fail_operations":"CreateKube"}`)).Error, ShouldBeNil)
+
+			srv.Core.InitializeBackground()
+			defer srv.Core.Shutdown(context.Background())
+
+			sg := srv.Core.NewAPIClient("token", admin.APIToken)
+			err := sg.Kubes.Resume(kube.ID, new(model.Kube))
+
+			resumed := new(model.Kube)
+			ready := waitFor(func() bool {
+				So(srv.Core.DB.First(resumed, *kube.ID), ShouldBeNil)
+				return resumed.Ready
+			})
+
+			Convey("It should skip the completed step, and finish provisioning", func() {
+				So(err, ShouldBeNil)
+				So(ready, ShouldBeTrue)
+				So(resumed.MasterPublicIP, ShouldEqual, failed.MasterPublicIP)
+				So(resumed.CompletedSteps, ShouldBeEmpty)
+
+				var nodes []*model.Node
+				So(srv.Core.DB.Where("kube_id = ?", kube.ID).Find(&nodes), ShouldBeNil)
+				So(nodes, ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestKubesProviderConfig(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	admin := createAdmin(srv.Core)
+	cloudAccount := &model.CloudAccount{
+		Name:        "aws",
+		Provider:    "aws",
+		Credentials: map[string]string{"access_key": "key", "secret_key": "secret"},
+	}
+	if err := srv.Core.DB.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+
+	sg := srv.Core.NewAPIClient("token", admin.APIToken)
+
+	kube := &model.Kube{
+		CloudAccountID: cloudAccount.ID,
+		Name:           "config",
+		MasterNodeSize: "m4.large",
+		NodeSizes:      []string{"m4.large"},
+		ProviderConfig: map[string]interface{}{
+			"region":             "us-east-1",
+			"availability_zones": []map[string]interface{}{{"name": "us-east-1b", "public_subnet_id": "subnet-1"}},
+			"master_id":          "i-1",
+			"vpc_id":             "vpc-1",
+		},
+	}
+	createErr := sg.Kubes.Create(kube)
+
+	Convey("Given an admin", t, func() {
+
+		Convey("When the admin Creates a Kube, giving readonly fields of its provider config", func() {
+			saved := new(model.Kube)
+			srv.Core.DB.First(saved, *kube.ID)
+
+			Convey("Its config should be stored as the provider's, with defaults, and without readonly fields", func() {
+				So(createErr, ShouldBeNil)
+				config, ok := saved.ProviderConfig.(*model.AWSKubeConfig)
+				So(ok, ShouldBeTrue)
+				So(config.Region, ShouldEqual, "us-east-1")
+				So(config.VPCIPRange, ShouldEqual, "172.20.0.0/16")
+				So(config.MasterID, ShouldBeEmpty)
+			})
+
+			Convey("When the admin Updates a field of its config", func() {
+				update := &model.Kube{
+					ProviderConfig: map[string]interface{}{"bastion_node_size": "t2.micro"},
+				}
+				updateErr := sg.Kubes.Update(kube.ID, update)
+
+				updated := new(model.Kube)
+				srv.Core.DB.First(updated, *kube.ID)
+
+				Convey("The rest of its config should be kept", func() {
+					So(updateErr, ShouldBeNil)
+					config := updated.ProviderConfig.(*model.AWSKubeConfig)
+					So(config.BastionNodeSize, ShouldEqual, "t2.micro")
+					So(config.Region, ShouldEqual, "us-east-1")
+					So(config.AvailabilityZones, ShouldHaveLength, 1)
+				})
+			})
+
+			Convey("When the admin Updates createonly fields of its config", func() {
+				update := &model.Kube{
+					ProviderConfig: map[string]interface{}{
+						"vpc_id":             "vpc-2",
+						"availability_zones": []map[string]interface{}{{"name": "us-east-1b", "public_subnet_id": "subnet-2"}},
+					},
+				}
+				updateErr := sg.Kubes.Update(kube.ID, update)
+
+				updated := new(model.Kube)
+				srv.Core.DB.First(updated, *kube.ID)
+
+				Convey("They should be kept as they were created", func() {
+					So(updateErr, ShouldBeNil)
+					config := updated.ProviderConfig.(*model.AWSKubeConfig)
+					So(config.VPCID, ShouldEqual, "vpc-1")
+					So(config.AvailabilityZones, ShouldHaveLength, 1)
+					So(config.AvailabilityZones[0].PublicSubnetID, ShouldEqual, "subnet-1")
+				})
+			})
+		})
+
+		Convey("When the admin Creates a Kube with a config that is not its provider's", func() {
+			kube := &model.Kube{
+				CloudAccountID: cloudAccount.ID,
+				Name:           "invalid",
+				MasterNodeSize: "m4.large",
+				NodeSizes:      []string{"m4.large"},
+				ProviderConfig: map[string]interface{}{"region": 1},
+			}
+			err := sg.Kubes.Create(kube)
+
+			Convey("It should not be created", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "ProviderConfig")
+			})
+		})
+	})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLeaderElection(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	Convey("Given two servers on the same database", t, func() {
+		cores := []*core.Core{restartCore(srv.Core), restartCore(srv.Core)}
+
+		Convey("When they start at the same time", func() {
+			var wg sync.WaitGroup
+			for _, c := range cores {
+				wg.Add(1)
+				go func(c *core.Core) {
+					defer wg.Done()
+					c.InitializeBackground()
+				}(c)
+			}
+			wg.Wait()
+
+			var leader, follower *core.Core
+			for _, c := range cores {
+				if c.Leader.IsLeader() {
+					leader = c
+				} else {
+					follower = c
+				}
+			}
+
+			Convey("Exactly one of them should be elected leader, and hold the Lease", func() {
+				So(leader, ShouldNotBeNil)
+				So(follower, ShouldNotBeNil)
+
+				lease := new(model.Lease)
+				So(srv.Core.DB.Where("name = ?", "leader").First(lease), ShouldBeNil)
+				So(lease.HolderID, ShouldEqual, leader.Leader.ID())
+			})
+
+			Convey("When the leader shuts down, and another server starts", func() {
+				So(leader.Shutdown(context.Background()), ShouldBeNil)
+
+				next := restartCore(srv.Core)
+				next.InitializeBackground()
+				defer next.Shutdown(context.Background())
+
+				Convey("The new server should take over the released Lease, and the follower stay a follower", func() {
+					So(next.Leader.IsLeader(), ShouldBeTrue)
+					So(follower.Leader.IsLeader(), ShouldBeFalse)
+				})
+			})
+
+			for _, c := range cores {
+				c.Shutdown(context.Background())
+			}
+		})
+	})
+}
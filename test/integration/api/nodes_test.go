@@ -0,0 +1,156 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/fake"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNodesProvisionRetry(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kube := createKube(srv.Core, kubeOptions{Name: "retries"})
+
+	Convey("Given a Node of a provider that creates Nodes idempotently", t, func() {
+		node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+		So(srv.Core.DB.Create(node), ShouldBeNil)
+
+		Convey("When it is provisioned again, as if the first attempt failed after creating the server", func() {
+			firstErr := srv.Core.Nodes.Provision(node.ID, node).Now()
+			serverID := node.ProviderID
+			So(srv.Core.DB.Model(node).Update("provider_id", "").Error, ShouldBeNil)
+			secondErr := srv.Core.Nodes.Provision(node.ID, node).Now()
+
+			Convey("It should have the server of the first attempt", func() {
+				So(firstErr, ShouldBeNil)
+				So(secondErr, ShouldBeNil)
+				So(serverID, ShouldNotBeEmpty)
+				So(node.ProviderID, ShouldEqual, serverID)
+				_, err := fake.DefaultCloud.Server(serverID)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestNodesSpot(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubes := 0
+
+	// createSpotKube creates a Kube whose fake.small Nodes are spot.
+	createSpotKube := func(credentials map[string]string, fallback bool) *model.Kube {
+		kubes++
+		return createKube(srv.Core, kubeOptions{
+			Name:        fmt.Sprintf("spot-%d", kubes),
+			Credentials: credentials,
+			NodeSizes:   []string{"fake.small", "fake.large"},
+			SpotPolicy:  &model.SpotPolicy{NodeSizes: []string{"fake.small"}, OnDemandFallback: fallback},
+		})
+	}
+
+	provisionNode := func(kube *model.Kube, size string) (*model.Node, error) {
+		node := &model.Node{KubeID: kube.ID, Size: size}
+		So(srv.Core.DB.Create(node), ShouldBeNil)
+		return node, srv.Core.Nodes.Provision(node.ID, node).Now()
+	}
+
+	Convey("Given a Kube with a SpotPolicy of node sizes it doesn't have", t, func() {
+		cloudAccount := &model.CloudAccount{Name: "spot-invalid", Provider: "fake", Credentials: map[string]string{}}
+		So(srv.Core.DB.Create(cloudAccount), ShouldBeNil)
+		kube := &model.Kube{
+			CloudAccountID: cloudAccount.ID,
+			Name:           "spot-invalid",
+			MasterNodeSize: "fake.small",
+			NodeSizes:      []string{"fake.small"},
+			SpotPolicy:     &model.SpotPolicy{NodeSizes: []string{"fake.large"}},
+			Username:       "user",
+			Password:       "password",
+		}
+
+		Convey("When it is created", func() {
+			err := srv.Core.Kubes.Create(kube)
+
+			Convey("It should be rejected", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "fake.large is not one of the Kube's node sizes")
+			})
+		})
+	})
+
+	Convey("Given a Kube with a SpotPolicy", t, func() {
+		kube := createSpotKube(map[string]string{}, false)
+
+		Convey("When Nodes of spot and other sizes are provisioned", func() {
+			spotNode, spotErr := provisionNode(kube, "fake.small")
+			onDemandNode, onDemandErr := provisionNode(kube, "fake.large")
+
+			Convey("Only those of the spot sizes should be spot", func() {
+				So(spotErr, ShouldBeNil)
+				So(spotNode.Spot, ShouldBeTrue)
+				server, err := fake.DefaultCloud.Server(spotNode.ProviderID)
+				So(err, ShouldBeNil)
+				So(server.Spot, ShouldBeTrue)
+
+				So(onDemandErr, ShouldBeNil)
+				So(onDemandNode.Spot, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a spot Node is interrupted", func() {
+			node, err := provisionNode(kube, "fake.small")
+			So(err, ShouldBeNil)
+			other, err := provisionNode(kube, "fake.small")
+			So(err, ShouldBeNil)
+
+			So(fake.DefaultCloud.InterruptServer(node.ProviderID), ShouldBeNil)
+			replaceErr := srv.Core.Nodes.ReplaceInterrupted(kube)
+
+			var nodes []*model.Node
+			So(srv.Core.DB.Where("kube_id = ?", kube.ID).Order("id").Find(&nodes), ShouldBeNil)
+
+			Convey("It should be deleted, and replaced with a Node of the same size and zone", func() {
+				So(replaceErr, ShouldBeNil)
+				So(nodes, ShouldHaveLength, 2)
+				So(*nodes[0].ID, ShouldEqual, *other.ID)
+				So(*nodes[1].ID, ShouldNotEqual, *node.ID)
+				So(nodes[1].Size, ShouldEqual, "fake.small")
+				So(nodes[1].AvailabilityZone, ShouldEqual, node.AvailabilityZone)
+
+				So(srv.Core.Nodes.Provision(nodes[1].ID, nodes[1]).Now(), ShouldBeNil)
+				So(nodes[1].Spot, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a Kube with a SpotPolicy and no spot capacity", t, func() {
+		credentials := map[string]string{"spot_unavailable": "true"}
+
+		Convey("When a spot Node is provisioned with on-demand fallback", func() {
+			node, err := provisionNode(createSpotKube(credentials, true), "fake.small")
+
+			Convey("It should be created on-demand", func() {
+				So(err, ShouldBeNil)
+				So(node.ProviderID, ShouldNotBeEmpty)
+				So(node.Spot, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a spot Node is provisioned without on-demand fallback", func() {
+			node, err := provisionNode(createSpotKube(credentials, false), "fake.small")
+
+			Convey("It should fail", func() {
+				So(err, ShouldEqual, fake.ErrorNoSpotCapacity)
+				So(node.ProviderID, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/core"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/openstack"
+	"github.com/supergiant/supergiant/test/fakekube"
+	"github.com/supergiant/supergiant/test/fakeopenstack"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOpenStackProvider(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	cloud := fakeopenstack.NewServer("supergiant", "secret", "kubes")
+	defer cloud.Close()
+	openstack.UserdataDir = "../../../config"
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+	kubernetes.Volumes = cloud
+
+	kube := createOpenStackKube(srv.Core, cloud.Credentials())
+	// Nodes of the Kube are launched in its security group.
+	cloud.AddSecurityGroup(kube.Name)
+
+	Convey("Given an OpenStack CloudAccount", t, func() {
+
+		Convey("When a Kube is provisioned, and then deleted", func() {
+			newKube := &model.Kube{
+				CloudAccountID: kube.CloudAccountID,
+				Name:           "new",
+				MasterNodeSize: "m1.medium",
+				NodeSizes:      []string{"m1.small"},
+				Username:       "user",
+				Password:       "password",
+				ProviderConfig: openStackKubeConfig(),
+			}
+			So(srv.Core.DB.Create(newKube), ShouldBeNil)
+
+			// The first minion registers with Kubernetes.
+			kubernetes.AddNode(&model.Node{Name: "new-minion-abcde"})
+
+			provisionErr := srv.Core.Kubes.Provision(newKube.ID, newKube).Now()
+			master := cloud.ServerByName("new-master")
+			group := cloud.SecurityGroup("new")
+			var rules []*openstack.SecurityGroupRule
+			if group != nil {
+				rules = cloud.SecurityGroupRules(group.ID)
+			}
+			config := *newKube.ProviderConfig.(*model.OpenStackKubeConfig)
+			var nodes []*model.Node
+			srv.Core.DB.Where("kube_id = ?", newKube.ID).Find(&nodes)
+
+			deleteErr := srv.Core.Kubes.Delete(newKube.ID, newKube).Now()
+
+			Convey("The security group, master server and first Node should be created, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(newKube.Ready, ShouldBeTrue)
+				So(group, ShouldNotBeNil)
+				So(config.SecurityGroupID, ShouldEqual, group.ID)
+				So(rules, ShouldHaveLength, 5)
+				So(master, ShouldNotBeNil)
+				So(config.MasterID, ShouldEqual, master.ID)
+				So(config.MasterPrivateIP, ShouldEqual, master.IP("fixed"))
+				So(newKube.MasterPublicIP, ShouldEqual, master.IP("fixed"))
+				So(nodes, ShouldHaveLength, 1)
+				So(deleteErr, ShouldBeNil)
+				So(cloud.ServerByName("new-master"), ShouldBeNil)
+				So(cloud.SecurityGroup("new"), ShouldBeNil)
+			})
+		})
+
+		Convey("When a CloudAccount is created with a wrong password", func() {
+			credentials := cloud.Credentials()
+			credentials["password"] = "wrong"
+			err := srv.Core.CloudAccounts.Create(&model.CloudAccount{
+				Name:        "bad",
+				Provider:    "openstack",
+				Credentials: credentials,
+			})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "401")
+			})
+		})
+
+		Convey("When an Entrypoint is provisioned, given a port, a Node is added, and then all are deleted", func() {
+			entrypoint := &model.Entrypoint{KubeID: kube.ID, Name: "web"}
+			So(srv.Core.DB.Create(entrypoint), ShouldBeNil)
+			provisionErr := srv.Core.Entrypoints.Provision(entrypoint.ID, entrypoint).Now()
+			lb := *cloud.LoadBalancer("sg-web")
+
+			addErr := srv.Core.Entrypoints.SetPort(entrypoint.ID, entrypoint, 80, 30001)
+			listener := cloud.Listener("sg-web-80")
+
+			node := &model.Node{KubeID: kube.ID, Size: "m1.small"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+			nodeErr := srv.Core.Nodes.Provision(node.ID, node).Now()
+			server := cloud.ServerByName(node.Name)
+			members := cloud.Members("sg-web-80")
+
+			nodeDeleteErr := srv.Core.Nodes.Delete(node.ID, node).Now()
+			membersAfterNodeDelete := cloud.Members("sg-web-80")
+
+			removeErr := srv.Core.Entrypoints.RemovePort(entrypoint.ID, entrypoint, 80)
+			listenerAfterRemove := cloud.Listener("sg-web-80")
+
+			srv.Core.Entrypoints.SetPort(entrypoint.ID, entrypoint, 443, 30002)
+			deleteErr := srv.Core.Entrypoints.Delete(entrypoint.ID, entrypoint).Now()
+
+			Convey("The load balancer should listen on the port, with the Node as a member of its pool until it is deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(entrypoint.Address, ShouldEqual, lb.VipAddress)
+				So(lb.VipSubnetID, ShouldEqual, "subnet-1")
+				So(addErr, ShouldBeNil)
+				So(listener, ShouldNotBeNil)
+				So(listener.ProtocolPort, ShouldEqual, 80)
+				So(nodeErr, ShouldBeNil)
+				So(server, ShouldNotBeNil)
+				So(node.ProviderID, ShouldEqual, server.ID)
+				So(members, ShouldHaveLength, 1)
+				So(members[0].Address, ShouldEqual, server.IP("fixed"))
+				So(members[0].ProtocolPort, ShouldEqual, 30001)
+				So(nodeDeleteErr, ShouldBeNil)
+				So(membersAfterNodeDelete, ShouldBeEmpty)
+				So(cloud.ServerByName(node.Name), ShouldBeNil)
+				So(removeErr, ShouldBeNil)
+				So(listenerAfterRemove, ShouldBeNil)
+				So(deleteErr, ShouldBeNil)
+				So(cloud.Listener("sg-web-443"), ShouldBeNil)
+				So(cloud.LoadBalancer("sg-web"), ShouldBeNil)
+			})
+		})
+
+		Convey("When an Instance with a Volume is started, and then stopped", func() {
+			node := &model.Node{KubeID: kube.ID, Size: "m1.small"}
+			So(srv.Core.DB.Create(node), ShouldBeNil)
+			So(srv.Core.Nodes.Provision(node.ID, node).Now(), ShouldBeNil)
+			kubernetes.AddNode(node)
+			userData := cloud.UserData(node.ProviderID)
+
+			app := &model.App{KubeID: kube.ID, Name: "cinder"}
+			So(srv.Core.DB.Create(app), ShouldBeNil)
+			So(srv.Core.Apps.Provision(app.ID, app).Now(), ShouldBeNil)
+			component := &model.Component{AppID: app.ID, Name: "db"}
+			So(srv.Core.DB.Create(component), ShouldBeNil)
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Name: "db-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+			attached := *cloud.Volume(instance.Volumes[0].ProviderID)
+			rc, rcErr := srv.Core.K8S(kube).ReplicationControllers(app.Name).Get(instance.Name)
+
+			stopErr := srv.Core.Instances.Stop(instance.ID, instance).Now()
+			stopped := *cloud.Volume(instance.Volumes[0].ProviderID)
+
+			Convey("The pod should mount the Cinder volume, which Kubernetes' OpenStack cloud provider attaches to its Node", func() {
+				So(userData, ShouldContainSubstring, "cloud: openstack")
+				So(userData, ShouldContainSubstring, `username = "supergiant"`)
+				So(userData, ShouldContainSubstring, `tenant-name = "kubes"`)
+				So(startErr, ShouldBeNil)
+				So(rcErr, ShouldBeNil)
+				So(rc.Spec.Template.Spec.Volumes[0].Cinder.VolumeID, ShouldEqual, attached.ID)
+				So(attached.Attachments, ShouldResemble, []map[string]string{{"server_id": node.ProviderID}})
+				So(stopErr, ShouldBeNil)
+				So(stopped.Attachments, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a Volume is provisioned, resized, and then deleted", func() {
+			volume := &model.Volume{KubeID: kube.ID, InstanceID: new(int64), Name: "data", Size: 10}
+			So(srv.Core.DB.DB.Create(volume).Error, ShouldBeNil)
+
+			provisionErr := srv.Core.Volumes.Provision(volume.ID, volume).Now()
+			created := *cloud.Volume(volume.ProviderID)
+
+			So(srv.Core.DB.Model(volume).Update("size", 20).Error, ShouldBeNil)
+			resizeErr := srv.Core.Volumes.Resize(volume.ID, volume).Now()
+			resized := *cloud.Volume(volume.ProviderID)
+
+			deleteErr := srv.Core.Volumes.Delete(volume.ID, volume).Now()
+
+			Convey("Its Cinder volume should be created, extended, and then deleted", func() {
+				So(provisionErr, ShouldBeNil)
+				So(created.Name, ShouldEqual, "test-data-"+strconv.FormatInt(*volume.ID, 10))
+				So(created.Size, ShouldEqual, 10)
+				So(created.VolumeType, ShouldEqual, "ssd")
+				So(resizeErr, ShouldBeNil)
+				So(resized.Size, ShouldEqual, 20)
+				So(deleteErr, ShouldBeNil)
+				So(cloud.Volume(volume.ProviderID), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func openStackKubeConfig() *model.OpenStackKubeConfig {
+	return &model.OpenStackKubeConfig{
+		TenantName: "kubes",
+		NetworkID:  "network-1",
+		SubnetID:   "subnet-1",
+		ImageID:    "image-1",
+		VolumeType: "ssd",
+	}
+}
+
+// createOpenStackKube creates a ready OpenStack Kube, without provisioning it.
+func createOpenStackKube(c *core.Core, credentials map[string]string) *model.Kube {
+	cloudAccount := &model.CloudAccount{
+		Name:        "openstack",
+		Provider:    "openstack",
+		Credentials: credentials,
+	}
+	if err := c.DB.Create(cloudAccount); err != nil {
+		panic(err)
+	}
+	kube := &model.Kube{
+		CloudAccountID: cloudAccount.ID,
+		Name:           "test",
+		MasterNodeSize: "m1.medium",
+		NodeSizes:      []string{"m1.small"},
+		Username:       "user",
+		Password:       "password",
+		ProviderConfig: openStackKubeConfig(),
+		Ready:          true,
+	}
+	if err := c.DB.Create(kube); err != nil {
+		panic(err)
+	}
+	return kube
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPortExternalAddresses(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+
+	kube := createKube(srv.Core)
+
+	// A Node in a private subnet, with no public IP.
+	node := &model.Node{KubeID: kube.ID, Size: "fake.small", ProviderID: "i-private", Name: "private-node"}
+	if err := srv.Core.DB.Create(node); err != nil {
+		panic(err)
+	}
+	kubernetes.AddNode(node)
+
+	// A public Node, of another Kube.
+	otherKube := createKube(srv.Core, kubeOptions{Name: "other", CloudAccount: kube.CloudAccount, Unready: true})
+	if err := srv.Core.DB.Create(&model.Node{KubeID: otherKube.ID, Size: "fake.small", ExternalIP: "203.0.113.5"}); err != nil {
+		panic(err)
+	}
+
+	app := &model.App{KubeID: kube.ID, Name: "web"}
+	if err := srv.Core.DB.Create(app); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.Apps.Provision(app.ID, app).Now(); err != nil {
+		panic(err)
+	}
+	component := &model.Component{AppID: app.ID, Name: "nginx"}
+	if err := srv.Core.DB.Create(component); err != nil {
+		panic(err)
+	}
+	release := &model.Release{
+		ComponentID: component.ID,
+		Config: &model.ComponentConfig{
+			Containers: []*model.ContainerBlueprint{
+				{
+					Image: "nginx",
+					Ports: []*model.Port{{Protocol: "TCP", Number: 80, Public: true, PerInstance: true}},
+				},
+			},
+		},
+	}
+	if err := srv.Core.DB.Create(release); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error; err != nil {
+		panic(err)
+	}
+
+	startInstance := func(num int) (*model.Instance, error) {
+		instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: num, Name: fmt.Sprintf("nginx-%d", num)}
+		if err := srv.Core.DB.Create(instance); err != nil {
+			return nil, err
+		}
+		return instance, srv.Core.Instances.Start(instance.ID, instance).Now()
+	}
+
+	Convey("Given a public Port without an Entrypoint", t, func() {
+
+		Convey("When an Instance is started while none of the Kube's Nodes has a public IP", func() {
+			instance, err := startInstance(0)
+
+			Convey("It should have no external address, not even on another Kube's Node", func() {
+				So(err, ShouldBeNil)
+				So(instance.Addresses.External, ShouldBeEmpty)
+				So(instance.Addresses.Internal, ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When an Instance is started once a Node of the Kube has a public IP", func() {
+			So(srv.Core.DB.Model(node).Update("external_ip", "198.51.100.7").Error, ShouldBeNil)
+			instance, err := startInstance(1)
+
+			Convey("Its external address should be the node port on that Node", func() {
+				So(err, ShouldBeNil)
+				So(instance.Addresses.External, ShouldHaveLength, 1)
+				So(strings.HasPrefix(instance.Addresses.External[0].Address, "198.51.100.7:"), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProvidersList(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user := createUser(srv.Core)
+
+	Convey("Given a user", t, func() {
+
+		Convey("When the user Lists Providers", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			var providers []*model.Provider
+			err := sg.Providers.List(&providers)
+
+			Convey("They should see the registered providers, with their schemas", func() {
+				So(err, ShouldBeNil)
+				So(len(providers), ShouldEqual, 6)
+				So(providers[0].Name, ShouldEqual, "aws")
+				So(providers[0].KubeConfig.Fields[0].Name, ShouldEqual, "region")
+				So(providers[1].Name, ShouldEqual, "digitalocean")
+				So(providers[1].KubeConfig.Fields[0].Name, ShouldEqual, "region")
+				So(providers[2].Name, ShouldEqual, "external")
+				So(providers[2].KubeConfig.Fields[0].Name, ShouldEqual, "host")
+				So(providers[2].External, ShouldBeTrue)
+				So(providers[3].Name, ShouldEqual, "fake")
+				So(providers[3].KubeConfig, ShouldBeNil)
+				So(providers[4].Name, ShouldEqual, "gce")
+				So(providers[4].KubeConfig.Fields[0].Name, ShouldEqual, "zone")
+				So(providers[5].Name, ShouldEqual, "openstack")
+				So(providers[5].KubeConfig.Fields[0].Name, ShouldEqual, "region")
+			})
+		})
+	})
+}
+
+func TestCloudAccountsCreateWithProviders(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user := createUser(srv.Core)
+
+	Convey("Given a user", t, func() {
+		sg := srv.Core.NewAPIClient("token", user.APIToken)
+
+		Convey("When the user Creates a CloudAccount with an unregistered provider", func() {
+			err := sg.CloudAccounts.Create(&model.CloudAccount{Name: "nope", Provider: "nope"})
+
+			Convey("They should receive an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the user Creates a CloudAccount with credentials its provider doesn't take", func() {
+			err := sg.CloudAccounts.Create(&model.CloudAccount{
+				Name:        "typo",
+				Provider:    "fake",
+				Credentials: map[string]string{"latncy": "1s"},
+			})
+
+			Convey("They should receive an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the user Creates a CloudAccount of the fake provider", func() {
+			err := sg.CloudAccounts.Create(&model.CloudAccount{
+				Name:        "fake",
+				Provider:    "fake",
+				Credentials: map[string]string{"latency": "1ms"},
+			})
+
+			Convey("It should be created", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
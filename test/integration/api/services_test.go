@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestServicesPauseAndResume(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	user, admin := createUserAndAdmin(srv.Core)
+
+	service := &model.Service{
+		Name:     "capacity_service",
+		Interval: 30,
+	}
+	srv.Core.DB.Create(service)
+
+	Convey("Given a user, an admin, and a Service", t, func() {
+
+		Convey("When the user Pauses the Service", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			err := sg.Services.Pause(service.ID, new(model.Service))
+
+			Convey("They should receive a 403 Forbidden error", func() {
+				So(err.(*model.Error).Status, ShouldEqual, 403)
+			})
+		})
+
+		Convey("When the admin Pauses the Service", func() {
+			sg := srv.Core.NewAPIClient("token", admin.APIToken)
+			paused := new(model.Service)
+			err := sg.Services.Pause(service.ID, paused)
+
+			Convey("The Service should be paused", func() {
+				So(err, ShouldBeNil)
+				So(paused.Paused, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the admin Resumes the Service", func() {
+			sg := srv.Core.NewAPIClient("token", admin.APIToken)
+			resumed := new(model.Service)
+			err := sg.Services.Resume(service.ID, resumed)
+
+			Convey("The Service should not be paused", func() {
+				So(err, ShouldBeNil)
+				So(resumed.Paused, ShouldBeFalse)
+			})
+		})
+
+		Convey("When the admin Runs the Service now", func() {
+			sg := srv.Core.NewAPIClient("token", admin.APIToken)
+			requested := new(model.Service)
+			err := sg.Services.RunNow(service.ID, requested)
+
+			Convey("A run should be requested", func() {
+				So(err, ShouldBeNil)
+				So(requested.RunRequested, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestServicesOnEveryServer(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+
+	createUser(srv.Core)
+
+	Convey("Given a leader that expires sessions more often than a follower", t, func() {
+		leader := restartCore(srv.Core)
+		leader.ServiceIntervals = map[string]int{"session_expirer": 1}
+		leader.InitializeBackground()
+		defer leader.Shutdown(context.Background())
+		So(leader.Leader.IsLeader(), ShouldBeTrue)
+
+		follower := restartCore(srv.Core)
+		follower.ServiceIntervals = map[string]int{"session_expirer": 3}
+		follower.InitializeBackground()
+		defer follower.Shutdown(context.Background())
+		So(follower.Leader.IsLeader(), ShouldBeFalse)
+
+		Convey("When a session on the follower expires", func() {
+			session := &model.Session{User: &model.User{Username: "user", Password: "password"}}
+			So(follower.Sessions.Create(session), ShouldBeNil)
+			session.CreatedAt = time.Now().Add(-4 * time.Hour)
+
+			expired := waitFor(func() bool {
+				return len(follower.Sessions.List()) == 0
+			})
+
+			Convey("The follower should expire it, on its own schedule", func() {
+				So(expired, ShouldBeTrue)
+			})
+		})
+	})
+}
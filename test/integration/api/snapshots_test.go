@@ -0,0 +1,254 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/fake"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSnapshots(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+	kubernetes.Volumes = fake.DefaultCloud
+
+	user := createUser(srv.Core)
+	kube := createKube(srv.Core)
+
+	app := &model.App{KubeID: kube.ID, Name: "snapshots"}
+	if err := srv.Core.DB.Create(app); err != nil {
+		panic(err)
+	}
+
+	components := 0
+
+	Convey("Given an Instance with a Volume", t, func() {
+		components++
+		component := &model.Component{AppID: app.ID, Name: fmt.Sprintf("db-%d", components)}
+		So(srv.Core.DB.Create(component), ShouldBeNil)
+
+		release := &model.Release{
+			ComponentID: component.ID,
+			Config: &model.ComponentConfig{
+				Volumes: []*model.VolumeBlueprint{{
+					Name:           "data",
+					Type:           "gp2",
+					Size:           10,
+					SnapshotPolicy: &model.SnapshotPolicy{Interval: 1, Retention: 1},
+				}},
+				Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+			},
+		}
+		So(srv.Core.DB.Create(release), ShouldBeNil)
+		So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+		instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+		So(srv.Core.DB.Create(instance), ShouldBeNil)
+		So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+		volume := instance.Volumes[0]
+
+		// NOTE the test server isn't the leader, so the queued Actions of
+		// Snapshots are performed here.
+		scheduledSnapshots := func() (snapshots []*model.Snapshot) {
+			So(srv.Core.DB.Where("volume_id = ? AND scheduled = ?", volume.ID, true).Order("id").Find(&snapshots), ShouldBeNil)
+			return snapshots
+		}
+
+		Convey("When a Snapshot of the Volume is created, listed and deleted through the API", func() {
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+
+			snapshot := new(model.Snapshot)
+			createErr := sg.Volumes.Snapshots(volume.ID).Create(snapshot)
+			So(performQueued(srv.Core, snapshot, srv.Core.Snapshots.Provision(snapshot.ID, snapshot)), ShouldBeNil)
+
+			var listed []*model.Snapshot
+			listErr := sg.Volumes.Snapshots(volume.ID).List(&listed)
+
+			deleteErr := sg.Volumes.Snapshots(volume.ID).Delete(snapshot.ID, new(model.Snapshot))
+			So(performQueued(srv.Core, snapshot, srv.Core.Snapshots.Delete(snapshot.ID, snapshot)), ShouldBeNil)
+			_, cloudErr := fake.DefaultCloud.Snapshot(snapshot.ProviderID)
+
+			Convey("It should be taken of the Volume, and then removed", func() {
+				So(createErr, ShouldBeNil)
+				So(snapshot.VolumeName, ShouldEqual, "data")
+				So(snapshot.Size, ShouldEqual, 10)
+				So(snapshot.Ready, ShouldBeTrue)
+				So(listErr, ShouldBeNil)
+				So(listed, ShouldHaveLength, 1)
+				So(*listed[0].ID, ShouldEqual, *snapshot.ID)
+				So(deleteErr, ShouldBeNil)
+				So(cloudErr, ShouldEqual, fake.ErrorSnapshotNotFound)
+			})
+		})
+
+		Convey("When the SnapshotPolicy's interval passes twice", func() {
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			first := scheduledSnapshots()
+			So(first, ShouldHaveLength, 1)
+			So(performQueued(srv.Core, first[0], srv.Core.Snapshots.Provision(first[0].ID, first[0])), ShouldBeNil)
+
+			So(srv.Core.DB.Model(first[0]).Update("created_at", time.Now().Add(-2*time.Hour)).Error, ShouldBeNil)
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			second := scheduledSnapshots()
+			So(second, ShouldHaveLength, 2)
+			So(performQueued(srv.Core, second[1], srv.Core.Snapshots.Provision(second[1].ID, second[1])), ShouldBeNil)
+
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			var deleting []*model.Action
+			So(srv.Core.DB.Where("resource_uuid = ?", first[0].UUID).Find(&deleting), ShouldBeNil)
+
+			Convey("A Snapshot should be taken each time, and the first deleted past the retention of 1", func() {
+				So(deleting, ShouldHaveLength, 1)
+				So(deleting[0].Description, ShouldEqual, "deleting")
+			})
+		})
+
+		Convey("When a scheduled Snapshot fails to be taken, after one that is ready", func() {
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			first := scheduledSnapshots()[0]
+			So(performQueued(srv.Core, first, srv.Core.Snapshots.Provision(first.ID, first)), ShouldBeNil)
+
+			So(srv.Core.DB.Model(first).Update("created_at", time.Now().Add(-2*time.Hour)).Error, ShouldBeNil)
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			whileTaking := scheduledSnapshots()
+			So(whileTaking, ShouldHaveLength, 2)
+			failed := whileTaking[1]
+
+			// As if the leader's Action had run out of retries.
+			So(srv.Core.DB.Model(new(model.Action)).Where("resource_uuid = ?", failed.UUID).Updates(map[string]interface{}{"error": "Simulated failure of CreateSnapshot", "retries": 5}).Error, ShouldBeNil)
+			So(srv.Core.Snapshots.Schedule(), ShouldBeNil)
+			afterFailure := scheduledSnapshots()
+
+			var firstActions, failedActions []*model.Action
+			So(srv.Core.DB.Where("resource_uuid = ?", first.UUID).Find(&firstActions), ShouldBeNil)
+			So(srv.Core.DB.Where("resource_uuid = ?", failed.UUID).Find(&failedActions), ShouldBeNil)
+
+			Convey("Another should be taken, as the interval has passed since the ready one, which is kept while the failed one is deleted", func() {
+				So(afterFailure, ShouldHaveLength, 3)
+				So(firstActions, ShouldBeEmpty)
+				So(failedActions, ShouldHaveLength, 1)
+				So(failedActions[0].Description, ShouldEqual, "deleting")
+			})
+		})
+
+		Convey("When the Release is updated to restore the Volume from a Snapshot that isn't ready, or from one that doesn't exist", func() {
+			snapshot := &model.Snapshot{VolumeID: volume.ID}
+			So(srv.Core.Snapshots.Create(snapshot), ShouldBeNil)
+
+			updateRelease := func(snapshotID *int64) error {
+				return srv.Core.Releases.Update(release.ID, new(model.Release), &model.Release{
+					Config: &model.ComponentConfig{
+						Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10, SnapshotID: snapshotID}},
+						Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+					},
+				})
+			}
+			missingID := int64(999999)
+			notReadyErr := updateRelease(snapshot.ID)
+			missingErr := updateRelease(&missingID)
+
+			Convey("It should fail to validate, as when a Release is created", func() {
+				So(notReadyErr, ShouldNotBeNil)
+				So(notReadyErr.Error(), ShouldContainSubstring, "isn't ready")
+				So(missingErr, ShouldNotBeNil)
+				So(missingErr.Error(), ShouldContainSubstring, "is not a Snapshot")
+			})
+		})
+
+		Convey("When a Release restores the Volume from a ready Snapshot", func() {
+			snapshot := &model.Snapshot{VolumeID: volume.ID}
+			So(srv.Core.Snapshots.Create(snapshot), ShouldBeNil)
+
+			restoreRelease := func(size int) (*model.Release, error) {
+				restored := &model.Component{AppID: app.ID, Name: component.Name + "-restored"}
+				So(srv.Core.DB.Create(restored), ShouldBeNil)
+				m := &model.Release{
+					ComponentID: restored.ID,
+					Config: &model.ComponentConfig{
+						Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: size, SnapshotID: snapshot.ID}},
+						Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+					},
+				}
+				return m, srv.Core.Releases.Create(m)
+			}
+
+			_, notReadyErr := restoreRelease(10)
+			srv.Core.DB.Where("name = ?", component.Name+"-restored").Delete(new(model.Component))
+
+			So(performQueued(srv.Core, snapshot, srv.Core.Snapshots.Provision(snapshot.ID, snapshot)), ShouldBeNil)
+
+			_, tooSmallErr := restoreRelease(5)
+			srv.Core.DB.Where("name = ?", component.Name+"-restored").Delete(new(model.Component))
+
+			restored, err := restoreRelease(20)
+			So(err, ShouldBeNil)
+			restoredInstance := &model.Instance{ComponentID: restored.ComponentID, ReleaseID: restored.ID, Num: 0, Name: component.Name + "-restored-0"}
+			So(srv.Core.DB.Create(restoredInstance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(restoredInstance.ID, restoredInstance), ShouldBeNil)
+
+			Convey("Its Volume should be created from the Snapshot, and have its filesystem grown", func() {
+				So(notReadyErr, ShouldNotBeNil)
+				So(notReadyErr.Error(), ShouldContainSubstring, "isn't ready")
+				So(tooSmallErr, ShouldNotBeNil)
+				So(tooSmallErr.Error(), ShouldContainSubstring, "Size")
+
+				restoredVolume := new(model.Volume)
+				So(srv.Core.DB.First(restoredVolume, *restoredInstance.Volumes[0].ID), ShouldBeNil)
+				So(*restoredVolume.SnapshotID, ShouldEqual, *snapshot.ID)
+				So(restoredVolume.GrowFilesystem, ShouldBeTrue)
+
+				cloudVolume, err := fake.DefaultCloud.Volume(restoredVolume.ProviderID)
+				So(err, ShouldBeNil)
+				So(cloudVolume.SnapshotID, ShouldEqual, snapshot.ProviderID)
+				So(cloudVolume.Size, ShouldEqual, 20)
+			})
+
+			Convey("When the Snapshot is deleted through the API", func() {
+				sg := srv.Core.NewAPIClient("token", user.APIToken)
+				apiErr := sg.Snapshots.Delete(snapshot.ID, new(model.Snapshot))
+				deleteErr := srv.Core.Snapshots.Delete(snapshot.ID, snapshot).Now()
+				_, cloudErr := fake.DefaultCloud.Snapshot(snapshot.ProviderID)
+
+				Convey("It should be refused, as the Release restores from it", func() {
+					So(apiErr, ShouldNotBeNil)
+					So(apiErr.Error(), ShouldContainSubstring, "can't be deleted")
+					So(deleteErr, ShouldNotBeNil)
+					So(cloudErr, ShouldBeNil)
+				})
+			})
+
+			Convey("When a Volume is created from the Snapshot after it is gone", func() {
+				gone := &model.Volume{
+					InstanceID: restoredInstance.ID,
+					KubeID:     kube.ID,
+					Name:       "gone",
+					Type:       "gp2",
+					Size:       20,
+					SnapshotID: snapshot.ID,
+				}
+				So(srv.Core.DB.Create(gone), ShouldBeNil)
+				So(srv.Core.DB.Delete(snapshot), ShouldBeNil)
+				provisioned := new(model.Volume)
+				err := srv.Core.Volumes.Provision(gone.ID, provisioned).Now()
+
+				Convey("It should fail, rather than be created empty", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "no longer exists")
+					So(provisioned.ProviderID, ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
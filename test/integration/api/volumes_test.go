@@ -0,0 +1,518 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/supergiant/supergiant/pkg/guber"
+	"github.com/supergiant/supergiant/pkg/model"
+	"github.com/supergiant/supergiant/pkg/provider/fake"
+	"github.com/supergiant/supergiant/test/fakekube"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVolumes(t *testing.T) {
+	srv := newTestServer()
+	go srv.Start()
+	defer srv.Stop()
+
+	kubernetes := fakekube.NewServer()
+	defer kubernetes.Close()
+	kubernetes.Use(srv.Core)
+	kubernetes.Volumes = fake.DefaultCloud
+
+	kube := createKube(srv.Core, kubeOptions{Name: "volumes"})
+	node := &model.Node{KubeID: kube.ID, Size: "fake.small"}
+	if err := srv.Core.DB.Create(node); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.Nodes.Provision(node.ID, node).Now(); err != nil {
+		panic(err)
+	}
+	kubernetes.AddNode(node)
+
+	app := &model.App{KubeID: kube.ID, Name: "volumes"}
+	if err := srv.Core.DB.Create(app); err != nil {
+		panic(err)
+	}
+	if err := srv.Core.Apps.Provision(app.ID, app).Now(); err != nil {
+		panic(err)
+	}
+
+	user := createUser(srv.Core)
+
+	components := 0
+
+	Convey("Given a Component", t, func() {
+		components++
+		component := &model.Component{AppID: app.ID, Name: fmt.Sprintf("db-%d", components)}
+		So(srv.Core.DB.Create(component), ShouldBeNil)
+
+		createRelease := func(volume *model.VolumeBlueprint) error {
+			return srv.Core.Releases.Create(&model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{volume},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			})
+		}
+
+		Convey("When a Release has an io1 Volume without IOPS", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "data", Type: "io1", Size: 100})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "IOPS")
+			})
+		})
+
+		Convey("When a Release has a gp2 Volume with IOPS", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "data", Type: "gp2", Size: 100, IOPS: 1000})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "IOPS")
+			})
+		})
+
+		Convey("When a Release has an st1 Volume smaller than st1 allows", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "data", Type: "st1", Size: 10})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "Size")
+			})
+		})
+
+		Convey("When a Release has an io1 Volume with IOPS", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "data", Type: "io1", Size: 100, IOPS: 1000})
+
+			Convey("It should be created", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When a Release has a Volume smaller than in the current Release", func() {
+			current := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 20}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(current), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("current_release_id", current.ID).Error, ShouldBeNil)
+
+			err := createRelease(&model.VolumeBlueprint{Name: "data", Type: "gp2", Size: 10})
+
+			Convey("It should fail to validate, as Volumes can't shrink", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "shrink")
+			})
+		})
+
+		Convey("When an Instance is started with a Release which grows its Volume", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			target := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 20}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(target), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", target.ID).Error, ShouldBeNil)
+
+			pods := srv.Core.K8S(kube).Pods(app.Name)
+			grown := make(chan bool)
+			go func() {
+				grown <- waitFor(func() bool {
+					list, err := pods.Query(&guber.QueryParams{LabelSelector: "instance=" + instance.Name})
+					if err != nil || len(list.Items) == 0 {
+						return false
+					}
+					kubernetes.SetPodLog(app.Name, list.Items[0].Metadata.Name, "resize2fs 1.42.12\nfilesystem grown\n")
+					return true
+				})
+			}()
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+			rc, rcErr := srv.Core.K8S(kube).ReplicationControllers(app.Name).Get(instance.Name)
+			list, podsErr := pods.Query(&guber.QueryParams{LabelSelector: "instance=" + instance.Name})
+
+			Convey("Its pod should grow the filesystem on the Volume, which is then dropped from its RC", func() {
+				So(<-grown, ShouldBeTrue)
+				So(startErr, ShouldBeNil)
+				So(podsErr, ShouldBeNil)
+				So(list.Items, ShouldHaveLength, 1)
+				containers := list.Items[0].Spec.Containers
+				So(containers, ShouldHaveLength, 2)
+				So(containers[1].Name, ShouldEqual, "grow-filesystem-0")
+				So(containers[1].VolumeMounts[0].Name, ShouldEqual, "data")
+				So(rcErr, ShouldBeNil)
+				So(rc.Spec.Template.Spec.Containers, ShouldHaveLength, 1)
+
+				volume := new(model.Volume)
+				So(srv.Core.DB.First(volume, *instance.Volumes[0].ID), ShouldBeNil)
+				So(volume.Size, ShouldEqual, 20)
+				So(volume.GrowFilesystem, ShouldBeFalse)
+			})
+		})
+
+		Convey("When an Instance is started again with a grown Volume, and its RC is left from before the Volume grew", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+			So(srv.Core.Instances.Start(instance.ID, instance).Now(), ShouldBeNil)
+
+			// As if a start had been interrupted once it created the RC, and the
+			// Volume grew before the next.
+			So(srv.Core.DB.Model(instance).Update("started", false).Error, ShouldBeNil)
+			So(srv.Core.DB.Model(instance.Volumes[0]).Update("grow_filesystem", true).Error, ShouldBeNil)
+
+			pods := srv.Core.K8S(kube).Pods(app.Name)
+			grown := make(chan bool)
+			go func() {
+				grown <- waitFor(func() bool {
+					list, err := pods.Query(&guber.QueryParams{LabelSelector: "instance=" + instance.Name})
+					if err != nil || len(list.Items) == 0 || len(list.Items[0].Spec.Containers) < 2 {
+						return false
+					}
+					kubernetes.SetPodLog(app.Name, list.Items[0].Metadata.Name, "resize2fs 1.42.12\nfilesystem grown\n")
+					return true
+				})
+			}()
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+			rc, rcErr := srv.Core.K8S(kube).ReplicationControllers(app.Name).Get(instance.Name)
+
+			Convey("The RC should be replaced by one whose pod grows the filesystem", func() {
+				So(<-grown, ShouldBeTrue)
+				So(startErr, ShouldBeNil)
+				So(rcErr, ShouldBeNil)
+				So(rc.Spec.Template.Spec.Containers, ShouldHaveLength, 1)
+
+				volume := new(model.Volume)
+				So(srv.Core.DB.First(volume, *instance.Volumes[0].ID), ShouldBeNil)
+				So(volume.GrowFilesystem, ShouldBeFalse)
+			})
+		})
+
+		Convey("When an Instance with a gp2 Volume is started with a Release which makes it io1", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			target := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "io1", Size: 10, IOPS: 500}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(target), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", target.ID).Error, ShouldBeNil)
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+
+			Convey("Its Volume should be changed to io1 with the IOPS", func() {
+				So(startErr, ShouldBeNil)
+				So(instance.Volumes, ShouldHaveLength, 1)
+				volume := instance.Volumes[0]
+				So(volume.Type, ShouldEqual, "io1")
+				So(volume.IOPS, ShouldEqual, 500)
+
+				cloudVolume, err := fake.DefaultCloud.Volume(volume.ProviderID)
+				So(err, ShouldBeNil)
+				So(cloudVolume.Type, ShouldEqual, "io1")
+				So(cloudVolume.IOPS, ShouldEqual, 500)
+			})
+		})
+
+		Convey("When an Instance with a retained Volume is deleted, and created again", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10, ReclaimPolicy: model.ReclaimPolicyRetain}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+			volume := instance.Volumes[0]
+			So(srv.Core.Instances.Delete(instance.ID, instance).Now(), ShouldBeNil)
+
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			var retained []*model.Volume
+			listErr := sg.Volumes.ListRetained(&retained)
+
+			recreated := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(recreated), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(recreated.ID, recreated), ShouldBeNil)
+
+			Convey("The Volume should be listed as retained, and reattached to the new Instance", func() {
+				So(listErr, ShouldBeNil)
+				var found bool
+				for _, retainedVol := range retained {
+					found = found || *retainedVol.ID == *volume.ID
+				}
+				So(found, ShouldBeTrue)
+
+				So(recreated.Volumes, ShouldHaveLength, 1)
+				So(*recreated.Volumes[0].ID, ShouldEqual, *volume.ID)
+				So(recreated.Volumes[0].ProviderID, ShouldEqual, volume.ProviderID)
+
+				reattached := new(model.Volume)
+				So(srv.Core.DB.First(reattached, *volume.ID), ShouldBeNil)
+				So(*reattached.InstanceID, ShouldEqual, *recreated.ID)
+				So(reattached.Retained, ShouldBeFalse)
+			})
+		})
+
+		Convey("When an Instance whose Volume is snapshotted when reclaimed is deleted", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10, ReclaimPolicy: model.ReclaimPolicySnapshot}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+			volume := instance.Volumes[0]
+			deleteErr := srv.Core.Instances.Delete(instance.ID, instance).Now()
+
+			Convey("The Volume should be deleted, leaving a ready Snapshot of it", func() {
+				So(deleteErr, ShouldBeNil)
+				_, err := fake.DefaultCloud.Volume(volume.ProviderID)
+				So(err, ShouldEqual, fake.ErrorVolumeNotFound)
+
+				var snapshots []*model.Snapshot
+				So(srv.Core.DB.Where("volume_id = ?", volume.ID).Find(&snapshots), ShouldBeNil)
+				So(snapshots, ShouldHaveLength, 1)
+				So(snapshots[0].Ready, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a Volume which isn't retained is deleted through the API", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 10}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			sg := srv.Core.NewAPIClient("token", user.APIToken)
+			err := sg.Volumes.Delete(instance.Volumes[0].ID, new(model.Volume))
+
+			Convey("It should be refused", func() {
+				So(err, ShouldNotBeNil)
+				So(err.(*model.Error).Status, ShouldEqual, 400)
+			})
+		})
+
+		Convey("When a Release has an empty_dir Volume with a Size", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "scratch", Kind: model.VolumeKindEmptyDir, Size: 10})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "Size")
+			})
+		})
+
+		Convey("When a Release has an nfs Volume without a Server", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "assets", Kind: model.VolumeKindNFS, Path: "/exports/assets"})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "Server")
+			})
+		})
+
+		Convey("When the target Release is updated to shrink a Volume of the current Release, change its kind, or grow it", func() {
+			current := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 20}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(current), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("current_release_id", current.ID).Error, ShouldBeNil)
+			So(createRelease(&model.VolumeBlueprint{Name: "data", Type: "gp2", Size: 30}), ShouldBeNil)
+			target := new(model.Release)
+			So(srv.Core.DB.Where("component_id = ? AND id <> ?", component.ID, *current.ID).First(target), ShouldBeNil)
+
+			updateRelease := func(volume *model.VolumeBlueprint) error {
+				return srv.Core.Releases.Update(target.ID, new(model.Release), &model.Release{
+					Config: &model.ComponentConfig{
+						Volumes:    []*model.VolumeBlueprint{volume},
+						Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+					},
+				})
+			}
+			shrinkErr := updateRelease(&model.VolumeBlueprint{Name: "data", Type: "gp2", Size: 10})
+			kindErr := updateRelease(&model.VolumeBlueprint{Name: "data", Kind: model.VolumeKindEmptyDir})
+			growErr := updateRelease(&model.VolumeBlueprint{Name: "data", Type: "gp2", Size: 40})
+
+			Convey("Only growing it should be allowed, as when the Release is created", func() {
+				So(shrinkErr, ShouldNotBeNil)
+				So(shrinkErr.Error(), ShouldContainSubstring, "shrink")
+				So(kindErr, ShouldNotBeNil)
+				So(kindErr.Error(), ShouldContainSubstring, "Kind")
+				So(growErr, ShouldBeNil)
+			})
+		})
+
+		Convey("When a Release has an efs Volume on a Kube not on AWS", func() {
+			err := createRelease(&model.VolumeBlueprint{Name: "models", Kind: model.VolumeKindEFS, Server: "fs-0123abcd", Path: "/"})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "AWS")
+			})
+		})
+
+		Convey("When a Release has a host_path Volume", func() {
+			hostPath := func(path string) error {
+				return createRelease(&model.VolumeBlueprint{Name: "logs", Kind: model.VolumeKindHostPath, Path: path})
+			}
+			notAllowedErr := hostPath("/var/lib/data/logs")
+
+			srv.Core.HostPathPrefixes = []string{"/var/lib/data"}
+			defer func() { srv.Core.HostPathPrefixes = nil }()
+			outsideErr := hostPath("/var/lib/data/../../../etc")
+			err := hostPath("/var/lib/data/logs")
+
+			Convey("It should only be created in one of the host_path_prefixes", func() {
+				So(notAllowedErr, ShouldNotBeNil)
+				So(notAllowedErr.Error(), ShouldContainSubstring, "host_path_prefixes")
+				So(outsideErr, ShouldNotBeNil)
+				So(outsideErr.Error(), ShouldContainSubstring, "Path")
+				So(err, ShouldBeNil)
+			})
+
+			Convey("When the Release is updated to a host_path Volume outside of them", func() {
+				release := new(model.Release)
+				So(srv.Core.DB.Where("component_id = ?", component.ID).First(release), ShouldBeNil)
+				update := &model.Release{
+					Config: &model.ComponentConfig{
+						Volumes:    []*model.VolumeBlueprint{{Name: "logs", Kind: model.VolumeKindHostPath, Path: "/etc"}},
+						Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+					},
+				}
+				updateErr := srv.Core.Releases.Update(release.ID, new(model.Release), update)
+
+				Convey("It should fail to validate", func() {
+					So(updateErr, ShouldNotBeNil)
+					So(updateErr.Error(), ShouldContainSubstring, "host_path_prefixes")
+				})
+			})
+		})
+
+		Convey("When a Release changes the kind of a Volume of the current Release", func() {
+			current := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes:    []*model.VolumeBlueprint{{Name: "data", Type: "gp2", Size: 20}},
+					Containers: []*model.ContainerBlueprint{{Image: "postgres"}},
+				},
+			}
+			So(srv.Core.DB.Create(current), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("current_release_id", current.ID).Error, ShouldBeNil)
+
+			err := createRelease(&model.VolumeBlueprint{Name: "data", Kind: model.VolumeKindEmptyDir})
+
+			Convey("It should fail to validate", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "Kind")
+			})
+		})
+
+		Convey("When an Instance is started with ebs, empty_dir and nfs Volumes", func() {
+			release := &model.Release{
+				ComponentID: component.ID,
+				Config: &model.ComponentConfig{
+					Volumes: []*model.VolumeBlueprint{
+						{Name: "data", Type: "gp2", Size: 10},
+						{Name: "scratch", Kind: model.VolumeKindEmptyDir},
+						{Name: "assets", Kind: model.VolumeKindNFS, Server: "10.0.0.5", Path: "/exports/assets", ReadOnly: true},
+					},
+					Containers: []*model.ContainerBlueprint{{Image: "nginx"}},
+				},
+			}
+			So(srv.Core.DB.Create(release), ShouldBeNil)
+			So(srv.Core.DB.Model(component).Update("target_release_id", release.ID).Error, ShouldBeNil)
+
+			instance := &model.Instance{ComponentID: component.ID, ReleaseID: release.ID, Num: 0, Name: component.Name + "-0"}
+			So(srv.Core.DB.Create(instance), ShouldBeNil)
+			So(srv.Core.Instances.CreateVolumes(instance.ID, instance), ShouldBeNil)
+
+			startErr := srv.Core.Instances.Start(instance.ID, instance).Now()
+			rc, rcErr := srv.Core.K8S(kube).ReplicationControllers(app.Name).Get(instance.Name)
+
+			Convey("Only the ebs Volume should be created, and the pod should have all three", func() {
+				So(startErr, ShouldBeNil)
+				So(instance.Volumes, ShouldHaveLength, 1)
+				So(instance.Volumes[0].Name, ShouldEqual, "data")
+
+				So(rcErr, ShouldBeNil)
+				kubeVolumes := rc.Spec.Template.Spec.Volumes
+				So(kubeVolumes, ShouldHaveLength, 3)
+				So(kubeVolumes[0].AwsElasticBlockStore.VolumeID, ShouldEqual, instance.Volumes[0].ProviderID)
+				So(kubeVolumes[1].Name, ShouldEqual, "scratch")
+				So(kubeVolumes[1].EmptyDir, ShouldNotBeNil)
+				So(kubeVolumes[1].AwsElasticBlockStore, ShouldBeNil)
+				So(kubeVolumes[2].NFS, ShouldResemble, &guber.NFS{Server: "10.0.0.5", Path: "/exports/assets", ReadOnly: true})
+			})
+		})
+	})
+}